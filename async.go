@@ -0,0 +1,164 @@
+package framework
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/gomega"
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+const (
+	// AsyncTimeoutEnv defines env of default timeout of eventually
+	// and consistently, e.g. 10s
+	AsyncTimeoutEnv = "ALOE_ASYNC_TIMEOUT"
+
+	// AsyncIntervalEnv defines env of default interval of eventually
+	// and consistently, e.g. 1s
+	AsyncIntervalEnv = "ALOE_ASYNC_INTERVAL"
+)
+
+const (
+	defaultTimeout  = 1 * time.Second
+	defaultInterval = 100 * time.Millisecond
+)
+
+// asyncDefaults defines default timeout and interval of async checking
+type asyncDefaults struct {
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// WithAsyncDefaults sets default timeout and interval of eventually and
+// consistently, they are used if a response doesn't set its own values
+func WithAsyncDefaults(timeout, interval time.Duration) Option {
+	return func(gf *genericFramework) {
+		gf.async = asyncDefaults{
+			timeout:  timeout,
+			interval: interval,
+		}
+	}
+}
+
+// loadEnv returns default values which are overridden by env
+func (d asyncDefaults) loadEnv() (asyncDefaults, error) {
+	for env, p := range map[string]*time.Duration{
+		AsyncTimeoutEnv:  &d.timeout,
+		AsyncIntervalEnv: &d.interval,
+	} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return d, fmt.Errorf("can't parse env %v: %v", env, err)
+		}
+		*p = duration
+	}
+	return d, nil
+}
+
+// durations returns timeout and interval of async checking
+// Default values are used if they are not set, and only values set by
+// response are validated. Interval is limited by timeout if one of
+// them is a default value, e.g. only a short timeout is set
+func (d asyncDefaults) durations(timeout, interval *types.Duration) (time.Duration, time.Duration, error) {
+	t, i := d.timeout, d.interval
+	if timeout != nil {
+		if timeout.Duration <= 0 {
+			return 0, 0, fmt.Errorf("timeout %v should be positive", timeout.Duration)
+		}
+		t = timeout.Duration
+	}
+	if interval != nil {
+		if interval.Duration <= 0 {
+			return 0, 0, fmt.Errorf("interval %v should be positive", interval.Duration)
+		}
+		i = interval.Duration
+	}
+	if timeout != nil && interval != nil {
+		if err := validateDurations(t, i); err != nil {
+			return 0, 0, err
+		}
+	}
+	if i > t {
+		i = t
+	}
+	return t, i, nil
+}
+
+func validateDurations(timeout, interval time.Duration) error {
+	if interval <= 0 || interval >= timeout {
+		return fmt.Errorf("interval %v should be positive and less than timeout %v", interval, timeout)
+	}
+	return nil
+}
+
+// eventually polls response until it is matched or timeout is exceeded
+// Interval is multiplied by multiplier after each polling and limited
+// by maxInterval if it is positive
+func eventually(poll func() *http.Response, m gomegatypes.GomegaMatcher, timeout, interval time.Duration, ev *types.Eventually) {
+	multiplier := ev.Multiplier
+	if multiplier <= 1 {
+		gomega.Eventually(poll, timeout, interval).Should(m)
+		return
+	}
+	var maxInterval time.Duration
+	if ev.MaxInterval != nil {
+		maxInterval = ev.MaxInterval.Duration
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		resp := poll()
+		matched, err := m.Match(resp)
+		if err == nil && matched {
+			return
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			// failure is reported by fail handler of gomega like gomega.Eventually
+			last := &failedMatch{err: err}
+			if err == nil {
+				last.message = m.FailureMessage(resp)
+			}
+			gomega.ExpectWithOffset(1, resp).To(last, "Timed out after %v.", timeout)
+			return
+		}
+		// response is polled at deadline for the last time
+		if interval < remaining {
+			time.Sleep(interval)
+		} else {
+			time.Sleep(remaining)
+		}
+		interval = time.Duration(float64(interval) * multiplier)
+		if maxInterval > 0 && interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// failedMatch is the last failed result of a matcher
+// Response is not matched again because its body may have been read
+type failedMatch struct {
+	err     error
+	message string
+}
+
+// Match implements types.GomegaMatcher
+func (f *failedMatch) Match(actual interface{}) (bool, error) {
+	return false, f.err
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (f *failedMatch) FailureMessage(actual interface{}) string {
+	return f.message
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (f *failedMatch) NegatedFailureMessage(actual interface{}) string {
+	return f.message
+}
@@ -0,0 +1,119 @@
+package framework
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/gomega"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurations(t *testing.T) {
+	d := asyncDefaults{timeout: time.Second, interval: 100 * time.Millisecond}
+	duration := func(s string) *types.Duration {
+		if s == "" {
+			return nil
+		}
+		v, _ := time.ParseDuration(s)
+		return &types.Duration{Duration: v}
+	}
+	cases := []struct {
+		timeout  string
+		interval string
+		expected [2]time.Duration
+		hasError bool
+	}{
+		{"", "", [2]time.Duration{time.Second, 100 * time.Millisecond}, false},
+		{"5s", "", [2]time.Duration{5 * time.Second, 100 * time.Millisecond}, false},
+		{"", "10ms", [2]time.Duration{time.Second, 10 * time.Millisecond}, false},
+		// default interval is limited by timeout of response
+		{"50ms", "", [2]time.Duration{50 * time.Millisecond, 50 * time.Millisecond}, false},
+		{"", "2s", [2]time.Duration{time.Second, time.Second}, false},
+		{"2s", "500ms", [2]time.Duration{2 * time.Second, 500 * time.Millisecond}, false},
+		{"1s", "1s", [2]time.Duration{}, true},
+		{"0s", "", [2]time.Duration{}, true},
+		{"", "0s", [2]time.Duration{}, true},
+	}
+	for _, c := range cases {
+		timeout, interval, err := d.durations(duration(c.timeout), duration(c.interval))
+		if c.hasError {
+			assert.Error(t, err, "%v %v", c.timeout, c.interval)
+			continue
+		}
+		assert.NoError(t, err, "%v %v", c.timeout, c.interval)
+		assert.Equal(t, c.expected, [2]time.Duration{timeout, interval}, "%v %v", c.timeout, c.interval)
+	}
+}
+
+// pollMatcher matches the nth polled response
+type pollMatcher struct {
+	n int
+}
+
+func (m *pollMatcher) Match(actual interface{}) (bool, error) {
+	return actual.(*http.Response).StatusCode >= m.n, nil
+}
+
+func (m *pollMatcher) FailureMessage(actual interface{}) string {
+	return "poll is not matched"
+}
+
+func (m *pollMatcher) NegatedFailureMessage(actual interface{}) string {
+	return "poll is matched"
+}
+
+func TestEventuallyBackoff(t *testing.T) {
+	ms := func(n int) *types.Duration {
+		return &types.Duration{Duration: time.Duration(n) * time.Millisecond}
+	}
+	cases := []struct {
+		desc    string
+		ev      types.Eventually
+		timeout time.Duration
+		matched int
+		// gaps are minimal gaps between pollings
+		gaps   []time.Duration
+		failed bool
+	}{
+		{"multiplier", types.Eventually{Multiplier: 2}, time.Second, 4,
+			[]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}, false},
+		{"max interval", types.Eventually{Multiplier: 3, MaxInterval: ms(15)}, time.Second, 4,
+			[]time.Duration{10 * time.Millisecond, 15 * time.Millisecond, 15 * time.Millisecond}, false},
+		{"expiry", types.Eventually{Multiplier: 2}, 50 * time.Millisecond, 100,
+			[]time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, true},
+	}
+	for _, c := range cases {
+		failure := ""
+		gomega.RegisterFailHandler(func(message string, callerSkip ...int) {
+			failure = message
+		})
+		polls := []time.Time{}
+		poll := func() *http.Response {
+			polls = append(polls, time.Now())
+			return &http.Response{StatusCode: len(polls)}
+		}
+		start := time.Now()
+		eventually(poll, &pollMatcher{n: c.matched}, c.timeout, 10*time.Millisecond, &c.ev)
+		if c.failed {
+			assert.Contains(t, failure, "Timed out after 50ms.", c.desc)
+			assert.Contains(t, failure, "poll is not matched", c.desc)
+		} else {
+			assert.Empty(t, failure, c.desc)
+		}
+		if !assert.True(t, len(polls) > len(c.gaps), c.desc) {
+			continue
+		}
+		if c.failed {
+			// response is polled at deadline for the last time
+			assert.True(t, polls[len(polls)-1].Sub(start) >= c.timeout, c.desc)
+		} else {
+			assert.Len(t, polls, c.matched, c.desc)
+		}
+		for i := 1; i < len(polls) && i <= len(c.gaps); i++ {
+			gap := polls[i].Sub(polls[i-1])
+			assert.True(t, gap >= c.gaps[i-1], "%v: gap %v is %v, expected at least %v", c.desc, i, gap, c.gaps[i-1])
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caicloud/aloe/template"
+)
+
+// Cleaner defines an interface to clean resources created by cases
+type Cleaner interface {
+	// Name returns name of cleaner
+	Name() string
+
+	// Clean cleans resources by variables of context
+	Clean(vs map[string]template.Variable) error
+}
+
+// Dependent can be implemented by cleaner which should be
+// run after other cleaners, e.g. products should be deleted
+// before their category
+type Dependent interface {
+	// After returns names of cleaners which should be run before it
+	After() []string
+}
+
+// ContextCleaner can be implemented by cleaner which should stop when
+// ctx is done, e.g. timeout of cleaning is exceeded
+type ContextCleaner interface {
+	// CleanContext cleans resources like Clean until ctx is done
+	CleanContext(ctx context.Context, vs map[string]template.Variable) error
+}
+
+// Func defines function of cleaner
+type Func func(vs map[string]template.Variable) error
+
+// ContextFunc defines function of cleaner which stops when ctx is done
+type ContextFunc func(ctx context.Context, vs map[string]template.Variable) error
+
+// New returns a cleaner which is run after cleaners in after
+func New(name string, f Func, after ...string) Cleaner {
+	return &funcCleaner{
+		name:  name,
+		f:     f,
+		after: after,
+	}
+}
+
+type funcCleaner struct {
+	name  string
+	f     Func
+	after []string
+}
+
+func (c *funcCleaner) Name() string {
+	return c.name
+}
+
+func (c *funcCleaner) Clean(vs map[string]template.Variable) error {
+	return c.f(vs)
+}
+
+func (c *funcCleaner) After() []string {
+	return c.after
+}
+
+// NewWithContext returns a cleaner like New, but f is stopped by context,
+// e.g. when timeout of cleaning is exceeded
+func NewWithContext(name string, f ContextFunc, after ...string) Cleaner {
+	return &contextCleaner{
+		name:  name,
+		f:     f,
+		after: after,
+	}
+}
+
+type contextCleaner struct {
+	name  string
+	f     ContextFunc
+	after []string
+}
+
+func (c *contextCleaner) Name() string {
+	return c.name
+}
+
+func (c *contextCleaner) Clean(vs map[string]template.Variable) error {
+	return c.f(context.Background(), vs)
+}
+
+func (c *contextCleaner) CleanContext(ctx context.Context, vs map[string]template.Variable) error {
+	return c.f(ctx, vs)
+}
+
+func (c *contextCleaner) After() []string {
+	return c.after
+}
+
+// Sort returns cleaners in order of dependencies
+// Independent cleaners are sorted by name
+func Sort(cs map[string]Cleaner) ([]Cleaner, error) {
+	names := make([]string, 0, len(cs))
+	for name := range cs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := []Cleaner{}
+	// states: 1 means visiting, 2 means visited
+	states := map[string]int{}
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		stack = append(stack, name)
+		switch states[name] {
+		case 1:
+			return fmt.Errorf("dependency cycle of cleaners: %v", strings.Join(stack, " -> "))
+		case 2:
+			return nil
+		}
+		states[name] = 1
+		c := cs[name]
+		if d, ok := c.(Dependent); ok {
+			for _, dep := range d.After() {
+				if _, ok := cs[dep]; !ok {
+					return fmt.Errorf("cleaner %v depends on unknown cleaner %v", name, dep)
+				}
+				if err := visit(dep, stack); err != nil {
+					return err
+				}
+			}
+		}
+		states[name] = 2
+		sorted = append(sorted, c)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Clean runs cleaners in order and returns all errors
+// A failed cleaner never blocks subsequent ones
+func Clean(cs []Cleaner, vs map[string]template.Variable) error {
+	return CleanWithTimeout(cs, vs, 0)
+}
+
+// CleanWithTimeout runs cleaners in order like Clean, but stops waiting
+// for cleaners when timeout is exceeded. Cleaners which are not finished
+// are reported. There is no timeout if it is zero
+// Context of ContextCleaner is cancelled when timeout is exceeded, other
+// cleaners can't be stopped and keep running in background, their
+// results are ignored
+func CleanWithTimeout(cs []Cleaner, vs map[string]template.Variable, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	errs := []string{}
+	timedOut := false
+	for _, c := range cs {
+		if timedOut {
+			errs = append(errs, fmt.Sprintf("cleaner %v is not run: timeout %v is exceeded", c.Name(), timeout))
+			continue
+		}
+		// buffered so that a timed out cleaner can exit
+		done := make(chan error, 1)
+		go func(c Cleaner) {
+			done <- cleanContext(ctx, c, vs)
+		}(c)
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("cleaner %v is failed: %v", c.Name(), err))
+			}
+		case <-ctx.Done():
+			timedOut = true
+			errs = append(errs, fmt.Sprintf("cleaner %v is not finished: timeout %v is exceeded", c.Name(), timeout))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// cleanContext runs c with ctx if it is a ContextCleaner
+func cleanContext(ctx context.Context, c Cleaner, vs map[string]template.Variable) error {
+	if cc, ok := c.(ContextCleaner); ok {
+		return cc.CleanContext(ctx, vs)
+	}
+	return c.Clean(vs)
+}
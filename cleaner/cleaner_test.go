@@ -0,0 +1,163 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caicloud/aloe/template"
+)
+
+func TestSort(t *testing.T) {
+	noop := func(vs map[string]template.Variable) error {
+		return nil
+	}
+	cases := []struct {
+		cleaners []Cleaner
+		expected []string
+		hasError bool
+	}{
+		{
+			[]Cleaner{New("b", noop), New("a", noop)},
+			[]string{"a", "b"}, false,
+		},
+		{
+			[]Cleaner{New("category", noop, "product"), New("product", noop, "order"), New("order", noop)},
+			[]string{"order", "product", "category"}, false,
+		},
+		{
+			[]Cleaner{New("a", noop, "b"), New("b", noop, "a")},
+			nil, true,
+		},
+		{
+			[]Cleaner{New("a", noop, "unknown")},
+			nil, true,
+		},
+	}
+	for _, c := range cases {
+		cs := map[string]Cleaner{}
+		for _, cl := range c.cleaners {
+			cs[cl.Name()] = cl
+		}
+		sorted, err := Sort(cs)
+		if c.hasError {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		names := []string{}
+		for _, cl := range sorted {
+			names = append(names, cl.Name())
+		}
+		assert.Equal(t, c.expected, names)
+	}
+}
+
+func TestClean(t *testing.T) {
+	called := []string{}
+	f := func(name string, err error) Func {
+		return func(vs map[string]template.Variable) error {
+			called = append(called, name)
+			return err
+		}
+	}
+	cs := []Cleaner{
+		New("a", f("a", errors.New("not found"))),
+		New("b", f("b", nil)),
+		New("c", f("c", errors.New("timeout"))),
+	}
+	err := Clean(cs, nil)
+	assert.Equal(t, []string{"a", "b", "c"}, called)
+	assert.EqualError(t, err, "cleaner a is failed: not found\ncleaner c is failed: timeout")
+}
+
+func TestRetry(t *testing.T) {
+	cases := []struct {
+		failures int
+		attempts int
+		called   int
+		hasError bool
+	}{
+		{0, 3, 1, false},
+		{2, 3, 3, false},
+		{3, 3, 3, true},
+		{1, 0, 1, true},
+	}
+	for _, c := range cases {
+		called := 0
+		cl := WithRetry(New("a", func(vs map[string]template.Variable) error {
+			called++
+			if called <= c.failures {
+				return errors.New("conflict")
+			}
+			return nil
+		}, "b"), Retry{MaxAttempts: c.attempts})
+		err := cl.Clean(nil)
+		assert.Equal(t, c.called, called)
+		assert.Equal(t, c.hasError, err != nil)
+		assert.Equal(t, []string{"b"}, cl.(Dependent).After())
+	}
+}
+
+func TestCleanWithTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	called := []string{}
+	cs := []Cleaner{
+		New("a", func(vs map[string]template.Variable) error {
+			called = append(called, "a")
+			return nil
+		}),
+		New("b", func(vs map[string]template.Variable) error {
+			<-block
+			return nil
+		}),
+		New("c", func(vs map[string]template.Variable) error {
+			called = append(called, "c")
+			return nil
+		}),
+	}
+	err := CleanWithTimeout(cs, nil, 50*time.Millisecond)
+	assert.Equal(t, []string{"a"}, called)
+	assert.EqualError(t, err, "cleaner b is not finished: timeout 50ms is exceeded\n"+
+		"cleaner c is not run: timeout 50ms is exceeded")
+}
+
+func TestCleanWithTimeoutContext(t *testing.T) {
+	stopped := make(chan error, 1)
+	attempts := 0
+	cs := []Cleaner{
+		NewWithContext("a", func(ctx context.Context, vs map[string]template.Variable) error {
+			<-ctx.Done()
+			stopped <- ctx.Err()
+			return ctx.Err()
+		}),
+		// retries are stopped when timeout is exceeded
+		WithRetry(NewWithContext("b", func(ctx context.Context, vs map[string]template.Variable) error {
+			attempts++
+			return errors.New("conflict")
+		}), Retry{MaxAttempts: 100, Interval: time.Hour}),
+	}
+	err := CleanWithTimeout(cs, nil, 50*time.Millisecond)
+	assert.EqualError(t, err, "cleaner a is not finished: timeout 50ms is exceeded\n"+
+		"cleaner b is not run: timeout 50ms is exceeded")
+	select {
+	case err := <-stopped:
+		assert.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(time.Second):
+		t.Errorf("cleaner a is not stopped")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = cs[1].(ContextCleaner).CleanContext(ctx, nil)
+	assert.EqualError(t, err, "conflict")
+	assert.Equal(t, 1, attempts)
+	// cleaners without timeout get a context which is never done
+	assert.NoError(t, NewWithContext("c", func(ctx context.Context, vs map[string]template.Variable) error {
+		return ctx.Err()
+	}).Clean(nil))
+}
@@ -0,0 +1,58 @@
+package cleaner
+
+import (
+	"context"
+	"time"
+
+	"github.com/caicloud/aloe/template"
+)
+
+// Retry defines retry policy of cleaner
+type Retry struct {
+	// MaxAttempts defines max attempts of clean including the first one
+	MaxAttempts int
+
+	// Interval defines interval between two attempts
+	Interval time.Duration
+}
+
+// WithRetry returns a cleaner which retries c by policy until it
+// is succeeded, dependencies of c are kept
+func WithRetry(c Cleaner, policy Retry) Cleaner {
+	return &retryCleaner{
+		Cleaner: c,
+		policy:  policy,
+	}
+}
+
+type retryCleaner struct {
+	Cleaner
+	policy Retry
+}
+
+func (c *retryCleaner) Clean(vs map[string]template.Variable) error {
+	return c.CleanContext(context.Background(), vs)
+}
+
+// CleanContext stops retrying when ctx is done
+func (c *retryCleaner) CleanContext(ctx context.Context, vs map[string]template.Variable) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = cleanContext(ctx, c.Cleaner, vs)
+		if err == nil || attempt >= c.policy.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(c.policy.Interval):
+		}
+	}
+}
+
+func (c *retryCleaner) After() []string {
+	if d, ok := c.Cleaner.(Dependent); ok {
+		return d.After()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package framework
+
+import (
+	"fmt"
+
+	"github.com/caicloud/aloe/cleaner"
+	"github.com/caicloud/aloe/data"
+)
+
+// cleanersOf returns cleaners of context in order of dependencies
+// All cleaners are returned for top level context without cleaners.
+// Cleaners in inherited are run by parent contexts after each case,
+// so they are not returned again
+func (gf *genericFramework) cleanersOf(isTop bool, names []string, inherited map[string]bool) []cleaner.Cleaner {
+	if len(names) == 0 && !isTop {
+		return nil
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+	cs := []cleaner.Cleaner{}
+	for _, c := range gf.sortedCleaners {
+		if (len(names) == 0 || selected[c.Name()]) && !inherited[c.Name()] {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// contextCleaners returns cleaners of context in dir, and cleaners
+// run by it after each case are added into scope of sub contexts
+func (gf *genericFramework) contextCleaners(dir *data.Dir, isTop bool, s *scope) []cleaner.Cleaner {
+	cs := gf.cleanersOf(isTop, dir.Context.Cleaners, s.cleaners)
+	if !s.once {
+		// cleaners of once context are run only when it is torn down,
+		// so cases of sub contexts still run them
+		s.cleaners = inheritCleaners(s.cleaners, cs)
+	}
+	return cs
+}
+
+// inheritCleaners returns names of cleaners run by parent contexts
+// and cs after each case
+func inheritCleaners(inherited map[string]bool, cs []cleaner.Cleaner) map[string]bool {
+	names := map[string]bool{}
+	for name := range inherited {
+		names[name] = true
+	}
+	for _, c := range cs {
+		names[c.Name()] = true
+	}
+	return names
+}
+
+// validateCleaners checks that cleaners of contexts in dir
+// and all sub dirs are registered
+func (gf *genericFramework) validateCleaners(dir *data.Dir) error {
+	for _, name := range dir.Context.Cleaners {
+		if _, ok := gf.cleaners[name]; !ok {
+			return fmt.Errorf("can't find cleaner %v of context in %v", name, dir.Path)
+		}
+	}
+	for _, d := range dir.Dirs {
+		if err := gf.validateCleaners(&d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
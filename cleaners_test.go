@@ -0,0 +1,74 @@
+package framework
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/cleaner"
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextCleaners(t *testing.T) {
+	counts := map[string]int{}
+	gf := &genericFramework{cleaners: map[string]cleaner.Cleaner{}}
+	for _, name := range []string{"orders", "products", "users"} {
+		name := name
+		assert.NoError(t, gf.RegisterCleaner(cleaner.New(name, func(vs map[string]template.Variable) error {
+			counts[name]++
+			return nil
+		})))
+	}
+	sorted, err := cleaner.Sort(gf.cleaners)
+	assert.NoError(t, err)
+	gf.sortedCleaners = sorted
+
+	newDir := func(once bool, cleaners ...string) *data.Dir {
+		return &data.Dir{Context: types.ContextConfig{Once: once, Cleaners: cleaners}}
+	}
+	cases := []struct {
+		description string
+		// chain is contexts of case from top level
+		chain    []*data.Dir
+		expected map[string]int
+	}{
+		{
+			description: "top level context runs all cleaners",
+			chain:       []*data.Dir{newDir(false), newDir(false, "orders"), newDir(false, "orders", "users")},
+			expected:    map[string]int{"orders": 1, "products": 1, "users": 1},
+		},
+		{
+			description: "cleaners are run by declaring contexts",
+			chain:       []*data.Dir{newDir(false, "orders"), newDir(false, "orders", "products"), newDir(false, "products", "users")},
+			expected:    map[string]int{"orders": 1, "products": 1, "users": 1},
+		},
+		{
+			description: "sub contexts without cleaners",
+			chain:       []*data.Dir{newDir(false, "products"), newDir(false), newDir(false)},
+			expected:    map[string]int{"products": 1},
+		},
+		{
+			description: "cleaners of once context are run by sub contexts",
+			chain:       []*data.Dir{newDir(true, "orders"), newDir(false, "orders"), newDir(false, "orders")},
+			expected:    map[string]int{"orders": 1},
+		},
+	}
+	for _, c := range cases {
+		for k := range counts {
+			delete(counts, k)
+		}
+		s := scope{once: c.chain[0].Context.Once}
+		for i, dir := range c.chain {
+			if i != 0 {
+				s = s.child(dir)
+			}
+			cs := gf.contextCleaners(dir, i == 0, &s)
+			// once context is not cleaned after each case
+			if !s.once {
+				assert.NoError(t, cleaner.Clean(cs, nil), "description: %v", c.description)
+			}
+		}
+		assert.Equal(t, c.expected, counts, "description: %v", c.description)
+	}
+}
@@ -1,40 +1,72 @@
 package framework
 
 import (
-	"fmt"
+	"errors"
 
-	"github.com/caicloud/aloe/roundtrip"
 	"github.com/caicloud/aloe/template"
 	"github.com/caicloud/aloe/types"
 )
 
-func (gf *genericFramework) constructContext(ctx *types.Context, ctxConfig *types.ContextConfig) (map[string]template.Variable, error) {
+// constructContext runs flow of context and returns variables of it
+// dirVs are variables of the directory of context
+func (gf *genericFramework) constructContext(ctx *types.Context, ctxConfig *types.ContextConfig, dirVs map[string]template.Variable) (map[string]template.Variable, error) {
 	if ctx.Error != nil {
 		return nil, ctx.Error
 	}
 
-	newVs := map[string]template.Variable{}
-	for k, v := range ctx.Variables {
-		newVs[k] = v
-	}
+	newVs := withVariables(inheritedVariables(ctxConfig, ctx.Variables, gf.rootVariables()), dirVs)
 	newCtx := types.Context{
-		Variables: newVs,
+		Variables:  newVs,
+		Dir:        ctx.Dir,
+		FS:         ctx.FS,
+		CookieJar:  ctx.CookieJar,
+		Presetters: ctx.Presetters,
+
+		RoundTripTemplate: ctx.RoundTripTemplate,
+		UpdateGolden:      ctx.UpdateGolden,
+		FailureVerbosity:  ctx.FailureVerbosity,
 	}
-	for _, rt := range ctxConfig.Flow {
-		respMatcher, err := roundtrip.MatchResponse(&newCtx, &rt)
+	// ran contains names of round trips which are not skipped
+	ran := map[string]bool{}
+	for i := range ctxConfig.Flow {
+		if _, skipped := skippedDependency(&ctxConfig.Flow[i], ran); skipped {
+			continue
+		}
+		if when := ctxConfig.Flow[i].When; when != "" {
+			ok, err := template.Evaluate(when, newCtx.Variables)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		rt, err := gf.preset(&newCtx, &ctxConfig.Flow[i])
+		if err != nil {
+			return nil, err
+		}
+		respMatcher, err := gf.matchResponse(&newCtx, rt)
 		if err != nil {
 			return nil, err
 		}
-		resp, err := gf.client.DoRequest(&newCtx, &rt)
+		resp, dump, err := gf.doRequest(&newCtx, rt)
 		if err != nil {
 			return nil, err
 		}
+		if gf.dumped() {
+			respMatcher = &logHandler{
+				ResponseHandler: respMatcher,
+				gf:              gf,
+				dump:            &dump,
+				name:            rt.Name,
+			}
+		}
 		matched, err := respMatcher.Match(resp)
 		if err != nil {
 			return nil, err
 		}
 		if !matched {
-			return nil, fmt.Errorf(respMatcher.FailureMessage(resp))
+			return nil, errors.New(respMatcher.FailureMessage(resp))
 		}
 		vs, err := respMatcher.Variables()
 		if err != nil {
@@ -43,7 +75,20 @@ func (gf *genericFramework) constructContext(ctx *types.Context, ctxConfig *type
 		for k, v := range vs {
 			newCtx.Variables[k] = v
 		}
+		gf.secrets.add(vs)
+		if name := ctxConfig.Flow[i].Name; name != "" {
+			ran[name] = true
+		}
 	}
 
 	return newCtx.Variables, nil
 }
+
+// inheritedVariables returns variables of parent contexts which are visible
+// in context, or root variables if context doesn't inherit variables
+func inheritedVariables(ctxConfig *types.ContextConfig, parent, root map[string]template.Variable) map[string]template.Variable {
+	if ctxConfig.InheritVariables != nil && !*ctxConfig.InheritVariables {
+		return root
+	}
+	return parent
+}
@@ -0,0 +1,64 @@
+package framework
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInheritedVariables(t *testing.T) {
+	variable := func(name, value string) template.Variable {
+		return template.Variable{Name: name, Raw: []byte(value), Type: template.StringType}
+	}
+	gf := &genericFramework{
+		secrets: newSecretSet(),
+		suiteVs: map[string]template.Variable{
+			"host":   variable("host", "suite"),
+			"tenant": variable("tenant", "suite"),
+		},
+	}
+	parent := map[string]template.Variable{
+		"host":   variable("host", "parent"),
+		"tenant": variable("tenant", "parent"),
+		"token":  variable("token", "parent"),
+	}
+	dirVs := map[string]template.Variable{
+		"tenant": variable("tenant", "dir"),
+	}
+	inherit := false
+	cases := []struct {
+		description string
+		inherit     *bool
+		expected    map[string]string
+	}{
+		{
+			description: "variables of parent are inherited by default",
+			expected:    map[string]string{"host": "parent", "tenant": "dir", "token": "parent"},
+		},
+		{
+			description: "only variables of suite are visible",
+			inherit:     &inherit,
+			expected:    map[string]string{"host": "suite", "tenant": "dir", "token": ""},
+		},
+	}
+	for _, c := range cases {
+		ctx := &types.Context{Variables: parent}
+		vs, err := gf.constructContext(ctx, &types.ContextConfig{InheritVariables: c.inherit}, dirVs)
+		if !assert.NoError(t, err, c.description) {
+			continue
+		}
+		// variables of dir override inherited ones
+		for name, value := range c.expected {
+			assert.Equal(t, value, string(vs[name].Raw), "%v: %v", c.description, name)
+		}
+		if c.inherit != nil {
+			// builtin variables are also root variables
+			assert.Contains(t, vs, ParallelNodeVariable, c.description)
+		}
+	}
+	// variables of parent are not modified by child
+	assert.Equal(t, "parent", string(parent["tenant"].Raw))
+	assert.Len(t, parent, 3)
+}
@@ -0,0 +1,35 @@
+package framework
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/caicloud/aloe/roundtrip"
+)
+
+var (
+	// defaultRedactedHeaders are redacted in curl commands by default
+	defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization"}
+)
+
+// curlHandler appends an equivalent curl command of request
+// to failure message of response handler
+type curlHandler struct {
+	roundtrip.ResponseHandler
+
+	redactedHeaders []string
+}
+
+// FailureMessage implements gomegatypes.GomegaMatcher
+func (h *curlHandler) FailureMessage(actual interface{}) string {
+	msg := h.ResponseHandler.FailureMessage(actual)
+	resp, ok := actual.(*http.Response)
+	if !ok || resp.Request == nil {
+		return msg
+	}
+	cmd, err := roundtrip.Curl(resp.Request, h.redactedHeaders...)
+	if err != nil {
+		return fmt.Sprintf("%v\ncan't dump request as curl command: %v", msg, err)
+	}
+	return fmt.Sprintf("%v\nequivalent curl command:\n%v", msg, cmd)
+}
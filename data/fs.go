@@ -0,0 +1,38 @@
+package data
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// osFS is a file system of disk
+// Unlike os.DirFS, it accepts absolute and relative paths,
+// so paths of test data are kept as they are
+type osFS struct{}
+
+var (
+	_ fs.ReadDirFS  = osFS{}
+	_ fs.ReadFileFS = osFS{}
+	_ fs.StatFS     = osFS{}
+)
+
+// Open implements fs.FS
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.FromSlash(name))
+}
+
+// ReadDir implements fs.ReadDirFS
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(filepath.FromSlash(name))
+}
+
+// ReadFile implements fs.ReadFileFS
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.FromSlash(name))
+}
+
+// Stat implements fs.StatFS
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(filepath.FromSlash(name))
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// resolveIncludes merges included fragments into context config
+// Fragments can also include others, and stack is used to detect cycles
+func resolveIncludes(fsys fs.FS, c *types.ContextConfig, file string, stack []string, opts Options) error {
+	abs := path.Clean(file)
+	for _, f := range stack {
+		if f == abs {
+			return fmt.Errorf("include cycle is detected: %v", strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	shared := types.ContextConfig{}
+	for _, inc := range c.Include {
+		p := inc
+		if !path.IsAbs(p) {
+			p = path.Join(path.Dir(file), p)
+		}
+		body, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("can't read included file %v: %v", inc, err)
+		}
+		fragment := types.ContextConfig{}
+		if err := decode(p, body, &fragment, opts); err != nil {
+			return fmt.Errorf("can't unmarshal included file %v, err: %v", p, err)
+		}
+		if err := checkFragment(&fragment); err != nil {
+			return fmt.Errorf("invalid included file %v: %v", p, err)
+		}
+		if err := resolveIncludes(fsys, &fragment, p, stack, opts); err != nil {
+			return err
+		}
+		mergeShared(&shared, &fragment)
+	}
+	c.Include = nil
+	mergeShared(&shared, c)
+	c.Tags = shared.Tags
+	c.Cleaners = shared.Cleaners
+	c.Preset = shared.Preset
+	c.Presetters = shared.Presetters
+	c.Flow = shared.Flow
+	return nil
+}
+
+// checkFragment checks that only shareable fields are set in fragment
+// Fields like summary, focus and once belong to the including context
+func checkFragment(c *types.ContextConfig) error {
+	fields := []string{}
+	if c.Summary != "" {
+		fields = append(fields, "summary")
+	}
+	if c.Description != "" {
+		fields = append(fields, "description")
+	}
+	if c.Focus {
+		fields = append(fields, "focus")
+	}
+	if c.Skip || c.SkipReason != "" {
+		fields = append(fields, "skip")
+	}
+	if c.Once {
+		fields = append(fields, "once")
+	}
+	if c.InheritVariables != nil {
+		fields = append(fields, "inheritVariables")
+	}
+	if len(fields) != 0 {
+		return fmt.Errorf("%v can't be shared by fragment", strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// mergeShared merges shareable fields of src into dst
+// Flow of src is run after flow of dst, and presetters of src
+// are applied before presetters of dst
+func mergeShared(dst, src *types.ContextConfig) {
+	dst.Tags = append(dst.Tags, src.Tags...)
+	dst.Cleaners = append(dst.Cleaners, src.Cleaners...)
+	dst.Preset = types.MergeRoundTrip(src.Preset, dst.Preset)
+	dst.Presetters = append(append([]types.PresetConfig{}, src.Presetters...), dst.Presetters...)
+	dst.Flow = append(dst.Flow, src.Flow...)
+}
@@ -1,11 +1,11 @@
 package data
 
 import (
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
-
-	"github.com/ghodss/yaml"
+	"io/fs"
+	"path"
+	"strings"
 
 	"github.com/caicloud/aloe/types"
 )
@@ -14,8 +14,18 @@ import (
 type Dir struct {
 	Context types.ContextConfig
 
+	// Variables are read from variables file of the directory
+	Variables types.VariablesConfig
+
 	Name string
 
+	// Path is path of the directory
+	Path string
+
+	// FS is file system of the directory
+	// It is nil if the directory is on disk
+	FS fs.FS
+
 	Dirs  map[string]Dir
 	Files map[string]File
 }
@@ -29,34 +39,71 @@ type File struct {
 
 // Walk walks a dir and return Dir struct
 func Walk(path string) (*Dir, error) {
-	files, err := ioutil.ReadDir(path)
+	return WalkWithOptions(path, Options{})
+}
+
+// WalkWithOptions walks a dir by options and return Dir struct
+func WalkWithOptions(path string, opts Options) (*Dir, error) {
+	return walk(osFS{}, path, opts)
+}
+
+// WalkFS walks a dir in file system, e.g. embed.FS
+// FS of the returned Dir is set to fsys
+func WalkFS(fsys fs.FS, path string, opts Options) (*Dir, error) {
+	dir, err := walk(fsys, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	dir.setFS(fsys)
+	return dir, nil
+}
+
+func walk(fsys fs.FS, dirPath string, opts Options) (*Dir, error) {
+	files, err := fs.ReadDir(fsys, dirPath)
 	if err != nil {
 		return nil, err
 	}
-	ctxConfig, err := readContext(path)
+	ctxConfig, err := readContext(fsys, dirPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("read context config %v error: %v", dirPath, err)
+	}
+	if err := checkBodyFiles(ctxConfig.Flow); err != nil {
+		return nil, fmt.Errorf("invalid context config %v: %v", dirPath, err)
+	}
+	vs, err := readVariables(fsys, dirPath, opts)
 	if err != nil {
-		return nil, fmt.Errorf("read context config %v error: %v", path, err)
+		return nil, fmt.Errorf("read variables %v error: %v", dirPath, err)
 	}
 	dir := Dir{
-		Context: *ctxConfig,
-		Name:    filepath.Base(path),
-		Dirs:    map[string]Dir{},
-		Files:   map[string]File{},
+		Context:   *ctxConfig,
+		Variables: *vs,
+		Name:      path.Base(dirPath),
+		Path:      dirPath,
+		Dirs:      map[string]Dir{},
+		Files:     map[string]File{},
 	}
 	for _, file := range files {
 		name := file.Name()
-		childPath := filepath.Join(path, name)
+		childPath := path.Join(dirPath, name)
+		if file.IsDir() && isShared(name) {
+			// shared fragments and fixtures are only read by
+			// contexts and cases which refer to them
+			continue
+		}
 		if file.IsDir() {
-			childDir, err := Walk(childPath)
+			childDir, err := walk(fsys, childPath, opts)
 			if err != nil {
 				return nil, err
 			}
 			dir.Dirs[name] = *childDir
 		} else if !isIgnored(name) {
-			c, err := readCase(childPath)
+			c, err := readCase(fsys, childPath, opts)
 			if err != nil {
 				return nil, fmt.Errorf("read test case %v error: %v", childPath, err)
 			}
+			if err := checkBodyFiles(c.Flow); err != nil {
+				return nil, fmt.Errorf("invalid test case %v: %v", childPath, err)
+			}
 			dir.Files[name] = File{
 				Case: *c,
 				Name: file.Name(),
@@ -66,36 +113,118 @@ func Walk(path string) (*Dir, error) {
 	return &dir, nil
 }
 
-func readContext(dir string) (*types.ContextConfig, error) {
-	contextFile := filepath.Join(dir, types.ContextFile)
+// setFS sets FS of dir and all sub dirs
+func (d *Dir) setFS(fsys fs.FS) {
+	d.FS = fsys
+	for name, sub := range d.Dirs {
+		sub.setFS(fsys)
+		d.Dirs[name] = sub
+	}
+}
+
+func readContext(fsys fs.FS, dir string, opts Options) (*types.ContextConfig, error) {
+	contextFile, err := findFile(fsys, dir, types.ContextFile, types.ContextJSONFile)
+	if err != nil {
+		return nil, err
+	}
 
-	contextBody, err := ioutil.ReadFile(contextFile)
+	contextBody, err := fs.ReadFile(fsys, contextFile)
 	if err != nil {
 		return nil, err
 	}
 	context := types.ContextConfig{}
-	if err := yaml.Unmarshal(contextBody, &context); err != nil {
+	if err := decode(contextFile, contextBody, &context, opts); err != nil {
 		return nil, fmt.Errorf("can't unmarshal %v, err: %v", contextFile, err)
 	}
+	if err := resolveIncludes(fsys, &context, contextFile, nil, opts); err != nil {
+		return nil, err
+	}
 	return &context, nil
 }
 
+// readVariables reads variables file in dir
+// Empty variables are returned if the file doesn't exist
+func readVariables(fsys fs.FS, dir string, opts Options) (*types.VariablesConfig, error) {
+	vs := types.VariablesConfig{}
+	file, err := findFile(fsys, dir, types.VariablesFile, types.VariablesJSONFile)
+	if err != nil {
+		return nil, err
+	}
+	body, err := fs.ReadFile(fsys, file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &vs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := decode(file, body, &vs, opts); err != nil {
+		return nil, fmt.Errorf("can't unmarshal %v, err: %v", file, err)
+	}
+	return &vs, nil
+}
+
+// findFile returns path of file in dir which can be either yaml or json,
+// but not both. Path of yaml file is returned if neither exists
+func findFile(fsys fs.FS, dir, yamlName, jsonName string) (string, error) {
+	yamlFile := path.Join(dir, yamlName)
+	jsonFile := path.Join(dir, jsonName)
+	_, yamlErr := fs.Stat(fsys, yamlFile)
+	_, jsonErr := fs.Stat(fsys, jsonFile)
+	switch {
+	case yamlErr == nil && jsonErr == nil:
+		return "", fmt.Errorf("both %v and %v exist", yamlName, jsonName)
+	case jsonErr == nil:
+		return jsonFile, nil
+	}
+	return yamlFile, nil
+}
+
+// isShared returns whether dir stores shared fragments or fixtures
+// whose name starts with _, e.g. _shared/auth.yaml
+// Files whose names start with _ are still read as cases
+func isShared(dir string) bool {
+	return strings.HasPrefix(dir, "_")
+}
+
+// checkBodyFiles checks that body files of flow are in shared dirs,
+// otherwise they are read as contexts or cases. Paths rendered by
+// variables are checked when they are read
+func checkBodyFiles(flow []types.RoundTrip) error {
+	for _, rt := range flow {
+		if rt.Request.BodyFile == nil {
+			continue
+		}
+		p := path.Clean(rt.Request.BodyFile.Raw())
+		if strings.Contains(p, "%{") || strings.HasPrefix(p, "../") {
+			continue
+		}
+		first := strings.SplitN(p, "/", 2)[0]
+		if (first == p && !isIgnored(p)) || (first != p && !isShared(first)) {
+			return fmt.Errorf("body file %v should be in a dir whose name starts with _, e.g. _fixtures/%v", p, path.Base(p))
+		}
+	}
+	return nil
+}
+
+// isIgnored returns whether file in root of a context isn't a case
+// name is relative to the context, so only context and variables files
+// of the context are ignored, e.g. _fixtures/variables.yaml isn't
 func isIgnored(name string) bool {
-	if filepath.Base(name) == types.ContextFile {
+	switch name {
+	case types.ContextFile, types.ContextJSONFile, types.VariablesFile, types.VariablesJSONFile:
 		return true
 	}
-	ext := filepath.Ext(name)
-	// TODO(liubog2008): add json support
-	return ext != ".yaml"
+	ext := path.Ext(name)
+	return ext != ".yaml" && ext != ".json"
 }
 
-func readCase(file string) (*types.Case, error) {
-	body, err := ioutil.ReadFile(file)
+func readCase(fsys fs.FS, file string, opts Options) (*types.Case, error) {
+	body, err := fs.ReadFile(fsys, file)
 	if err != nil {
 		return nil, err
 	}
 	c := types.Case{}
-	if err := yaml.Unmarshal(body, &c); err != nil {
+	if err := decode(file, body, &c, opts); err != nil {
 		return nil, err
 	}
 	return &c, nil
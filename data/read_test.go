@@ -0,0 +1,256 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const bodyFileCase = `description: "Create product"
+flow:
+- request:
+    api: POST /products
+    bodyFile: %v/product.json
+  response:
+    statusCode: 201
+`
+
+func TestWalkBodyFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cases/_context.yaml":          {Data: []byte(`summary: "Products"`)},
+		"cases/create.yaml":            {Data: []byte(fmt.Sprintf(bodyFileCase, "_fixtures"))},
+		"cases/_fixtures/product.json": {Data: []byte(`{"name": "apple"}`)},
+	}
+	dir, err := WalkFS(fsys, "cases", Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// fixtures are not read as contexts or cases
+	assert.Empty(t, dir.Dirs)
+	assert.Equal(t, []string{"create.yaml"}, sortedFiles(dir.Files))
+	body, err := fs.ReadFile(dir.FS, path.Join(dir.Path, "_fixtures", "product.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "apple"}`, string(body))
+}
+
+func TestCheckBodyFiles(t *testing.T) {
+	cases := []struct {
+		bodyFile string
+		valid    bool
+	}{
+		{"_fixtures/product.json", true},
+		{"_fixtures/nested/product.json", true},
+		{"./_fixtures/product.json", true},
+		{"logo.png", true},
+		{"../_fixtures/product.json", true},
+		{"_fixtures/%{name}.json", true},
+		{"fixtures/product.json", false},
+		{"product.json", false},
+		{"product.yaml", false},
+	}
+	for _, c := range cases {
+		bodyFile, err := types.NewTemplate(c.bodyFile)
+		if !assert.NoError(t, err, c.bodyFile) {
+			continue
+		}
+		err = checkBodyFiles([]types.RoundTrip{{Request: types.Request{BodyFile: bodyFile}}})
+		if c.valid {
+			assert.NoError(t, err, c.bodyFile)
+			continue
+		}
+		if assert.Error(t, err, c.bodyFile) {
+			assert.Contains(t, err.Error(), "should be in a dir whose name starts with _", c.bodyFile)
+		}
+	}
+}
+
+func TestWalkShared(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cases/_context.yaml":      {Data: []byte("summary: \"Products\"\ninclude:\n- \"_shared/login.yaml\"\n")},
+		"cases/_shared/login.yaml": {Data: []byte("flow:\n- name: login\n  request:\n    api: POST /login\n")},
+		"cases/_draft.yaml":        {Data: []byte(`description: "Draft"`)},
+		"cases/get.yaml":           {Data: []byte(`description: "Get product"`)},
+	}
+	dir, err := WalkFS(fsys, "cases", Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// dirs whose names start with _ are not read as contexts
+	assert.Empty(t, dir.Dirs)
+	assert.Equal(t, []string{"_draft.yaml", "get.yaml"}, sortedFiles(dir.Files))
+	if assert.Len(t, dir.Context.Flow, 1) {
+		assert.Equal(t, "login", dir.Context.Flow[0].Name)
+	}
+}
+
+func sortedFiles(files map[string]File) []string {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWalkInclude(t *testing.T) {
+	cases := []struct {
+		description string
+		files       map[string]string
+		err         string
+	}{
+		{
+			description: "fragments are merged",
+			files: map[string]string{
+				"cases/_context.yaml":      "summary: \"Products\"\nonce: true\ntags: [\"products\"]\ninclude:\n- \"_shared/auth.yaml\"\nflow:\n- name: create\n",
+				"cases/_shared/auth.yaml":  "tags: [\"auth\"]\ninclude:\n- \"login.yaml\"\nflow:\n- name: token\n",
+				"cases/_shared/login.yaml": "cleaners: [\"users\"]\nflow:\n- name: login\n",
+			},
+		},
+		{
+			description: "cycle of fragments",
+			files: map[string]string{
+				"cases/_context.yaml":  "summary: \"Products\"\ninclude:\n- \"_shared/a.yaml\"\n",
+				"cases/_shared/a.yaml": "include:\n- \"b.yaml\"\n",
+				"cases/_shared/b.yaml": "include:\n- \"a.yaml\"\n",
+			},
+			err: "include cycle is detected: cases/_context.yaml -> cases/_shared/a.yaml -> cases/_shared/b.yaml -> cases/_shared/a.yaml",
+		},
+		{
+			description: "fragment includes itself",
+			files: map[string]string{
+				"cases/_context.yaml":  "summary: \"Products\"\ninclude:\n- \"_shared/a.yaml\"\n",
+				"cases/_shared/a.yaml": "include:\n- \"./a.yaml\"\n",
+			},
+			err: "include cycle is detected: cases/_context.yaml -> cases/_shared/a.yaml -> cases/_shared/a.yaml",
+		},
+		{
+			description: "fields of context in fragment",
+			files: map[string]string{
+				"cases/_context.yaml":  "summary: \"Products\"\ninclude:\n- \"_shared/a.yaml\"\n",
+				"cases/_shared/a.yaml": "summary: \"Shared\"\nfocus: true\nonce: true\n",
+			},
+			err: "invalid included file cases/_shared/a.yaml: summary, focus, once can't be shared by fragment",
+		},
+	}
+	for _, c := range cases {
+		fsys := fstest.MapFS{}
+		for name, content := range c.files {
+			fsys[name] = &fstest.MapFile{Data: []byte(content)}
+		}
+		dir, err := WalkFS(fsys, "cases", Options{})
+		if c.err != "" {
+			if assert.Error(t, err, c.description) {
+				assert.Contains(t, err.Error(), c.err, c.description)
+			}
+			continue
+		}
+		if !assert.NoError(t, err, c.description) {
+			continue
+		}
+		ctx := dir.Context
+		assert.Equal(t, "Products", ctx.Summary, c.description)
+		assert.True(t, ctx.Once, c.description)
+		assert.Equal(t, []string{"auth", "products"}, ctx.Tags, c.description)
+		assert.Equal(t, []string{"users"}, ctx.Cleaners, c.description)
+		names := []string{}
+		for _, rt := range ctx.Flow {
+			names = append(names, rt.Name)
+		}
+		// flow of fragments is run first
+		assert.Equal(t, []string{"login", "token", "create"}, names, c.description)
+	}
+}
+
+func TestReadVariables(t *testing.T) {
+	cases := []struct {
+		description string
+		files       map[string]string
+		expected    types.VariablesConfig
+		err         string
+	}{
+		{
+			description: "no variables file",
+			files:       map[string]string{},
+			expected:    types.VariablesConfig{},
+		},
+		{
+			description: "yaml variables file",
+			files: map[string]string{
+				"cases/variables.yaml": "variables:\n  tenant: \"t1\"\nenvironments:\n  staging:\n    tenant: \"t2\"\n",
+			},
+			expected: types.VariablesConfig{
+				Variables:    map[string]json.RawMessage{"tenant": json.RawMessage(`"t1"`)},
+				Environments: map[string]map[string]json.RawMessage{"staging": {"tenant": json.RawMessage(`"t2"`)}},
+			},
+		},
+		{
+			description: "json variables file",
+			files: map[string]string{
+				"cases/variables.json": `{"variables": {"count": 3}}`,
+			},
+			expected: types.VariablesConfig{
+				Variables: map[string]json.RawMessage{"count": json.RawMessage(`3`)},
+			},
+		},
+		{
+			description: "both yaml and json variables files",
+			files: map[string]string{
+				"cases/variables.yaml": "variables:\n  count: 3\n",
+				"cases/variables.json": `{"variables": {"count": 3}}`,
+			},
+			err: "both variables.yaml and variables.json exist",
+		},
+	}
+	for _, c := range cases {
+		fsys := fstest.MapFS{}
+		for name, content := range c.files {
+			fsys[name] = &fstest.MapFile{Data: []byte(content)}
+		}
+		vs, err := readVariables(fsys, "cases", Options{})
+		if c.err != "" {
+			if assert.Error(t, err, c.description) {
+				assert.Contains(t, err.Error(), c.err, c.description)
+			}
+			continue
+		}
+		if !assert.NoError(t, err, c.description) {
+			continue
+		}
+		assert.Equal(t, c.expected, *vs, c.description)
+	}
+}
+
+func TestWalkVariablesFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cases/_context.yaml":            {Data: []byte(`summary: "Products"`)},
+		"cases/variables.yaml":           {Data: []byte("variables:\n  tenant: \"t1\"\n")},
+		"cases/get.yaml":                 {Data: []byte(`description: "Get product"`)},
+		"cases/orders/_context.json":     {Data: []byte(`{"summary": "Orders"}`)},
+		"cases/orders/variables.json":    {Data: []byte(`{"variables": {"tenant": "t2"}}`)},
+		"cases/orders/list.json":         {Data: []byte(`{"description": "List orders"}`)},
+		"cases/_fixtures/variables.yaml": {Data: []byte(`tenant: t3`)},
+	}
+	dir, err := WalkFS(fsys, "cases", Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// variables files are only read as variables of contexts
+	assert.Equal(t, []string{"get.yaml"}, sortedFiles(dir.Files))
+	assert.Equal(t, `"t1"`, string(dir.Variables.Variables["tenant"]))
+	orders := dir.Dirs["orders"]
+	assert.Equal(t, []string{"list.json"}, sortedFiles(orders.Files))
+	assert.Equal(t, `"t2"`, string(orders.Variables.Variables["tenant"]))
+
+	// variables.yaml in shared dirs is a body file like others
+	bodyFile, err := types.NewTemplate("_fixtures/variables.yaml")
+	if assert.NoError(t, err) {
+		assert.NoError(t, checkBodyFiles([]types.RoundTrip{{Request: types.Request{BodyFile: bodyFile}}}))
+	}
+}
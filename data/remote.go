@@ -0,0 +1,297 @@
+package data
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Remote defines a remote source of test data
+// It can be a git repo or a http(s) url of gzipped tarball
+type Remote struct {
+	// URL is url of source
+	// Git repo should end with .git or start with git@
+	// Tarball should end with .tar.gz or .tgz
+	URL string
+
+	// Ref is branch or tag of git repo
+	// Default branch is used if it is empty
+	Ref string
+
+	// Dir is path of test data in source
+	Dir string
+
+	// Username and Token are used to fetch private source
+	// Token is sent as a bearer token if username is empty,
+	// otherwise basic auth is used, e.g. x-access-token for github
+	Username string
+	Token    string
+
+	// CacheTTL defines how long a cached tarball is reused, it is
+	// downloaded again if it is older than CacheTTL. Tarball is
+	// downloaded once in each run if CacheTTL is zero
+	CacheTTL time.Duration
+}
+
+var (
+	// DefaultCacheDir is dir to cache remote sources
+	DefaultCacheDir = filepath.Join(os.TempDir(), "aloe-cache")
+
+	// fetchTimeout defines timeout of downloading tarball
+	// A lock which is older than it is treated as stale
+	fetchTimeout = 5 * time.Minute
+
+	// lockInterval defines interval of waiting for lock of cache
+	lockInterval = 100 * time.Millisecond
+
+	// started is start time of current process
+	// Sources fetched after it are fetched by current run, e.g. by
+	// another parallel node, and they are never fetched again
+	started = time.Now()
+)
+
+// WalkRemote fetches remote source into cache dir and walks test data
+func WalkRemote(r *Remote, cacheDir string, opts Options) (*Dir, error) {
+	path, err := Fetch(r, cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return WalkWithOptions(path, opts)
+}
+
+// Fetch fetches remote source into cache dir and returns path of test data
+// Git repo in cache is updated, and tarball in cache is reused until
+// CacheTTL is exceeded. Source is fetched by only one process at a time
+// and at most once in a run, so parallel nodes can share the cache dir
+func Fetch(r *Remote, cacheDir string) (string, error) {
+	if cacheDir == "" {
+		cacheDir = DefaultCacheDir
+	}
+	sum := sha256.Sum256([]byte(r.URL + "#" + r.Ref))
+	dst := filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("can't create cache dir: %v", err)
+	}
+	unlock, err := lock(dst + ".lock")
+	if err != nil {
+		return "", fmt.Errorf("can't lock cache of %v: %v", r.URL, err)
+	}
+	defer unlock()
+
+	switch {
+	case isGitURL(r.URL):
+		err = fetchGit(r, dst)
+	case isTarballURL(r.URL):
+		err = fetchTarball(r, dst)
+	default:
+		err = fmt.Errorf("unknown type of remote source")
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't fetch %v: %v", r.URL, err)
+	}
+	return filepath.Join(dst, r.Dir), nil
+}
+
+// lock creates lock file exclusively, and waits if it exists
+// Lock file is removed by unlock, a stale lock of a crashed process is
+// removed after fetch timeout
+func lock(path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > fetchTimeout {
+			os.Remove(path)
+			continue
+		}
+		time.Sleep(lockInterval)
+	}
+}
+
+// fetched returns true if dst is fetched by current run
+func fetched(dst string) bool {
+	info, err := os.Stat(dst)
+	return err == nil && info.ModTime().After(started)
+}
+
+func isGitURL(u string) bool {
+	return strings.HasSuffix(u, ".git") || strings.HasPrefix(u, "git@")
+}
+
+func isTarballURL(u string) bool {
+	return strings.HasSuffix(u, ".tar.gz") || strings.HasSuffix(u, ".tgz")
+}
+
+// authHeader returns value of authorization header
+func (r *Remote) authHeader() string {
+	if r.Token == "" {
+		return ""
+	}
+	if r.Username == "" {
+		return "Bearer " + r.Token
+	}
+	req := http.Request{Header: http.Header{}}
+	req.SetBasicAuth(r.Username, r.Token)
+	return req.Header.Get("Authorization")
+}
+
+func fetchGit(r *Remote, dst string) error {
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if fetched(filepath.Join(dst, ".git")) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".git")); err != nil {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+		if err := runGit(r, dst, "init", "-q"); err != nil {
+			return err
+		}
+	}
+	if err := runGit(r, dst, "fetch", "-q", "--depth", "1", r.URL, ref); err != nil {
+		return err
+	}
+	if err := runGit(r, dst, "checkout", "-q", "-f", "FETCH_HEAD"); err != nil {
+		return err
+	}
+	now := time.Now()
+	return os.Chtimes(filepath.Join(dst, ".git"), now, now)
+}
+
+func runGit(r *Remote, dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if h := r.authHeader(); h != "" {
+		// header is passed by env so that token is not shown in
+		// command line of process
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: "+h,
+		)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %v failed: %v: %v", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func fetchTarball(r *Remote, dst string) error {
+	if info, err := os.Stat(dst); err == nil && (time.Since(info.ModTime()) < r.CacheTTL || fetched(dst)) {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodGet, r.URL, nil)
+	if err != nil {
+		return err
+	}
+	if h := r.authHeader(); h != "" {
+		req.Header.Set("Authorization", h)
+	}
+	client := http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	// extract into a temp dir first so that a broken tarball
+	// is never cached
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(filepath.Dir(dst), filepath.Base(dst)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractTarball(resp.Body, tmp); err != nil {
+		return err
+	}
+	// expired tarball is replaced, it is not used by current run
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := os.Rename(rootOf(tmp), dst); err != nil {
+		return err
+	}
+	now := time.Now()
+	return os.Chtimes(dst, now, now)
+}
+
+// extractTarball extracts gzipped tarball into dir
+func extractTarball(r io.Reader, dir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, h.Name)
+		if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("invalid path %v in tarball", h.Name)
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(path, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// rootOf returns the only sub dir of dir if there is no other file,
+// e.g. tarballs of github are wrapped by a dir named by repo and commit
+func rootOf(dir string) string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != 1 || !files[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, files[0].Name())
+}
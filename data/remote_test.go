@@ -0,0 +1,160 @@
+package data
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTarball returns a gzipped tarball of files
+func newTarball(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		h := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatalf("can't write tarball: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestFetchTarball(t *testing.T) {
+	var downloads int32
+	version := "v1"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		w.Write(newTarball(t, map[string]string{"api-cases/cases/version": version}))
+	}))
+	defer s.Close()
+
+	cases := []struct {
+		ttl       time.Duration
+		version   string
+		downloads int32
+	}{
+		{0, "v1", 1},
+		{time.Hour, "v1", 0},
+		{0, "v2", 1},
+		{time.Nanosecond, "v3", 1},
+	}
+	cacheDir, err := ioutil.TempDir("", "aloe-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	for _, c := range cases {
+		atomic.StoreInt32(&downloads, 0)
+		// each case is a new run
+		started = time.Now()
+		version = c.version
+		p, err := Fetch(&Remote{URL: s.URL + "/cases.tar.gz", Dir: "cases", CacheTTL: c.ttl}, cacheDir)
+		if !assert.NoError(t, err, "ttl: %v", c.ttl) {
+			continue
+		}
+		body, err := ioutil.ReadFile(filepath.Join(p, "version"))
+		assert.NoError(t, err, "ttl: %v", c.ttl)
+		assert.Equal(t, c.version, string(body), "ttl: %v", c.ttl)
+		assert.Equal(t, c.downloads, atomic.LoadInt32(&downloads), "ttl: %v", c.ttl)
+	}
+}
+
+func TestFetchParallel(t *testing.T) {
+	var downloads int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		w.Write(newTarball(t, map[string]string{"api-cases/cases/version": "v1"}))
+	}))
+	defer s.Close()
+	cacheDir, err := ioutil.TempDir("", "aloe-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	// nodes of a run fetch the same source at the same time
+	started = time.Now()
+	nodes := 8
+	errs := make(chan error, nodes)
+	for i := 0; i < nodes; i++ {
+		go func() {
+			p, err := Fetch(&Remote{URL: s.URL + "/cases.tar.gz", Dir: "cases"}, cacheDir)
+			if err == nil {
+				_, err = os.Stat(filepath.Join(p, "version"))
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < nodes; i++ {
+		assert.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+	files, err := ioutil.ReadDir(cacheDir)
+	assert.NoError(t, err)
+	// lock and temp dirs are removed
+	assert.Len(t, files, 1)
+}
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe-lock")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache.lock")
+
+	unlock, err := lock(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := lock(path)
+		if assert.NoError(t, err) {
+			unlock()
+		}
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatalf("lock should wait for unlock")
+	case <-time.After(3 * lockInterval):
+	}
+	unlock()
+	<-locked
+
+	// stale lock is removed
+	assert.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	old := time.Now().Add(-2 * fetchTimeout)
+	assert.NoError(t, os.Chtimes(path, old, old))
+	unlock, err = lock(path)
+	if assert.NoError(t, err) {
+		unlock()
+	}
+}
+
+func TestRunGitToken(t *testing.T) {
+	// fake git prints its args and config in env
+	bin, err := ioutil.TempDir("", "aloe-git")
+	assert.NoError(t, err)
+	defer os.RemoveAll(bin)
+	script := "#!/bin/sh\necho \"$@\" \"$GIT_CONFIG_KEY_0=$GIT_CONFIG_VALUE_0\" >&2\nexit 1\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(bin, "git"), []byte(script), 0755))
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer os.Setenv("PATH", strings.TrimPrefix(os.Getenv("PATH"), bin+string(os.PathListSeparator)))
+
+	err = runGit(&Remote{Token: "secret"}, bin, "fetch", "-q")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "fetch -q http.extraHeader=Authorization: Bearer secret")
+		assert.NotContains(t, err.Error(), "-c")
+	}
+}
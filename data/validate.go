@@ -0,0 +1,345 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Options defines options of reading test data
+type Options struct {
+	// Strict rejects unknown fields in cases and contexts
+	// Unknown fields are only warned if it is false
+	Strict bool
+
+	// Warn is called with warnings of test data, e.g. unknown fields
+	// Warnings are dropped if it is nil
+	Warn func(msg string)
+}
+
+// decode decodes body of file into v by its extension
+// Invalid and unknown fields are reported with line and column
+func decode(file string, body []byte, v interface{}, opts Options) error {
+	isJSON := filepath.Ext(file) == ".json"
+	var err error
+	if isJSON {
+		err = json.Unmarshal(body, v)
+	} else {
+		err = yaml.Unmarshal(body, v)
+	}
+	if err != nil {
+		return locateError(file, body, isJSON, v, err)
+	}
+
+	jsonBody := body
+	if !isJSON {
+		if jsonBody, err = yaml.YAMLToJSON(body); err != nil {
+			return err
+		}
+	}
+	var raw interface{}
+	if err := json.Unmarshal(jsonBody, &raw); err != nil {
+		return err
+	}
+	errs := []string{}
+	for _, f := range unknownFields(raw, reflect.TypeOf(v), nil) {
+		msg := fmt.Sprintf("unknown field %v", formatPath(f.path))
+		if f.suggestion != "" {
+			msg += fmt.Sprintf(", did you mean %v?", f.suggestion)
+		}
+		msg = position(file, body, isJSON, f.path) + msg
+		if !opts.Strict {
+			if opts.Warn != nil {
+				opts.Warn(msg)
+			}
+			continue
+		}
+		errs = append(errs, msg)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// locateError adds position of invalid field into decoding error
+func locateError(file string, body []byte, isJSON bool, v interface{}, err error) error {
+	if isJSON {
+		if se, ok := err.(*json.SyntaxError); ok {
+			// offset is after the invalid character
+			line, col := lineAndColumn(body, int(se.Offset)-1)
+			return fmt.Errorf("%v:%v:%v: %v", file, line, col, err)
+		}
+	} else {
+		// yaml is converted to json before decoding, so decode it
+		// again to get the original error of json
+		jsonBody, yamlErr := yaml.YAMLToJSON(body)
+		if yamlErr != nil {
+			// syntax error of yaml already has line number
+			return fmt.Errorf("%v: %v", file, yamlErr)
+		}
+		n := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+		if jsonErr := json.Unmarshal(jsonBody, n); jsonErr != nil {
+			err = jsonErr
+		}
+	}
+	te, ok := err.(*json.UnmarshalTypeError)
+	if !ok || te.Field == "" {
+		return fmt.Errorf("%v: %v", file, err)
+	}
+	path := strings.Split(te.Field, ".")
+	return fmt.Errorf("%vinvalid field %v: can't use %v as %v", position(file, body, isJSON, path), formatPath(path), te.Value, te.Type)
+}
+
+// formatPath formats path of field, e.g. flow[0].response
+func formatPath(path []string) string {
+	s := ""
+	for _, p := range path {
+		if _, err := strconv.Atoi(p); err == nil {
+			s += "[" + p + "]"
+			continue
+		}
+		if s != "" {
+			s += "."
+		}
+		s += p
+	}
+	return s
+}
+
+// position returns prefix of error message with
+// file, line and column of the field
+func position(file string, body []byte, isJSON bool, path []string) string {
+	var (
+		line, col int
+		ok        bool
+	)
+	if isJSON {
+		line, col, ok = locateJSON(body, path)
+	} else {
+		line, col, ok = locateYAML(body, path)
+	}
+	if !ok {
+		return file + ": "
+	}
+	return fmt.Sprintf("%v:%v:%v: ", file, line, col)
+}
+
+// lineAndColumn returns one-indexed line and column of offset
+func lineAndColumn(body []byte, offset int) (int, int) {
+	if offset > len(body) {
+		offset = len(body)
+	}
+	before := body[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := offset - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+type unknownField struct {
+	path       []string
+	suggestion string
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields returns fields of v which are not defined in t
+// Keys of json are matched case sensitively
+func unknownFields(v interface{}, t reflect.Type, path []string) []unknownField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	fs := []unknownField{}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for _, k := range sortedKeys(m) {
+			p := append(append([]string{}, path...), k)
+			ft, ok := fields[k]
+			if !ok {
+				f := unknownField{path: p}
+				for name := range fields {
+					if strings.EqualFold(name, k) {
+						f.suggestion = name
+					}
+				}
+				fs = append(fs, f)
+				continue
+			}
+			fs = append(fs, unknownFields(m[k], ft, p)...)
+		}
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, k := range sortedKeys(m) {
+			fs = append(fs, unknownFields(m[k], t.Elem(), append(append([]string{}, path...), k))...)
+		}
+	case reflect.Slice, reflect.Array:
+		s, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, e := range s {
+			fs = append(fs, unknownFields(e, t.Elem(), append(append([]string{}, path...), strconv.Itoa(i)))...)
+		}
+	}
+	return fs
+}
+
+// jsonFields returns types of fields in struct by their json names
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+// locateYAML returns one-indexed line and column of field by path
+// Position of key is returned for field of mapping
+func locateYAML(body []byte, path []string) (int, int, bool) {
+	doc := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(body, doc); err != nil || len(doc.Content) == 0 {
+		return 0, 0, false
+	}
+	n := doc.Content[0]
+	line, col := n.Line, n.Column
+	for _, seg := range path {
+		for n.Kind == yamlv3.AliasNode {
+			n = n.Alias
+		}
+		switch n.Kind {
+		case yamlv3.MappingNode:
+			var value *yamlv3.Node
+			// keys and values are in turn
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if key := n.Content[i]; key.Value == seg {
+					line, col = key.Line, key.Column
+					value = n.Content[i+1]
+					break
+				}
+			}
+			if value == nil {
+				return 0, 0, false
+			}
+			n = value
+		case yamlv3.SequenceNode:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(n.Content) {
+				return 0, 0, false
+			}
+			n = n.Content[i]
+			line, col = n.Line, n.Column
+		default:
+			return 0, 0, false
+		}
+	}
+	return line, col, true
+}
+
+// locateJSON returns one-indexed line and column of field by path
+func locateJSON(body []byte, path []string) (int, int, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	offset, ok := seekJSON(dec, path)
+	if !ok {
+		return 0, 0, false
+	}
+	// skip separators before the field
+	for offset < len(body) && strings.IndexByte(" \t\r\n,:", body[offset]) != -1 {
+		offset++
+	}
+	line, col := lineAndColumn(body, offset)
+	return line, col, true
+}
+
+// seekJSON reads the next value and returns offset of field by path in it
+func seekJSON(dec *json.Decoder, path []string) (int, bool) {
+	start := int(dec.InputOffset())
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, false
+	}
+	if len(path) == 0 {
+		return start, true
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			keyOffset := int(dec.InputOffset())
+			k, err := dec.Token()
+			if err != nil {
+				return 0, false
+			}
+			if k == path[0] {
+				if len(path) == 1 {
+					return keyOffset, true
+				}
+				return seekJSON(dec, path[1:])
+			}
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return 0, false
+			}
+		}
+	case json.Delim('['):
+		n, err := strconv.Atoi(path[0])
+		if err != nil {
+			return 0, false
+		}
+		for i := 0; dec.More(); i++ {
+			if i == n {
+				return seekJSON(dec, path[1:])
+			}
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return 0, false
+			}
+		}
+	}
+	return 0, false
+}
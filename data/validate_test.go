@@ -0,0 +1,142 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caicloud/aloe/types"
+)
+
+const testCase = `description: "Get product"
+flow:
+- description: "Create"
+  request:
+    api: POST /products
+    body: |
+      {
+        "statuscode": 1
+      }
+  response:
+    statusCode: 201
+- description: "Get"
+  request:
+    api: GET /products/1
+  response:
+    statuscode: 200
+    bodyType: json
+`
+
+func TestLocateYAML(t *testing.T) {
+	cases := []struct {
+		path []string
+		line int
+		col  int
+		ok   bool
+	}{
+		{[]string{"description"}, 1, 1, true},
+		{[]string{"flow", "0", "description"}, 3, 3, true},
+		{[]string{"flow", "0", "response", "statusCode"}, 11, 5, true},
+		{[]string{"flow", "1", "response", "statuscode"}, 16, 5, true},
+		{[]string{"flow", "1", "response", "bodyType"}, 17, 5, true},
+		{[]string{"flow", "0", "request", "body", "statuscode"}, 0, 0, false},
+		{[]string{"flow", "2"}, 0, 0, false},
+	}
+	for _, c := range cases {
+		line, col, ok := locateYAML([]byte(testCase), c.path)
+		assert.Equal(t, c.ok, ok, "path: %v", c.path)
+		assert.Equal(t, c.line, line, "path: %v", c.path)
+		assert.Equal(t, c.col, col, "path: %v", c.path)
+	}
+}
+
+func TestLocateYAMLFlowStyle(t *testing.T) {
+	body := `flow:
+- {description: "Create", request: {api: POST /products}}
+- &get
+  request:
+    headers: {"X-Id": [1, 2]}
+- *get
+`
+	cases := []struct {
+		path []string
+		line int
+		col  int
+		ok   bool
+	}{
+		{[]string{"flow", "0", "request", "api"}, 2, 37, true},
+		{[]string{"flow", "1", "request", "headers", "X-Id", "1"}, 5, 27, true},
+		{[]string{"flow", "2", "request"}, 4, 3, true},
+		{[]string{"flow", "0", "response"}, 0, 0, false},
+	}
+	for _, c := range cases {
+		line, col, ok := locateYAML([]byte(body), c.path)
+		assert.Equal(t, c.ok, ok, "path: %v", c.path)
+		assert.Equal(t, c.line, line, "path: %v", c.path)
+		assert.Equal(t, c.col, col, "path: %v", c.path)
+	}
+}
+
+func TestLocateJSON(t *testing.T) {
+	body := `{
+  "flow": [
+    {"description": "a"},
+    {
+      "response": {"statuscode": 200}
+    }
+  ]
+}`
+	cases := []struct {
+		path []string
+		line int
+		col  int
+		ok   bool
+	}{
+		{[]string{"flow"}, 2, 3, true},
+		{[]string{"flow", "0", "description"}, 3, 6, true},
+		{[]string{"flow", "1", "response", "statuscode"}, 5, 20, true},
+		{[]string{"flow", "3"}, 0, 0, false},
+	}
+	for _, c := range cases {
+		line, col, ok := locateJSON([]byte(body), c.path)
+		assert.Equal(t, c.ok, ok, "path: %v", c.path)
+		assert.Equal(t, c.line, line, "path: %v", c.path)
+		assert.Equal(t, c.col, col, "path: %v", c.path)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		file   string
+		body   string
+		strict bool
+		err    string
+	}{
+		{"a.yaml", testCase, false, ""},
+		{"a.yaml", testCase, true, "a.yaml:16:5: unknown field flow[1].response.statuscode, did you mean statusCode?"},
+		{"a.yaml", "flow:\n- definitions: 1\n", false, "a.yaml:2:3: invalid field flow[0].definitions: can't use number as []types.Definition"},
+		{"a.json", `{"flow": [{"unknown": 1}]}`, true, "a.json:1:12: unknown field flow[0].unknown"},
+		{"a.json", `{"flow": [}`, false, "a.json:1:11: invalid character '}' looking for beginning of value"},
+	}
+	for _, c := range cases {
+		err := decode(c.file, []byte(c.body), &types.Case{}, Options{Strict: c.strict})
+		if c.err == "" {
+			assert.NoError(t, err, c.body)
+			continue
+		}
+		if assert.Error(t, err, c.body) {
+			assert.Equal(t, c.err, err.Error(), c.body)
+		}
+	}
+}
+
+func TestDecodeWarnings(t *testing.T) {
+	warnings := []string{}
+	opts := Options{Warn: func(msg string) {
+		warnings = append(warnings, msg)
+	}}
+	assert.NoError(t, decode("a.yaml", []byte(testCase), &types.Case{}, opts))
+	assert.Equal(t, []string{"a.yaml:16:5: unknown field flow[1].response.statuscode, did you mean statusCode?"}, warnings)
+	// warnings are dropped without Warn
+	assert.NoError(t, decode("a.yaml", []byte(testCase), &types.Case{}, Options{}))
+}
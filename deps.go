@@ -0,0 +1,161 @@
+package framework
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/ginkgo"
+)
+
+// caseOrder returns names of files in order of dependencies
+// Independent cases are sorted by name
+func caseOrder(files map[string]data.File) ([]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	order := []string{}
+	// states: 1 means visiting, 2 means visited
+	states := map[string]int{}
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		stack = append(stack, name)
+		switch states[name] {
+		case 1:
+			return fmt.Errorf("dependency cycle of cases: %v", strings.Join(stack, " -> "))
+		case 2:
+			return nil
+		}
+		states[name] = 1
+		for _, dep := range files[name].Case.DependsOn {
+			d, ok := resolveCase(files, dep)
+			if !ok {
+				return fmt.Errorf("case %v depends on unknown case %v", name, dep)
+			}
+			if err := visit(d, stack); err != nil {
+				return err
+			}
+		}
+		states[name] = 2
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// shuffleCases shuffles cases in order by r, positions of cases
+// which depend on or are depended on by other cases are kept
+func shuffleCases(files map[string]data.File, order []string, r *rand.Rand) []string {
+	related := map[string]bool{}
+	for name, f := range files {
+		for _, dep := range f.Case.DependsOn {
+			d, _ := resolveCase(files, dep)
+			related[name] = true
+			related[d] = true
+		}
+	}
+	free := []int{}
+	for i, name := range order {
+		if !related[name] {
+			free = append(free, i)
+		}
+	}
+	shuffled := append([]string{}, order...)
+	for i, j := range r.Perm(len(free)) {
+		shuffled[free[i]] = order[free[j]]
+	}
+	return shuffled
+}
+
+// seedOf returns seed of shuffling cases in dir, so that order of
+// cases doesn't depend on order of walking dirs
+func seedOf(seed int64, dir string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(dir))
+	return seed ^ int64(h.Sum64())
+}
+
+// resolveCase returns file name of case which is referenced by name
+// Extension of file can be omitted, e.g. create means create.yaml
+func resolveCase(files map[string]data.File, name string) (string, bool) {
+	if _, ok := files[name]; ok {
+		return name, true
+	}
+	for fn := range files {
+		if strings.TrimSuffix(fn, path.Ext(fn)) == name {
+			return fn, true
+		}
+	}
+	return "", false
+}
+
+// validateDependencies checks dependencies of cases in dir and all sub dirs
+func validateDependencies(dir *data.Dir) error {
+	if _, err := caseOrder(dir.Files); err != nil {
+		return fmt.Errorf("invalid dependencies in %v: %v", dir.Path, err)
+	}
+	for _, d := range dir.Dirs {
+		if err := validateDependencies(&d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// caseResults records cases which are passed on current node
+type caseResults struct {
+	lock   sync.Mutex
+	passed map[string]bool
+}
+
+func newCaseResults() *caseResults {
+	return &caseResults{
+		passed: map[string]bool{},
+	}
+}
+
+func (r *caseResults) pass(file string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.passed[file] = true
+}
+
+func (r *caseResults) isPassed(file string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.passed[file]
+}
+
+// withDependencies skips body if any dependency of case is not passed
+// and records the case if it is passed
+func (gf *genericFramework) withDependencies(ctx *types.Context, files map[string]data.File, name string, body func()) func() {
+	deps := []string{}
+	for _, dep := range files[name].Case.DependsOn {
+		// dependencies have been validated
+		d, _ := resolveCase(files, dep)
+		deps = append(deps, d)
+	}
+	return func() {
+		for _, d := range deps {
+			if !gf.results.isPassed(path.Join(ctx.Dir, d)) {
+				ginkgo.Skip(fmt.Sprintf("dependency %v is not passed", d))
+			}
+		}
+		body()
+		gf.results.pass(path.Join(ctx.Dir, name))
+	}
+}
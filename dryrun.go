@@ -0,0 +1,142 @@
+package framework
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+)
+
+// dryRun prints planned requests of all cases in dir to w
+// Variables defined by responses are replaced by placeholders
+func (gf *genericFramework) dryRun(w io.Writer, dir *data.Dir, s scope) error {
+	ctx := &types.Context{
+		Variables: builtinVariables(),
+	}
+	return gf.plan(w, ctx, dir, s)
+}
+
+func (gf *genericFramework) plan(w io.Writer, parent *types.Context, dir *data.Dir, s scope) error {
+	if dir.Context.Skip {
+		fmt.Fprintf(w, "%v: %v [skipped]\n", dir.Path, dir.Context.Summary)
+		return nil
+	}
+	fmt.Fprintf(w, "%v: %v\n", dir.Path, dir.Context.Summary)
+	// variables have been validated before planning
+	dirVs, _ := gf.dirVariables(dir)
+	ctx := &types.Context{
+		Variables:  withVariables(inheritedVariables(&dir.Context, parent.Variables, builtinVariables()), dirVs),
+		Dir:        dir.Path,
+		FS:         dir.FS,
+		Presetters: parent.Presetters,
+
+		RoundTripTemplate: parent.RoundTripTemplate,
+	}
+	if err := gf.planFlow(w, ctx, dir.Path, dir.Context.Flow); err != nil {
+		return err
+	}
+	// presetters of inner context are applied first
+	ctx.Presetters = append(append([]types.PresetConfig{}, dir.Context.Presetters...), parent.Presetters...)
+	ctx.RoundTripTemplate = types.MergeRoundTrip(dir.Context.Preset, parent.RoundTripTemplate)
+
+	for _, name := range dirNames(dir) {
+		d := dir.Dirs[name]
+		if err := gf.plan(w, ctx, &d, s.child(&d)); err != nil {
+			return err
+		}
+	}
+	// dependencies have been validated before planning
+	order, _ := caseOrder(dir.Files)
+	for _, name := range order {
+		c := dir.Files[name]
+		if !gf.tags.match(append(append([]string{}, s.tags...), c.Case.Tags...)) {
+			continue
+		}
+		file := path.Join(dir.Path, name)
+		if c.Case.Skip {
+			fmt.Fprintf(w, "%v: %v [skipped]\n", file, c.Case.Description)
+			continue
+		}
+		fmt.Fprintf(w, "%v: %v\n", file, c.Case.Description)
+		caseCtx := *ctx
+		caseCtx.Variables = copyVariables(ctx.Variables)
+		if err := gf.planFlow(w, &caseCtx, file, c.Case.Flow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planFlow prints requests of flow and defines placeholders of variables
+func (gf *genericFramework) planFlow(w io.Writer, ctx *types.Context, file string, flow []types.RoundTrip) error {
+	for i := range flow {
+		if fe := flow[i].ForEach; fe != nil {
+			as := fe.As
+			if as == "" {
+				as = types.DefaultForEachItem
+			}
+			// request of each item is printed once by placeholders
+			for _, name := range []string{as, fe.Index} {
+				if name != "" {
+					ctx.Variables[name] = placeholder(name, false)
+				}
+			}
+		}
+		rt, err := gf.preset(ctx, &flow[i])
+		if err != nil {
+			return fmt.Errorf("can't plan step %v of %v: %v", i+1, file, err)
+		}
+		req, err := gf.client.NewRequest(ctx, rt)
+		if err != nil {
+			return fmt.Errorf("can't plan step %v of %v: %v", i+1, file, err)
+		}
+		// expected body is only rendered because placeholders may be invalid json
+		if rt.Response.Body != nil {
+			if _, err := rt.Response.Body.Render(ctx.Variables); err != nil {
+				return fmt.Errorf("can't plan step %v of %v: can't render response body: %v", i+1, file, err)
+			}
+		}
+		notes := ""
+		if fe := flow[i].ForEach; fe != nil && fe.Items != nil {
+			notes += fmt.Sprintf(" (for each of %v)", fe.Items.Raw())
+		}
+		if when := flow[i].When; when != "" {
+			// conditions can't be evaluated by placeholders
+			notes += fmt.Sprintf(" (when %v)", when)
+		}
+		if name := flow[i].Name; name != "" {
+			notes = fmt.Sprintf(" [%v]", name) + notes
+		}
+		if deps := flow[i].DependsOn; len(deps) != 0 {
+			notes += fmt.Sprintf(" (depends on %v)", strings.Join(deps, ", "))
+		}
+		fmt.Fprintf(w, "  %v. %v %v%v\n", i+1, req.Method, req.URL, notes)
+		if req.Body != nil {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return fmt.Errorf("can't plan step %v of %v: %v", i+1, file, err)
+			}
+			if len(body) != 0 {
+				fmt.Fprintf(w, "     %v\n", strings.Replace(string(body), "\n", "\n     ", -1))
+			}
+		}
+		for _, def := range rt.Definitions {
+			ctx.Variables[def.Name] = placeholder(def.Name, def.Secret)
+		}
+	}
+	return nil
+}
+
+// placeholder returns a variable whose value is its name, e.g. <token>
+func placeholder(name string, secret bool) template.Variable {
+	return template.Variable{
+		Raw:    []byte("<" + name + ">"),
+		Name:   name,
+		Type:   template.StringType,
+		Secret: secret,
+	}
+}
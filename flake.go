@@ -0,0 +1,48 @@
+package framework
+
+import (
+	"github.com/caicloud/aloe/template"
+	"github.com/onsi/gomega"
+)
+
+// attemptFailure is used to stop an attempt of flaky case
+type attemptFailure struct {
+	message string
+}
+
+// interceptFailure runs f and returns message of the first failure
+// f is stopped when it is failed, and fail handler of gomega is
+// restored after f is returned
+func interceptFailure(f func()) string {
+	var (
+		failure string
+		p       interface{}
+	)
+	gomega.InterceptGomegaFailures(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if af, ok := r.(attemptFailure); ok {
+					failure = af.message
+				} else {
+					p = r
+				}
+			}
+		}()
+		gomega.RegisterFailHandler(func(message string, callerSkip ...int) {
+			panic(attemptFailure{message: message})
+		})
+		f()
+	})
+	if p != nil {
+		panic(p)
+	}
+	return failure
+}
+
+func copyVariables(vs map[string]template.Variable) map[string]template.Variable {
+	newVs := make(map[string]template.Variable, len(vs))
+	for k, v := range vs {
+		newVs[k] = v
+	}
+	return newVs
+}
@@ -0,0 +1,158 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/jsonutil"
+)
+
+// runForEach runs round trip for each item of its list
+// Variables defined by round trip are collected and added into context
+// It returns false if round trip is skipped for all items
+func (gf *genericFramework) runForEach(ctx *types.Context, step *types.RoundTrip) bool {
+	fe := step.ForEach
+	items, err := forEachItems(ctx, fe)
+	gf.expectNoError(err)
+
+	ran := false
+	collected := map[string]*collection{}
+	for i, itemVs := range items {
+		gf.by(fmt.Sprintf("Run for item %v/%v", i+1, len(items)))
+		itemCtx := *ctx
+		itemCtx.Variables = withVariables(ctx.Variables, itemVs)
+		vs, ok := gf.runStep(&itemCtx, step)
+		if !ok {
+			continue
+		}
+		ran = true
+		key := ""
+		if fe.Key != nil {
+			key, err = fe.Key.Render(itemCtx.Variables)
+			gf.expectNoError(err)
+		}
+		for name, v := range vs {
+			if collected[name] == nil {
+				collected[name] = newCollection(fe.Key != nil)
+			}
+			gf.expectNoError(collected[name].add(key, v))
+		}
+	}
+	for name, c := range collected {
+		v, err := c.variable(name)
+		gf.expectNoError(err)
+		ctx.Variables[name] = v
+	}
+	return ran
+}
+
+// forEachItems returns variables of each item of loop
+func forEachItems(ctx *types.Context, fe *types.ForEach) ([]map[string]template.Variable, error) {
+	if fe.Items == nil {
+		return nil, fmt.Errorf("items of for each should be set")
+	}
+	rendered, err := fe.Items.RenderJSON(ctx.Variables)
+	if err != nil {
+		return nil, err
+	}
+	raws := []json.RawMessage{}
+	if err := json.Unmarshal([]byte(rendered), &raws); err != nil {
+		return nil, fmt.Errorf("items of for each should be a json array: %v", err)
+	}
+	as := fe.As
+	if as == "" {
+		as = types.DefaultForEachItem
+	}
+	items := []map[string]template.Variable{}
+	for i, raw := range raws {
+		value, t, err := jsonutil.GetByJSONPath(raw, "$")
+		if err != nil {
+			return nil, fmt.Errorf("can't read item %v of for each: %v", i, err)
+		}
+		vs := map[string]template.Variable{
+			as: {
+				Raw:  value,
+				Name: as,
+				Type: t,
+			},
+		}
+		if fe.Index != "" {
+			vs[fe.Index] = template.Variable{
+				Raw:  []byte(fmt.Sprint(i)),
+				Name: fe.Index,
+				Type: template.NumberType,
+			}
+		}
+		items = append(items, vs)
+	}
+	return items, nil
+}
+
+// collection collects values of a variable defined in a loop
+type collection struct {
+	keyed  bool
+	values map[string]json.RawMessage
+	list   []json.RawMessage
+	secret bool
+}
+
+func newCollection(keyed bool) *collection {
+	return &collection{
+		keyed:  keyed,
+		values: map[string]json.RawMessage{},
+	}
+}
+
+// add adds value of variable of an item, values of keyed
+// collection can't be added by the same key
+func (c *collection) add(key string, v template.Variable) error {
+	raw := json.RawMessage(v.Raw)
+	if v.Type == template.StringType {
+		// values of strings are not quoted, and they are escaped if
+		// they are read from json, but not when they are from headers
+		raw = json.RawMessage(`"` + string(v.Raw) + `"`)
+		if !json.Valid(raw) {
+			quoted, err := json.Marshal(string(v.Raw))
+			if err != nil {
+				return err
+			}
+			raw = quoted
+		}
+	}
+	c.secret = c.secret || v.Secret
+	if !c.keyed {
+		c.list = append(c.list, raw)
+		return nil
+	}
+	if _, ok := c.values[key]; ok {
+		return fmt.Errorf("key %v of variable %v is duplicated", key, v.Name)
+	}
+	c.values[key] = raw
+	return nil
+}
+
+// variable returns collected values as an array or an object
+func (c *collection) variable(name string) (template.Variable, error) {
+	var raw []byte
+	var err error
+	t := template.ArrayType
+	if c.keyed {
+		raw, err = json.Marshal(c.values)
+		t = template.ObjectType
+	} else {
+		raw, err = json.Marshal(c.list)
+	}
+	if err != nil {
+		return template.Variable{}, fmt.Errorf("can't collect variable %v: %v", name, err)
+	}
+	// collected variables are typed, so they can be whole json strings
+	return template.Variable{
+		Raw:    raw,
+		Name:   name,
+		Type:   t,
+		Secret: c.secret,
+		Typed:  true,
+	}, nil
+}
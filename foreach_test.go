@@ -0,0 +1,97 @@
+package framework
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollection(t *testing.T) {
+	str := func(s string) template.Variable {
+		return template.Variable{Raw: []byte(s), Name: "v", Type: template.StringType}
+	}
+	num := template.Variable{Raw: []byte("1"), Name: "v", Type: template.NumberType}
+
+	list := newCollection(false)
+	// value read from json is escaped
+	assert.NoError(t, list.add("", str(`say \"hi\"`)))
+	// value of header is not escaped
+	assert.NoError(t, list.add("", str(`say "hi"`)))
+	assert.NoError(t, list.add("", str(`C:\`)))
+	assert.NoError(t, list.add("", num))
+	v, err := list.variable("v")
+	assert.NoError(t, err)
+	assert.Equal(t, `["say \"hi\"","say \"hi\"","C:\\",1]`, string(v.Raw))
+	assert.Equal(t, template.ArrayType, v.Type)
+	assert.True(t, json.Valid(v.Raw))
+
+	keyed := newCollection(true)
+	assert.NoError(t, keyed.add("a", str("x")))
+	assert.NoError(t, keyed.add("b", num))
+	// keys are not overridden
+	assert.Error(t, keyed.add("a", str("y")))
+	v, err = keyed.variable("v")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":"x","b":1}`, string(v.Raw))
+	assert.Equal(t, template.ObjectType, v.Type)
+}
+
+const forEachStep = `{
+	"forEach": {"items": %{items}, "as": "name"},
+	"request": {"api": "POST /products", "body": {"name": "%{name}"}},
+	"response": {"statusCode": 201},
+	"definitions": [{"name": "names", "jsonPath": "$.name"}]
+}`
+
+func TestRunForEach(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// body is echoed
+		w.WriteHeader(http.StatusCreated)
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer s.Close()
+	gf := NewFrameworkWithOptions(s.URL, func() {}, nil).(*genericFramework)
+
+	cases := []struct {
+		description string
+		items       string
+		key         string
+		expected    string
+		failure     string
+	}{
+		{"items are collected in order", `["apple", "say \"hi\""]`, "", `["apple","say \"hi\""]`, ""},
+		{"items are collected by key", `["apple", "banana"]`, "%{name}", `{"apple":"apple","banana":"banana"}`, ""},
+		{"key is duplicated", `["apple", "apple"]`, "%{name}", "", "key apple of variable names is duplicated"},
+	}
+	for _, c := range cases {
+		step := &types.RoundTrip{}
+		body := strings.Replace(forEachStep, "%{items}", c.items, 1)
+		if !assert.NoError(t, json.Unmarshal([]byte(body), step), c.description) {
+			continue
+		}
+		if c.key != "" {
+			key, err := types.NewTemplate(c.key)
+			assert.NoError(t, err, c.description)
+			step.ForEach.Key = key
+		}
+		ctx := &types.Context{Variables: map[string]template.Variable{}}
+		failure := interceptFailure(func() {
+			assert.True(t, gf.runForEach(ctx, step), c.description)
+		})
+		if c.failure != "" {
+			assert.Contains(t, failure, c.failure, c.description)
+			continue
+		}
+		if assert.Empty(t, failure, c.description) {
+			assert.Equal(t, c.expected, string(ctx.Variables["names"].Raw), c.description)
+		}
+	}
+}
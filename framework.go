@@ -1,139 +1,921 @@
 package framework
 
 import (
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
 	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/caicloud/aloe/cleaner"
 	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/matcher"
+	"github.com/caicloud/aloe/openapi"
+	"github.com/caicloud/aloe/preset"
+	"github.com/caicloud/aloe/reporter"
 	"github.com/caicloud/aloe/roundtrip"
 	"github.com/caicloud/aloe/template"
 	"github.com/caicloud/aloe/types"
 	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
 	"github.com/onsi/gomega"
 )
 
 // Framework defines an API test framework
 type Framework interface {
+	// RegisterPresetter registers presetters which can be
+	// referenced by name in context config
+	RegisterPresetter(ps ...preset.Presetter) error
+
+	// RegisterCleaner registers cleaners which are run in order of
+	// dependencies after each case of contexts selecting them
+	// Top level contexts without cleaners select all of them
+	RegisterCleaner(cs ...cleaner.Cleaner) error
+
+	// RegisterMatcher registers a custom matcher which can be
+	// referenced by $matcher in response body
+	RegisterMatcher(name string, f matcher.MatcherFunc) error
+
+	// RegisterDecoder registers a decoder of responses with content type
+	// Decoded responses are matched as json
+	RegisterDecoder(contentType string, d roundtrip.Decoder) error
+
+	// RegisterSetup registers functions which are called in order
+	// once before all cases by the first parallel node
+	RegisterSetup(fns ...SetupFn)
+
+	// RegisterTeardown registers functions which are called in reverse
+	// order once after all cases by the first parallel node
+	RegisterTeardown(fns ...TeardownFn)
+
 	Run() error
+
+	// ListCases returns contexts and cases in test data without running them
+	ListCases() ([]ContextInfo, error)
+
+	// Reporters returns custom reporters configured by options
+	// They should be passed to ginkgo.RunSpecsWithDefaultAndCustomReporters
+	Reporters() []ginkgo.Reporter
+
+	// ConnectionsOpened returns number of connections opened by client
+	// of current node, e.g. to check that connections are reused
+	ConnectionsOpened() int64
 }
 
 // ClearFn defines function to clear context
 type ClearFn func()
 
+// Option defines option of framework
+type Option func(gf *genericFramework)
+
+// WithClientOptions sets options of round trip client
+// e.g. tls config of client
+func WithClientOptions(opts ...roundtrip.Option) Option {
+	return func(gf *genericFramework) {
+		gf.clientOpts = append(gf.clientOpts, opts...)
+	}
+}
+
+// WithJUnitReport writes a junit xml report to path
+// when all specs are finished
+func WithJUnitReport(path string) Option {
+	return func(gf *genericFramework) {
+		gf.reporters = append(gf.reporters, reporter.NewJUnitReporterWithMask(path, gf.secrets.mask))
+	}
+}
+
+// WithHTMLReport writes a self-contained html report to path when all
+// specs are finished, it contains steps of cases with their requests
+// and responses, and failures are shown in steps where cases are failed
+// Contexts and cases of parallel nodes are merged by the first node
+func WithHTMLReport(path string) Option {
+	return func(gf *genericFramework) {
+		gf.html = reporter.NewHTMLReporter(path, gf.secrets.mask)
+		gf.reporters = append(gf.reporters, gf.html)
+	}
+}
+
+// WithCoverageReport writes a json report of operations in OpenAPI spec
+// which are and aren't covered by requests of round trips when all specs
+// are finished. Spec can be OpenAPI 3 or swagger 2 in json or yaml
+// Requests of parallel nodes are merged into one report by the first node
+func WithCoverageReport(spec, path string) Option {
+	return func(gf *genericFramework) {
+		s, err := openapi.Load(spec)
+		if err != nil {
+			gf.optErr = err
+			return
+		}
+		gf.coverage = reporter.NewCoverageReporter(path, s)
+		gf.reporters = append(gf.reporters, gf.coverage)
+	}
+}
+
+// WithOpenAPIValidation validates body of each http response by schema of
+// the operation in OpenAPI spec which is matched by method and path of
+// request, and declared for status code of response. Responses whose
+// operations or schemas are not declared are not validated
+func WithOpenAPIValidation(spec string) Option {
+	return func(gf *genericFramework) {
+		s, err := openapi.Load(spec)
+		if err != nil {
+			gf.optErr = err
+			return
+		}
+		gf.openapi = s
+	}
+}
+
+// WithWebhook posts a json summary to url when all specs are finished,
+// e.g. a slack incoming webhook. Text is a go template of summary,
+// see reporter.DefaultWebhookText. Errors of posting don't fail specs
+// Summaries of parallel nodes are merged and posted by the first node
+func WithWebhook(url, text string) Option {
+	return func(gf *genericFramework) {
+		r, err := reporter.NewWebhookReporter(url, text, gf.secrets.mask)
+		if err != nil {
+			gf.optErr = err
+			return
+		}
+		gf.reporters = append(gf.reporters, r)
+	}
+}
+
+// WithParallel allows specs to be run by parallel nodes of ginkgo,
+// e.g. ginkgo -nodes=4. Each node runs specs in its own process, so
+// context is isolated, but backend state is still shared. Variable
+// parallelNode can be used to generate different names of resources
+// and clearFn should only clear resources of current node.
+// Without it, Run returns error if specs are run in parallel
+func WithParallel() Option {
+	return func(gf *genericFramework) {
+		gf.parallel = true
+	}
+}
+
+// WithRandomSeed sets seed of random functions in templates
+// Default seed is random seed of ginkgo, e.g. -ginkgo.seed
+func WithRandomSeed(seed int64) Option {
+	return func(gf *genericFramework) {
+		gf.seed = &seed
+	}
+}
+
+// WithOutputDir sets dir of files written by cases, e.g. saved responses
+// Default dir is dir of test data
+func WithOutputDir(dir string) Option {
+	return func(gf *genericFramework) {
+		gf.outputDir = dir
+	}
+}
+
+// WithCurlOnFailure appends an equivalent curl command of request
+// to failure message when response is not matched
+func WithCurlOnFailure() Option {
+	return func(gf *genericFramework) {
+		gf.curl = true
+	}
+}
+
+// WithRedactedHeaders sets headers whose values are redacted in curl commands
+// Default headers are Authorization and Proxy-Authorization
+func WithRedactedHeaders(headers ...string) Option {
+	return func(gf *genericFramework) {
+		gf.redactedHeaders = headers
+	}
+}
+
+// WithCleanTimeout sets timeout of running cleaners after each case
+// Cleaners which are not finished in time are reported as failed
+// and only cleaners implementing cleaner.ContextCleaner are stopped
+func WithCleanTimeout(timeout time.Duration) Option {
+	return func(gf *genericFramework) {
+		gf.cleanTimeout = timeout
+	}
+}
+
+// WithFailFast stops running specs after the first failure
+// It is same as -ginkgo.failFast, cleaners and cleanUp of the
+// failed case are still called
+func WithFailFast() Option {
+	return func(gf *genericFramework) {
+		gf.failFast = true
+	}
+}
+
+// WithRandomOrder shuffles cases in each context by seed of
+// WithRandomSeed or random seed of ginkgo, the seed is printed
+// by Run. Cases with dependencies are not shuffled
+func WithRandomOrder() Option {
+	return func(gf *genericFramework) {
+		gf.randomOrder = true
+	}
+}
+
+// WithDryRun makes Run print planned requests of all cases to w
+// instead of registering specs, variables defined by responses are
+// printed as placeholders, e.g. <token>
+func WithDryRun(w io.Writer) Option {
+	return func(gf *genericFramework) {
+		gf.dryRunOut = w
+	}
+}
+
+// WithDataFS adds dirs of test data in file system, e.g. embed.FS
+// so that test data can be compiled into test binary
+func WithDataFS(fsys fs.FS, dirs ...string) Option {
+	return func(gf *genericFramework) {
+		for _, d := range dirs {
+			gf.fsDirs = append(gf.fsDirs, fsDir{fsys: fsys, dir: d})
+		}
+	}
+}
+
+// WithRemoteData adds remote sources of test data, e.g. a git repo
+// They are fetched into cache dir when framework is run
+func WithRemoteData(remotes ...data.Remote) Option {
+	return func(gf *genericFramework) {
+		gf.remotes = append(gf.remotes, remotes...)
+	}
+}
+
+// WithCacheDir sets dir to cache remote sources
+// Default dir is data.DefaultCacheDir
+func WithCacheDir(dir string) Option {
+	return func(gf *genericFramework) {
+		gf.cacheDir = dir
+	}
+}
+
+// WithStrictData rejects unknown fields in cases and contexts
+// Without it, unknown fields are only warned by logger
+func WithStrictData() Option {
+	return func(gf *genericFramework) {
+		gf.dataOpts.Strict = true
+	}
+}
+
 // NewFramework returns an API test framework
 func NewFramework(host string, clearFn ClearFn, dataDirs ...string) Framework {
-	return &genericFramework{
-		dataDirs,
-		roundtrip.NewClient(host),
-		clearFn,
+	return NewFrameworkWithOptions(host, clearFn, dataDirs)
+}
+
+// NewFrameworkWithOptions returns an API test framework with options
+func NewFrameworkWithOptions(host string, clearFn ClearFn, dataDirs []string, opts ...Option) Framework {
+	gf := &genericFramework{
+		dataDirs:   dataDirs,
+		clearFn:    clearFn,
+		presetters: map[string]preset.Presetter{},
+		cleaners:   map[string]cleaner.Cleaner{},
+
+		redactedHeaders: defaultRedactedHeaders,
+		logger:          defaultLogger(),
+		secrets:         newSecretSet(),
+		results:         newCaseResults(),
 	}
+	// options override env
+	gf.env = os.Getenv(EnvironmentEnv)
+	gf.updateGolden, gf.optErr = updateGoldenEnv()
+	gf.async, gf.asyncErr = asyncDefaults{
+		timeout:  defaultTimeout,
+		interval: defaultInterval,
+	}.loadEnv()
+	// built-in presetters will never be conflicted
+	gf.RegisterPresetter(
+		preset.NewHeaderPresetter(),
+		preset.NewAuthPresetter(),
+		preset.NewQueryPresetter(),
+		preset.NewHMACPresetter(),
+	)
+	for _, opt := range opts {
+		opt(gf)
+	}
+	gf.tags.loadEnv()
+	gf.client = roundtrip.NewClient(host, gf.clientOpts...)
+	// tokens are got by client of framework, e.g. with its tls config
+	gf.RegisterPresetter(preset.NewOAuth2Presetter(gf.client.HTTPClient()))
+	return gf
+}
+
+// fsDir is a dir of test data in file system
+type fsDir struct {
+	fsys fs.FS
+	dir  string
 }
 
 type genericFramework struct {
 	dataDirs []string
 
+	fsDirs []fsDir
+
+	remotes []data.Remote
+
+	cacheDir string
+
+	dataOpts data.Options
+
 	client *roundtrip.Client
 
+	clientOpts []roundtrip.Option
+
+	presetters map[string]preset.Presetter
+
+	cleaners map[string]cleaner.Cleaner
+
+	// sortedCleaners are cleaners in order of dependencies
+	sortedCleaners []cleaner.Cleaner
+
+	cleanTimeout time.Duration
+
+	failFast bool
+
+	async asyncDefaults
+	// asyncErr is error of loading async defaults from env
+	asyncErr error
+
+	// optErr is error of options, it is returned by Run
+	optErr error
+
+	// env selects variables of environment in variables files
+	env string
+
+	// updateGolden means golden files are written instead of matched
+	updateGolden bool
+
+	failureVerbosity types.FailureVerbosity
+
+	randomOrder bool
+	// orderSeed is seed of shuffling cases which is same on all nodes
+	orderSeed int64
+
+	setups []SetupFn
+
+	teardowns []TeardownFn
+
+	// suiteVs are variables added by setup functions
+	suiteVs map[string]template.Variable
+
+	logLevel LogLevel
+
+	logger Logger
+
+	// dryRunOut is writer of planned requests in dry run mode
+	dryRunOut io.Writer
+
+	reporters []ginkgo.Reporter
+
+	// html records steps of cases if html report is written
+	html *reporter.HTMLReporter
+	// coverage records requests if coverage report is written
+	coverage *reporter.CoverageReporter
+
+	// openapi validates bodies of responses if it is set
+	openapi *openapi.Spec
+
+	parallel bool
+
+	tags tagFilter
+
+	seed *int64
+
+	outputDir string
+
+	curl bool
+
+	redactedHeaders []string
+
+	secrets *secretSet
+
+	results *caseResults
+
+	// focus means any case or context is focused
+	focus bool
+
+	// onces are once contexts of running case
+	onces onceStack
+
 	clearFn ClearFn
 }
 
-func (gf *genericFramework) Run() error {
+func (gf *genericFramework) RegisterPresetter(ps ...preset.Presetter) error {
+	for _, p := range ps {
+		if _, ok := gf.presetters[p.Name()]; ok {
+			return fmt.Errorf("presetter %v has been registered", p.Name())
+		}
+		gf.presetters[p.Name()] = p
+	}
+	return nil
+}
+
+func (gf *genericFramework) RegisterCleaner(cs ...cleaner.Cleaner) error {
+	for _, c := range cs {
+		if _, ok := gf.cleaners[c.Name()]; ok {
+			return fmt.Errorf("cleaner %v has been registered", c.Name())
+		}
+		gf.cleaners[c.Name()] = c
+	}
+	return nil
+}
+
+func (gf *genericFramework) RegisterMatcher(name string, f matcher.MatcherFunc) error {
+	return matcher.Register(name, f)
+}
+
+func (gf *genericFramework) RegisterDecoder(contentType string, d roundtrip.Decoder) error {
+	return roundtrip.RegisterDecoder(contentType, d)
+}
+
+func (gf *genericFramework) Reporters() []ginkgo.Reporter {
+	return gf.reporters
+}
+
+// ConnectionsOpened implements Framework
+func (gf *genericFramework) ConnectionsOpened() int64 {
+	return gf.client.ConnectionsOpened()
+}
+
+// load reads all test data and validates dependencies of cases
+func (gf *genericFramework) load() ([]*data.Dir, error) {
+	if gf.dataOpts.Warn == nil {
+		gf.dataOpts.Warn = func(msg string) {
+			gf.logger.Printf("WARNING: %v", msg)
+		}
+	}
+	// golden files are written beside test data, so data should be on disk
+	if gf.updateGolden && (len(gf.fsDirs) != 0 || len(gf.remotes) != 0) {
+		return nil, fmt.Errorf("update golden mode only supports data dirs on disk, golden files of data in file systems or remotes can't be written back")
+	}
+	dirs := []*data.Dir{}
 	for _, r := range gf.dataDirs {
-		dir, err := data.Walk(r)
+		dir, err := data.WalkWithOptions(r, gf.dataOpts)
 		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+	for _, d := range gf.fsDirs {
+		dir, err := data.WalkFS(d.fsys, d.dir, gf.dataOpts)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+	for i := range gf.remotes {
+		dir, err := data.WalkRemote(&gf.remotes[i], gf.cacheDir, gf.dataOpts)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs {
+		if err := validateDependencies(dir); err != nil {
+			return nil, err
+		}
+		if err := gf.validateVariables(dir); err != nil {
+			return nil, err
+		}
+		if err := validateFlows(dir); err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+func (gf *genericFramework) Run() error {
+	if total := config.GinkgoConfig.ParallelTotal; total > 1 && !gf.parallel {
+		return fmt.Errorf("specs are run by %v parallel nodes, but parallel is not allowed by option", total)
+	}
+	seed := config.GinkgoConfig.RandomSeed
+	if gf.seed != nil {
+		seed = *gf.seed
+	}
+	if gf.optErr != nil {
+		return gf.optErr
+	}
+	if gf.asyncErr != nil {
+		return gf.asyncErr
+	}
+	if err := validateDurations(gf.async.timeout, gf.async.interval); err != nil {
+		return fmt.Errorf("invalid async defaults: %v", err)
+	}
+	if gf.failFast {
+		config.GinkgoConfig.FailFast = true
+	}
+	if gf.randomOrder {
+		gf.orderSeed = seed
+		fmt.Printf("aloe: cases are shuffled by seed %v\n", seed)
+	}
+	// random values of parallel nodes should be different
+	template.SetSeed(seed + int64(ginkgo.GinkgoParallelNode()))
+	sorted, err := cleaner.Sort(gf.cleaners)
+	if err != nil {
+		return err
+	}
+	gf.sortedCleaners = sorted
+
+	dirs, err := gf.load()
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if err := gf.validateCleaners(dir); err != nil {
 			return err
 		}
-		ctx := &types.Context{}
-		f := gf.walk(ctx, dir, true)
-		ginkgo.Describe(dir.Context.Summary, f)
+		gf.focus = gf.focus || hasFocus(dir)
+	}
+	if gf.dryRunOut != nil {
+		for _, dir := range dirs {
+			if err := gf.dryRun(gf.dryRunOut, dir, scope{tags: dir.Context.Tags}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	once := false
+	for _, dir := range dirs {
+		once = once || dir.Context.Once
+	}
+	gf.registerHooks(once)
+	for _, dir := range dirs {
+		ctx := &types.Context{
+			UpdateGolden:     gf.updateGolden,
+			FailureVerbosity: gf.failureVerbosity,
+		}
+		s := scope{
+			tags:    dir.Context.Tags,
+			focused: dir.Context.Focus,
+			once:    dir.Context.Once,
+		}
+		f := gf.walk(ctx, dir, true, s)
+		describe(true, dir.Context.Summary, &dir.Context, f)
 	}
 
 	return nil
 }
 
-func (gf *genericFramework) walk(ctx *types.Context, dir *data.Dir, isTop bool) func() {
+func (gf *genericFramework) walk(ctx *types.Context, dir *data.Dir, isTop bool, s scope) func() {
 	dirs, files := dir.Dirs, dir.Files
 	ctxConfig := dir.Context
+	tags := s.tags
+	once := s.once
+	cleaners := gf.contextCleaners(dir, isTop, &s)
+	// variables have been validated before walking
+	dirVs, _ := gf.dirVariables(dir)
 
 	return func() {
 		var contextVs map[string]template.Variable
+		var contextDir string
+		var contextPresetters []types.PresetConfig
+		var contextTemplate types.RoundTrip
+
+		var onceVs map[string]template.Variable
+		var onceErr error
+		oc := &onceContext{path: dir.Path}
+		oc.teardown = func() error {
+			err := cleaner.CleanWithTimeout(cleaners, onceVs, gf.cleanTimeout)
+			if isTop {
+				gf.clearFn()
+				ctx.Variables = nil
+				ctx.CookieJar = nil
+				ctx.FS = nil
+			}
+			return err
+		}
 
 		ginkgo.BeforeEach(func() {
+			// once contexts which are left are torn down first
+			construct, releaseErr := true, error(nil)
+			if once {
+				construct, releaseErr = gf.onces.enter(s.depth, oc)
+			} else {
+				releaseErr = gf.onces.release(s.depth)
+			}
+			if isTop && construct {
+				// cookie jar can't be created with nil options
+				jar, _ := cookiejar.New(nil)
+				ctx.CookieJar = jar
+				ctx.Variables = gf.rootVariables()
+				ctx.FS = dir.FS
+			}
 			contextVs = ctx.Variables
-			ctx.Variables, ctx.Error = gf.constructContext(ctx, &ctxConfig)
+			contextDir = ctx.Dir
+			ctx.Dir = dir.Path
+			if once {
+				if construct {
+					onceVs, onceErr = gf.constructContext(ctx, &ctxConfig, dirVs)
+				}
+				ctx.Variables, ctx.Error = copyVariables(onceVs), onceErr
+			} else {
+				ctx.Variables, ctx.Error = gf.constructContext(ctx, &ctxConfig, dirVs)
+			}
+			contextPresetters = ctx.Presetters
+			// presetters of inner context are applied first
+			ctx.Presetters = append(append([]types.PresetConfig{}, ctxConfig.Presetters...), ctx.Presetters...)
+			contextTemplate = ctx.RoundTripTemplate
+			ctx.RoundTripTemplate = types.MergeRoundTrip(ctxConfig.Preset, ctx.RoundTripTemplate)
+			// context is set before errors of left contexts are reported
+			gf.expectNoError(releaseErr)
 		})
 
 		ginkgo.AfterEach(func() {
-			if isTop {
+			// once contexts are torn down by onces
+			var cleanErr error
+			if !once {
+				cleanErr = cleaner.CleanWithTimeout(cleaners, ctx.Variables, gf.cleanTimeout)
+			}
+			if isTop && !once {
 				gf.clearFn()
 				ctx.Variables = nil
+				ctx.CookieJar = nil
+				ctx.FS = nil
 			} else {
 				ctx.Variables = contextVs
 			}
+			ctx.Dir = contextDir
+			ctx.Presetters = contextPresetters
+			ctx.RoundTripTemplate = contextTemplate
 			ctx.Error = nil
+			// context is restored before errors of cleaners are reported
+			gf.expectNoError(cleanErr)
 		})
 
-		for name, d := range dirs {
-			f := gf.walk(ctx, &d, false)
+		// sub contexts are registered in the same order on all parallel
+		// nodes, otherwise specs are not sharded correctly
+		for _, name := range dirNames(dir) {
+			d := dirs[name]
+			f := gf.walk(ctx, &d, false, s.child(&d))
 			summary := genSummary(name, d.Context.Summary)
-			ginkgo.Context(summary, f)
+			describe(false, summary, &d.Context, f)
+		}
+		// dependencies have been validated before walking
+		order, _ := caseOrder(files)
+		if gf.randomOrder {
+			order = shuffleCases(files, order, rand.New(rand.NewSource(seedOf(gf.orderSeed, dir.Path))))
 		}
-		for name, c := range files {
+		for _, name := range order {
+			c := files[name]
+			if !gf.tags.match(append(append([]string{}, tags...), c.Case.Tags...)) {
+				continue
+			}
 			summary := genSummary(name, c.Case.Description)
-			f := gf.itFunc(ctx, &c)
-			ginkgo.It(summary, f)
+			f := gf.withDependencies(ctx, files, name, gf.itFunc(ctx, &c))
+			it(summary, &c.Case, gf.releaseInner(s.depth, f))
 		}
 	}
 }
 
+const (
+	// ParallelNodeVariable is name of variable which is
+	// one-indexed number of current parallel node
+	ParallelNodeVariable = "parallelNode"
+)
+
+// builtinVariables returns variables which can be used in all contexts
+func builtinVariables() map[string]template.Variable {
+	return map[string]template.Variable{
+		ParallelNodeVariable: {
+			Raw:  []byte(strconv.Itoa(ginkgo.GinkgoParallelNode())),
+			Name: ParallelNodeVariable,
+			Type: template.NumberType,
+		},
+	}
+}
+
+// dirNames returns sorted names of sub dirs
+func dirNames(dir *data.Dir) []string {
+	names := make([]string, 0, len(dir.Dirs))
+	for name := range dir.Dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func genSummary(name, summary string) string {
 	return name + ": " + summary
 }
 
-var (
-	defaultTimeout  = 1 * time.Second
-	defaultInterval = 100 * time.Millisecond
-)
+// describe registers a ginkgo container by focus and skip of context
+// Skipped context is registered as pending
+func describe(isTop bool, summary string, c *types.ContextConfig, body func()) {
+	switch {
+	case c.Skip && isTop:
+		ginkgo.PDescribe(skipSummary(summary, c.SkipReason), body)
+	case c.Skip:
+		ginkgo.PContext(skipSummary(summary, c.SkipReason), body)
+	case c.Focus && isTop:
+		ginkgo.FDescribe(summary, body)
+	case c.Focus:
+		ginkgo.FContext(summary, body)
+	case isTop:
+		ginkgo.Describe(summary, body)
+	default:
+		ginkgo.Context(summary, body)
+	}
+}
+
+// it registers a ginkgo spec by focus and skip of case
+// Skipped case is registered as pending
+func it(summary string, c *types.Case, body func()) {
+	switch {
+	case c.Skip:
+		ginkgo.PIt(skipSummary(summary, c.SkipReason), body)
+	case c.Focus:
+		ginkgo.FIt(summary, body)
+	default:
+		ginkgo.It(summary, body)
+	}
+}
+
+func skipSummary(summary, reason string) string {
+	if reason == "" {
+		return summary + " [skipped]"
+	}
+	return summary + " [skipped: " + reason + "]"
+}
 
 func (gf *genericFramework) itFunc(ctx *types.Context, file *data.File) func() {
 	c := file.Case
 	return func() {
-		ginkgo.By("Context should be constructed successfully")
-		gomega.Expect(ctx.Error).NotTo(gomega.HaveOccurred())
+		gf.by("Context should be constructed successfully")
+		gf.expectNoError(ctx.Error)
 
-		for _, rt := range c.Flow {
-			ginkgo.By(rt.Description)
+		attempts := c.FlakeAttempts
+		if attempts < 1 {
+			attempts = 1
+		}
+		snapshot := copyVariables(ctx.Variables)
+		if c.Requests != nil {
+			// only requests of case are recorded
+			defer func() {
+				ctx.Recorder = nil
+			}()
+		}
+		for i := 1; i < attempts; i++ {
+			failure := interceptFailure(func() {
+				gf.runCaseFlow(ctx, &c)
+			})
+			if failure == "" {
+				if i > 1 {
+					gf.by(fmt.Sprintf("Case is passed on attempt %v/%v", i, attempts))
+				}
+				return
+			}
+			gf.by(fmt.Sprintf("Case is failed on attempt %v/%v, retry it: %v", i, attempts, failure))
+			ctx.Variables = copyVariables(snapshot)
+		}
+		gf.runCaseFlow(ctx, &c)
+		if attempts > 1 {
+			gf.by(fmt.Sprintf("Case is passed on attempt %v/%v", attempts, attempts))
+		}
+	}
+}
 
-			respMatcher, err := roundtrip.MatchResponse(ctx, &rt)
-			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+// runCaseFlow runs flow of case and checks requests sent by it
+func (gf *genericFramework) runCaseFlow(ctx *types.Context, c *types.Case) {
+	if c.Requests == nil {
+		gf.runFlow(ctx, c.Flow)
+		return
+	}
+	l := &requestLog{}
+	ctx.Recorder = l
+	gf.runFlow(ctx, c.Flow)
+	gf.by("Requests of case should be matched")
+	gf.expectNoError(checkRequests(c.Requests, l.sent(), ctx.Variables))
+}
 
-			if ev := rt.Response.Eventually; ev != nil {
-				timeout := ev.Timeout
-				if timeout == nil {
-					timeout = &types.Duration{defaultTimeout}
-				}
-				interval := ev.Interval
-				if interval == nil {
-					interval = &types.Duration{defaultInterval}
-				}
-				gomega.Eventually(func() *http.Response {
-					resp, err := gf.client.DoRequest(ctx, &rt)
-					gomega.Expect(err).NotTo(gomega.HaveOccurred())
-					return resp
-				}, ev.Timeout.Duration, ev.Interval.Duration).Should(respMatcher)
+// runFlow runs round trips of case in order
+// Variables defined by round trips are added into context
+func (gf *genericFramework) runFlow(ctx *types.Context, flow []types.RoundTrip) {
+	// ran contains names of round trips which are not skipped
+	ran := map[string]bool{}
+	for i := range flow {
+		gf.by(stepText(&flow[i]))
 
-			} else {
-				resp, err := gf.client.DoRequest(ctx, &rt)
-				gomega.Expect(err).NotTo(gomega.HaveOccurred())
-				gomega.Expect(resp).To(respMatcher)
+		if dep, skipped := skippedDependency(&flow[i], ran); skipped {
+			gf.by(fmt.Sprintf("Step is skipped because %v is skipped", dep))
+			continue
+		}
+		if flow[i].ForEach != nil {
+			if gf.runForEach(ctx, &flow[i]) && flow[i].Name != "" {
+				ran[flow[i].Name] = true
 			}
-			vs, err := respMatcher.Variables()
-			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			continue
+		}
+		vs, ok := gf.runStep(ctx, &flow[i])
+		if !ok {
+			continue
+		}
+		if flow[i].Name != "" {
+			ran[flow[i].Name] = true
+		}
+		for k, v := range vs {
+			ctx.Variables[k] = v
+		}
+	}
+}
 
-			for k, v := range vs {
-				ctx.Variables[k] = v
-			}
+// runStep runs a round trip and returns variables defined by it
+// It returns false if round trip is skipped by its condition
+func (gf *genericFramework) runStep(ctx *types.Context, step *types.RoundTrip) (map[string]template.Variable, bool) {
+	if when := step.When; when != "" {
+		ok, err := template.Evaluate(when, ctx.Variables)
+		gf.expectNoError(err)
+		if !ok {
+			gf.by(fmt.Sprintf("Step is skipped because %v is false", when))
+			return nil, false
+		}
+	}
+	rt, err := gf.preset(ctx, step)
+	gf.expectNoError(err)
+
+	respMatcher, err := gf.matchResponse(ctx, rt)
+	gf.expectNoError(err)
+
+	// dump is the last request and response of round trip
+	var dump string
+	if gf.dumped() {
+		respMatcher = &logHandler{
+			ResponseHandler: respMatcher,
+			gf:              gf,
+			dump:            &dump,
+			name:            rt.Name,
+		}
+	}
+	doRequest := func() *http.Response {
+		var resp *http.Response
+		var err error
+		resp, dump, err = gf.doRequest(ctx, rt)
+		gf.expectNoError(err)
+		if rt.SaveResponse != nil {
+			// response is saved before it is matched
+			// so that it can be inspected on failure
+			err := roundtrip.SaveResponse(ctx, rt.SaveResponse, gf.outputDirOf(ctx), resp)
+			gf.expectNoError(err)
 		}
+		return resp
+	}
+	ev, cs := rt.Response.Eventually, rt.Response.Consistently
+	if ev != nil {
+		timeout, interval, err := gf.async.durations(ev.Timeout, ev.Interval)
+		gf.expectNoError(err)
+		eventually(doRequest, respMatcher, timeout, interval, ev)
+	}
+	if cs != nil {
+		timeout, interval, err := gf.async.durations(cs.Timeout, cs.Interval)
+		gf.expectNoError(err)
+		gomega.Consistently(doRequest, timeout, interval).Should(respMatcher)
+	}
+	if ev == nil && cs == nil {
+		gomega.Expect(doRequest()).To(respMatcher)
 	}
+	vs, err := respMatcher.Variables()
+	gf.expectNoError(err)
+	gf.secrets.add(vs)
+	return vs, true
+}
 
+// matchResponse returns response handler of round trip
+// Secret variables are masked in its failure message
+func (gf *genericFramework) matchResponse(ctx *types.Context, rt *types.RoundTrip) (roundtrip.ResponseHandler, error) {
+	var validators []roundtrip.Validator
+	if gf.openapi != nil {
+		validators = append(validators, gf.openapi)
+	}
+	h, err := roundtrip.MatchResponseWithValidators(ctx, rt, validators...)
+	if err != nil {
+		return nil, err
+	}
+	if gf.curl {
+		h = &curlHandler{
+			ResponseHandler: h,
+			redactedHeaders: gf.redactedHeaders,
+		}
+	}
+	return &maskHandler{
+		ResponseHandler: h,
+		secrets:         gf.secrets,
+	}, nil
+}
+
+// by logs a step with secret variables masked
+func (gf *genericFramework) by(text string) {
+	if gf.html != nil {
+		gf.html.Step(text)
+	}
+	ginkgo.By(gf.secrets.mask(text))
+}
+
+// outputDirOf returns dir of files written by cases in context
+// Files are written into working dir if test data is not on disk
+func (gf *genericFramework) outputDirOf(ctx *types.Context) string {
+	if gf.outputDir != "" || ctx.FS != nil {
+		return gf.outputDir
+	}
+	return ctx.Dir
 }
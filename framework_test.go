@@ -0,0 +1,86 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/roundtrip"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirNames(t *testing.T) {
+	dir := &data.Dir{Dirs: map[string]data.Dir{}}
+	expected := []string{}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("context%02d", i)
+		dir.Dirs[name] = data.Dir{Name: name}
+		expected = append(expected, name)
+	}
+	// order of registering sub contexts should be the same on all nodes
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, dirNames(dir))
+	}
+	assert.Empty(t, dirNames(&data.Dir{}))
+}
+
+func TestWithDataFS(t *testing.T) {
+	products := fstest.MapFS{
+		"products/_context.yaml": {Data: []byte(`summary: "Products"`)},
+		"products/get.yaml":      {Data: []byte(`description: "Get product"`)},
+	}
+	orders := fstest.MapFS{
+		"orders/_context.yaml": {Data: []byte(`summary: "Orders"`)},
+		"orders/get.yaml":      {Data: []byte(`description: "Get order"`)},
+	}
+	gf := NewFrameworkWithOptions("localhost", func() {}, nil,
+		WithDataFS(products, "products"),
+		WithDataFS(orders, "orders"),
+	).(*genericFramework)
+	dirs, err := gf.load()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// each dir is read from its own file system
+	summaries := []string{}
+	for _, dir := range dirs {
+		summaries = append(summaries, dir.Context.Summary)
+	}
+	assert.Equal(t, []string{"Products", "Orders"}, summaries)
+}
+
+func TestConnectionsOpened(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	cases := []struct {
+		opts     []Option
+		expected int64
+	}{
+		{nil, 1},
+		{[]Option{WithClientOptions(roundtrip.WithDisableKeepAlives())}, 3},
+	}
+	for _, c := range cases {
+		f := NewFrameworkWithOptions(s.URL, func() {}, nil, c.opts...)
+		assert.Equal(t, int64(0), f.ConnectionsOpened())
+		gf := f.(*genericFramework)
+		for i := 0; i < 3; i++ {
+			rt := &types.RoundTrip{}
+			if !assert.NoError(t, json.Unmarshal([]byte(`{"request": {"api": "GET /products"}}`), rt)) {
+				return
+			}
+			resp, err := gf.client.DoRequest(&types.Context{}, rt)
+			if !assert.NoError(t, err) {
+				return
+			}
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		assert.Equal(t, c.expected, f.ConnectionsOpened())
+	}
+}
@@ -0,0 +1,36 @@
+package framework
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	// UpdateGoldenEnv defines env which enables update golden mode if it is true
+	UpdateGoldenEnv = "ALOE_UPDATE_GOLDEN"
+)
+
+// WithUpdateGolden enables or disables update golden mode, in which
+// golden files of responses are written by received responses instead
+// of matched. It overrides UpdateGoldenEnv
+// Only data dirs on disk are supported, data of WithDataFS and
+// WithRemoteData are rejected
+func WithUpdateGolden(update bool) Option {
+	return func(gf *genericFramework) {
+		gf.updateGolden = update
+	}
+}
+
+// updateGoldenEnv returns whether update golden mode is enabled by env
+func updateGoldenEnv() (bool, error) {
+	v := os.Getenv(UpdateGoldenEnv)
+	if v == "" {
+		return false, nil
+	}
+	update, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("can't parse env %v: %v", UpdateGoldenEnv, err)
+	}
+	return update, nil
+}
@@ -0,0 +1,45 @@
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateGoldenData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe-golden")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "_context.yaml"), []byte(`summary: "Products"`), 0644))
+	fsys := fstest.MapFS{
+		"products/_context.yaml": {Data: []byte(`summary: "Products"`)},
+	}
+
+	cases := []struct {
+		description string
+		dataDirs    []string
+		opts        []Option
+		hasError    bool
+	}{
+		{"data on disk", []string{dir}, nil, false},
+		{"data in file system", nil, []Option{WithDataFS(fsys, "products")}, true},
+		{"remote data", nil, []Option{WithRemoteData(data.Remote{URL: "https://example.com/cases.tar.gz"})}, true},
+	}
+	for _, c := range cases {
+		opts := append([]Option{WithUpdateGolden(true)}, c.opts...)
+		gf := NewFrameworkWithOptions("localhost", func() {}, c.dataDirs, opts...).(*genericFramework)
+		_, err := gf.load()
+		if !c.hasError {
+			assert.NoError(t, err, c.description)
+			continue
+		}
+		if assert.Error(t, err, c.description) {
+			assert.Contains(t, err.Error(), "update golden mode only supports data dirs on disk", c.description)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/onsi/ginkgo"
+)
+
+// SetupFn defines function which is called once before all cases
+// Variables added into vs are visible to all cases
+type SetupFn func(vs map[string]template.Variable) error
+
+// TeardownFn defines function which is called once after all cases
+// vs contains variables added by setup functions
+type TeardownFn func(vs map[string]template.Variable) error
+
+func (gf *genericFramework) RegisterSetup(fns ...SetupFn) {
+	gf.setups = append(gf.setups, fns...)
+}
+
+func (gf *genericFramework) RegisterTeardown(fns ...TeardownFn) {
+	gf.teardowns = append(gf.teardowns, fns...)
+}
+
+// registerHooks registers setup and teardown functions by
+// ginkgo.SynchronizedBeforeSuite and ginkgo.SynchronizedAfterSuite,
+// once contexts which are not left by cases are torn down on each node
+// Nothing is registered if there are no hooks and once contexts,
+// so that suites can still define their own BeforeSuite and AfterSuite
+func (gf *genericFramework) registerHooks(once bool) {
+	hooks := len(gf.setups) != 0 || len(gf.teardowns) != 0
+	if hooks {
+		ginkgo.SynchronizedBeforeSuite(func() []byte {
+			payload, err := gf.setupSuite()
+			gf.expectNoError(err)
+			return payload
+		}, func(payload []byte) {
+			gf.expectNoError(gf.loadSuite(payload))
+		})
+	}
+	if hooks || once {
+		ginkgo.SynchronizedAfterSuite(func() {
+			gf.expectNoError(gf.onces.release(0))
+		}, func() {
+			// teardown functions are called after all nodes are finished
+			gf.expectNoError(gf.teardown())
+		})
+	}
+}
+
+// setupSuite calls setup functions on the first parallel node
+// and returns variables added by them for all nodes
+func (gf *genericFramework) setupSuite() ([]byte, error) {
+	vs := builtinVariables()
+	for _, setup := range gf.setups {
+		err := setup(vs)
+		gf.secrets.add(vs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// built-in variables are different on each node
+	for name := range builtinVariables() {
+		delete(vs, name)
+	}
+	payload, err := json.Marshal(vs)
+	if err != nil {
+		return nil, fmt.Errorf("can't encode variables of setup functions: %v", err)
+	}
+	return payload, nil
+}
+
+// loadSuite sets variables added by setup functions on the first node
+func (gf *genericFramework) loadSuite(payload []byte) error {
+	vs := map[string]template.Variable{}
+	if err := json.Unmarshal(payload, &vs); err != nil {
+		return fmt.Errorf("can't decode variables of setup functions: %v", err)
+	}
+	gf.secrets.add(vs)
+	gf.suiteVs = vs
+	return nil
+}
+
+// teardown calls teardown functions in reverse order of registration
+// All of them are called even if some of them are failed
+func (gf *genericFramework) teardown() error {
+	vs := gf.rootVariables()
+	errs := []string{}
+	for i := len(gf.teardowns) - 1; i >= 0; i-- {
+		if err := gf.teardowns[i](vs); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("can't tear down suite: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// rootVariables returns variables of top level contexts
+func (gf *genericFramework) rootVariables() map[string]template.Variable {
+	vs := builtinVariables()
+	for k, v := range gf.suiteVs {
+		vs[k] = v
+	}
+	return vs
+}
@@ -0,0 +1,81 @@
+package framework
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupSuite(t *testing.T) {
+	first := &genericFramework{secrets: newSecretSet()}
+	first.RegisterSetup(func(vs map[string]template.Variable) error {
+		vs["tenantID"] = template.Variable{Name: "tenantID", Raw: []byte("t1"), Type: template.StringType}
+		return nil
+	}, func(vs map[string]template.Variable) error {
+		// variables of previous setup functions are visible
+		vs["token"] = template.Variable{Name: "token", Raw: []byte("secret-" + string(vs["tenantID"].Raw)), Type: template.StringType, Secret: true}
+		return nil
+	})
+	payload, err := first.setupSuite()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// other nodes only load variables of the first node
+	other := &genericFramework{secrets: newSecretSet()}
+	other.RegisterSetup(func(vs map[string]template.Variable) error {
+		t.Errorf("setup functions should not be called by other nodes")
+		return nil
+	})
+	if !assert.NoError(t, other.loadSuite(payload)) {
+		return
+	}
+	vs := other.rootVariables()
+	assert.Equal(t, "t1", string(vs["tenantID"].Raw))
+	assert.Equal(t, "secret-t1", string(vs["token"].Raw))
+	assert.Contains(t, vs, ParallelNodeVariable)
+	assert.Equal(t, "token: ***", other.secrets.mask("token: secret-t1"))
+
+	assert.Error(t, other.loadSuite([]byte("{")))
+}
+
+func TestSetupSuiteError(t *testing.T) {
+	gf := &genericFramework{secrets: newSecretSet()}
+	called := false
+	gf.RegisterSetup(func(vs map[string]template.Variable) error {
+		vs["token"] = template.Variable{Name: "token", Raw: []byte("secret"), Type: template.StringType, Secret: true}
+		return errors.New("can't create tenant by secret")
+	}, func(vs map[string]template.Variable) error {
+		called = true
+		return nil
+	})
+	_, err := gf.setupSuite()
+	assert.Error(t, err)
+	assert.False(t, called)
+	// variables added before failure are still masked
+	assert.Equal(t, "by ***", gf.secrets.mask("by secret"))
+}
+
+func TestTeardown(t *testing.T) {
+	gf := &genericFramework{
+		suiteVs: map[string]template.Variable{
+			"tenantID": {Name: "tenantID", Raw: []byte("t1"), Type: template.StringType},
+		},
+	}
+	order := []string{}
+	gf.RegisterTeardown(func(vs map[string]template.Variable) error {
+		order = append(order, "first")
+		return errors.New("first")
+	}, func(vs map[string]template.Variable) error {
+		order = append(order, "second:"+string(vs["tenantID"].Raw))
+		return errors.New("second")
+	})
+	err := gf.teardown()
+	// teardown functions are called in reverse order even if they are failed
+	assert.Equal(t, []string{"second:t1", "first"}, order)
+	if assert.Error(t, err) {
+		assert.Equal(t, "can't tear down suite: second; first", err.Error())
+	}
+}
@@ -0,0 +1,78 @@
+package framework
+
+import (
+	"path"
+
+	"github.com/caicloud/aloe/data"
+)
+
+// ContextInfo describes a context in test data
+type ContextInfo struct {
+	// Path is path of dir of context
+	Path string `json:"path"`
+
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Focus       bool     `json:"focus,omitempty"`
+	Skip        bool     `json:"skip,omitempty"`
+
+	// Contexts are sub contexts sorted by path
+	Contexts []ContextInfo `json:"contexts,omitempty"`
+
+	// Cases are sorted in order of running
+	Cases []CaseInfo `json:"cases,omitempty"`
+}
+
+// CaseInfo describes a case in test data
+type CaseInfo struct {
+	// Path is path of file of case
+	Path string `json:"path"`
+
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Focus       bool     `json:"focus,omitempty"`
+	Skip        bool     `json:"skip,omitempty"`
+	DependsOn   []string `json:"dependsOn,omitempty"`
+}
+
+func (gf *genericFramework) ListCases() ([]ContextInfo, error) {
+	dirs, err := gf.load()
+	if err != nil {
+		return nil, err
+	}
+	infos := []ContextInfo{}
+	for _, dir := range dirs {
+		infos = append(infos, contextInfo(dir))
+	}
+	return infos, nil
+}
+
+func contextInfo(dir *data.Dir) ContextInfo {
+	info := ContextInfo{
+		Path:        dir.Path,
+		Summary:     dir.Context.Summary,
+		Description: dir.Context.Description,
+		Tags:        dir.Context.Tags,
+		Focus:       dir.Context.Focus,
+		Skip:        dir.Context.Skip,
+	}
+	for _, name := range dirNames(dir) {
+		d := dir.Dirs[name]
+		info.Contexts = append(info.Contexts, contextInfo(&d))
+	}
+	// dependencies have been validated by load
+	order, _ := caseOrder(dir.Files)
+	for _, name := range order {
+		c := dir.Files[name].Case
+		info.Cases = append(info.Cases, CaseInfo{
+			Path:        path.Join(dir.Path, name),
+			Description: c.Description,
+			Tags:        c.Tags,
+			Focus:       c.Focus,
+			Skip:        c.Skip,
+			DependsOn:   c.DependsOn,
+		})
+	}
+	return info
+}
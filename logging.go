@@ -0,0 +1,161 @@
+package framework
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/caicloud/aloe/roundtrip"
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/ginkgo"
+)
+
+// Logger defines logger of requests and responses
+// It is implemented by *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LogLevel defines which requests and responses are logged
+type LogLevel int
+
+const (
+	// LogNone doesn't log requests and responses
+	LogNone LogLevel = iota
+
+	// LogFailures only logs requests and responses of failed round trips
+	LogFailures
+
+	// LogVerbose logs all requests and responses when they are received
+	LogVerbose
+)
+
+// WithLogLevel sets level of logging requests and responses
+// Secret variables and redacted headers are masked in logs
+func WithLogLevel(level LogLevel) Option {
+	return func(gf *genericFramework) {
+		gf.logLevel = level
+	}
+}
+
+// WithFailureVerbosity sets how mismatches of responses are rendered in
+// failure messages, full bodies are rendered by default
+func WithFailureVerbosity(v types.FailureVerbosity) Option {
+	return func(gf *genericFramework) {
+		switch v {
+		case types.FullFailures, types.TruncatedFailures, types.PathFailures:
+		default:
+			gf.optErr = fmt.Errorf("unknown failure verbosity %v", v)
+			return
+		}
+		gf.failureVerbosity = v
+	}
+}
+
+// WithLogger sets logger of requests and responses
+// Default logger writes to ginkgo.GinkgoWriter
+func WithLogger(l Logger) Option {
+	return func(gf *genericFramework) {
+		gf.logger = l
+	}
+}
+
+func defaultLogger() Logger {
+	return log.New(ginkgo.GinkgoWriter, "", 0)
+}
+
+// doRequest sends request of round trip and returns dump of it
+// Dump is empty if requests are not logged
+// Request is also recorded if coverage report is written
+func (gf *genericFramework) doRequest(ctx *types.Context, rt *types.RoundTrip) (*http.Response, string, error) {
+	resp, err := gf.client.DoRequest(ctx, rt)
+	if err == nil && gf.coverage != nil && resp.Request != nil {
+		gf.coverage.Hit(resp.Request.Method, resp.Request.URL.Path)
+	}
+	if err != nil || !gf.dumped() {
+		return resp, "", err
+	}
+	return resp, gf.dump(resp, rt.Response.Stream), nil
+}
+
+// dumped returns whether requests and responses are dumped
+// for logs or html report
+func (gf *genericFramework) dumped() bool {
+	return gf.logLevel != LogNone || gf.html != nil
+}
+
+// dump returns request and response in logs
+// Body of streamed response is not dumped
+func (gf *genericFramework) dump(resp *http.Response, stream bool) string {
+	lines := []string{}
+	if resp.Request != nil {
+		req, err := roundtrip.DumpRequest(resp.Request, gf.redactedHeaders...)
+		if err != nil {
+			req = err.Error()
+		}
+		lines = append(lines, "--> "+req)
+	}
+	out := roundtrip.DumpResponseHeader(resp)
+	if !stream {
+		var err error
+		if out, err = roundtrip.DumpResponse(resp); err != nil {
+			out = err.Error()
+		}
+	}
+	lines = append(lines, "<-- "+out)
+	return strings.Join(lines, "\n")
+}
+
+// logf logs with secret variables masked
+func (gf *genericFramework) logf(format string, v ...interface{}) {
+	gf.logger.Printf("%v", gf.secrets.mask(fmt.Sprintf(format, v...)))
+}
+
+// logHandler logs the last request and response of round trip
+// after it is matched, or only when it is failed to match
+// They are also added into html report
+type logHandler struct {
+	roundtrip.ResponseHandler
+
+	gf   *genericFramework
+	dump *string
+	// name is name of round trip which is logged with dump
+	name string
+}
+
+// Match implements gomegatypes.GomegaMatcher
+// Response is logged after it is matched, so secret variables
+// defined by it are also masked
+func (h *logHandler) Match(actual interface{}) (bool, error) {
+	matched, err := h.ResponseHandler.Match(actual)
+	if h.gf.html != nil && *h.dump != "" {
+		h.gf.html.Detail(*h.dump)
+	}
+	if h.gf.logLevel != LogVerbose || *h.dump == "" {
+		return matched, err
+	}
+	if matched && err == nil {
+		if vs, err := h.Variables(); err == nil {
+			h.gf.secrets.add(vs)
+		}
+	}
+	h.gf.logf("%v%v", h.prefix(), *h.dump)
+	return matched, err
+}
+
+// prefix returns prefix of logs, e.g. [create-product]
+func (h *logHandler) prefix() string {
+	if h.name == "" {
+		return ""
+	}
+	return fmt.Sprintf("[%v] ", h.name)
+}
+
+// FailureMessage implements gomegatypes.GomegaMatcher
+func (h *logHandler) FailureMessage(actual interface{}) string {
+	if h.gf.logLevel == LogFailures && *h.dump != "" {
+		h.gf.logf("%vround trip is failed:\n%v", h.prefix(), *h.dump)
+	}
+	return h.ResponseHandler.FailureMessage(actual)
+}
@@ -0,0 +1,79 @@
+package matcher
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// MatcherFunc defines a custom matcher
+// It returns error which describes the mismatch
+type MatcherFunc func(actual interface{}) error
+
+var (
+	customLock sync.RWMutex
+	customs    = map[string]MatcherFunc{}
+)
+
+// Register registers a custom matcher which can be used by $matcher
+//
+//	{"id": {"$matcher": "ulid"}}
+func Register(name string, f MatcherFunc) error {
+	if name == "" {
+		return fmt.Errorf("name of matcher should not be empty")
+	}
+	if f == nil {
+		return fmt.Errorf("matcher %v should not be nil", name)
+	}
+	customLock.Lock()
+	defer customLock.Unlock()
+	if _, ok := customs[name]; ok {
+		return fmt.Errorf("matcher %v has been registered", name)
+	}
+	customs[name] = f
+	return nil
+}
+
+func generateCustomMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	name, ok := expr.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of $matcher MUST be a string, actual: %T", expr)
+	}
+	customLock.RLock()
+	f, ok := customs[name]
+	customLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("matcher %v is not registered", name)
+	}
+	return &customMatcher{
+		name: name,
+		f:    f,
+	}, nil
+}
+
+// customMatcher matches value by a registered matcher
+type customMatcher struct {
+	name string
+	f    MatcherFunc
+
+	// State.
+	err error
+}
+
+// Match implements types.GomegaMatcher
+func (m *customMatcher) Match(actual interface{}) (bool, error) {
+	m.err = m.f(actual)
+	return m.err == nil, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *customMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to match %v: %v", m.name, m.err))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *customMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to match %v", m.name))
+}
@@ -0,0 +1,88 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// length matchers and their descriptions
+var lengthMatchers = map[string]struct {
+	match       func(length, bound int) bool
+	description string
+}{
+	LenMatcher: {func(length, bound int) bool {
+		return length == bound
+	}, "exactly"},
+	MinLenMatcher: {func(length, bound int) bool {
+		return length >= bound
+	}, "at least"},
+	MaxLenMatcher: {func(length, bound int) bool {
+		return length <= bound
+	}, "at most"},
+}
+
+func generateLengthMatcher(key string, expr interface{}) (types.GomegaMatcher, error) {
+	f, ok := toFloat(expr)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("value of %v MUST be a non-negative integer, actual: %v", key, expr)
+	}
+	lm := lengthMatchers[key]
+	return &lengthMatcher{
+		bound:       int(f),
+		match:       lm.match,
+		description: lm.description,
+	}, nil
+}
+
+// lengthMatcher matches length of array, object or string
+// Length of string is number of characters
+type lengthMatcher struct {
+	bound       int
+	match       func(length, bound int) bool
+	description string
+}
+
+// lengthOf returns length of value or false if it has no length
+func lengthOf(actual interface{}) (int, bool) {
+	switch v := actual.(type) {
+	case string:
+		return utf8.RuneCountInString(v), true
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	}
+	return 0, false
+}
+
+// Match implements types.GomegaMatcher
+// Value which has no length is a failure
+func (m *lengthMatcher) Match(actual interface{}) (bool, error) {
+	length, ok := lengthOf(actual)
+	if !ok {
+		return false, nil
+	}
+	return m.match(length, m.bound), nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *lengthMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to have length %v %v, actual length: %v", m.description, m.bound, m.actual(actual)))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *lengthMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to have length %v %v, actual length: %v", m.description, m.bound, m.actual(actual)))
+}
+
+func (m *lengthMatcher) actual(actual interface{}) string {
+	length, ok := lengthOf(actual)
+	if !ok {
+		return "none"
+	}
+	return fmt.Sprint(length)
+}
@@ -4,70 +4,175 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/onsi/gomega"
 	gomegatypes "github.com/onsi/gomega/types"
 )
 
-func generateMapMatcher(matcher map[string]interface{}) (gomegatypes.GomegaMatcher, error) {
+func (p *parser) generateMapMatcher(matcher map[string]interface{}) (gomegatypes.GomegaMatcher, error) {
 	fields := Fields{}
 	exists := map[string]bool{}
 	for k, expr := range matcher {
-		if expr == nil {
-			fields[k] = gomega.BeNil()
-			continue
-		}
-		isSpMatcher := false
-		typ := reflect.TypeOf(expr)
-		if typ.Kind() == reflect.Map {
-			m, err := convertToMap(expr)
-			if err != nil {
-				return nil, err
-			}
-			for childKey, childExpr := range m {
-				switch childKey {
-				case RegexpMatcher:
-					ma, err := generateRegexpMatcher(childExpr)
-					if err != nil {
-						return nil, err
-					}
-					fields[k] = ma
-					isSpMatcher = true
-				case ExistsMatcher:
-					b, ok := childExpr.(bool)
-					if !ok {
-						return nil, fmt.Errorf("value of $exists MUST be bool, actual: %T", expr)
-					}
-
-					if len(m) != 1 {
-						if !b {
-							return nil, fmt.Errorf("if $exists is false, all other matchers will be ignored")
-						}
-						isSpMatcher = false
-					} else {
-						exists[k] = b
-						isSpMatcher = true
-					}
+		if m, ok := expr.(map[string]interface{}); ok {
+			m = negateExists(m)
+			if e, ok := m[ExistsMatcher]; ok {
+				b, ok := e.(bool)
+				if !ok {
+					return nil, fmt.Errorf("value of $exists MUST be bool, actual: %T", e)
 				}
+				if len(m) == 1 {
+					exists[k] = b
+					continue
+				}
+				if !b {
+					return nil, fmt.Errorf("if $exists is false, all other matchers will be ignored")
+				}
+				expr = withoutKey(m, ExistsMatcher)
 			}
 		}
-		if !isSpMatcher {
-			ma, err := generateMatcher(expr)
-			if err != nil {
-				return nil, err
-			}
-			fields[k] = ma
+		ma, err := p.generateMatcher(expr)
+		if err != nil {
+			return nil, err
 		}
+		fields[k] = ma
 	}
 	return MatchMap(fields, exists), nil
 }
 
+// generateSpecialMatcher generates matcher from a map whose keys
+// are all special matchers, e.g. {"$regexp": "^a"}
+// It returns false if map is not a special matcher
+func (p *parser) generateSpecialMatcher(matcher map[string]interface{}) (gomegatypes.GomegaMatcher, bool, error) {
+	ms := []gomegatypes.GomegaMatcher{}
+	special := 0
+	for k, expr := range matcher {
+		var (
+			ma  gomegatypes.GomegaMatcher
+			err error
+		)
+		special++
+		switch k {
+		case NotMatcher:
+			ma, err = p.generateNotMatcher(expr)
+		case RegexpMatcher:
+			ma, err = generateRegexpMatcher(expr)
+		case UnorderedMatcher:
+			ma, err = p.generateUnorderedMatcher(expr)
+		case CustomMatcher:
+			ma, err = generateCustomMatcher(expr)
+		case GreaterThanMatcher, GreaterThanOrEqualMatcher, LessThanMatcher, LessThanOrEqualMatcher:
+			ma, err = generateCompareMatcher(k, expr)
+		case ContainsMatcher, HasPrefixMatcher, HasSuffixMatcher, TextMatcher:
+			ma, err = generateStringMatcher(k, matcher)
+		case IgnoreCaseMatcher, IgnoreSpaceMatcher:
+			// they are options of string matchers
+			if !hasStringMatcher(matcher) {
+				return nil, true, fmt.Errorf("%v can only be used with string matchers", k)
+			}
+			continue
+		case TimeMatcher:
+			ma, err = generateTimeMatcher(matcher)
+		case WithinMatcher, AfterMatcher, BeforeMatcher:
+			// they are options of $time
+			if _, ok := matcher[TimeMatcher]; !ok {
+				return nil, true, fmt.Errorf("%v can only be used with %v", k, TimeMatcher)
+			}
+			continue
+		case TypeMatcher:
+			ma, err = generateTypeMatcher(expr)
+		case LenMatcher, MinLenMatcher, MaxLenMatcher:
+			ma, err = generateLengthMatcher(k, expr)
+		case ApproxMatcher:
+			ma, err = generateApproxMatcher(matcher)
+		case ToleranceMatcher, RelativeToleranceMatcher:
+			// they are options of $approx
+			if _, ok := matcher[ApproxMatcher]; !ok {
+				return nil, true, fmt.Errorf("%v can only be used with %v", k, ApproxMatcher)
+			}
+			continue
+		default:
+			special--
+			continue
+		}
+		if err != nil {
+			return nil, true, err
+		}
+		ms = append(ms, ma)
+	}
+	switch {
+	case special == 0:
+		return nil, false, nil
+	case special != len(matcher):
+		return nil, true, fmt.Errorf("special matchers can't be mixed with fields: %v", keys(matcher))
+	case len(ms) == 1:
+		return ms[0], true, nil
+	}
+	return gomega.And(ms...), true, nil
+}
+
+// negateExists converts {"$not": {"$exists": b}} to {"$exists": !b}
+// because existance is checked by map matcher
+func negateExists(m map[string]interface{}) map[string]interface{} {
+	if len(m) != 1 {
+		return m
+	}
+	n, ok := m[NotMatcher].(map[string]interface{})
+	if !ok || len(n) != 1 {
+		return m
+	}
+	b, ok := n[ExistsMatcher].(bool)
+	if !ok {
+		return m
+	}
+	return map[string]interface{}{ExistsMatcher: !b}
+}
+
+func (p *parser) generateNotMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+	ma, err := p.generateMatcher(expr)
+	if err != nil {
+		return nil, err
+	}
+	return gomega.Not(ma), nil
+}
+
 func generateRegexpMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
 	s, ok := expr.(string)
 	if !ok {
 		return nil, fmt.Errorf("value of $regexp MUST be a string, actual: %T", expr)
 	}
-	return gomega.MatchRegexp(s), nil
+	return MatchRegexp(s)
+}
+
+func (p *parser) generateUnorderedMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+	s, ok := expr.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value of $unordered MUST be an array, actual: %T", expr)
+	}
+	elems, err := p.generateElements(s)
+	if err != nil {
+		return nil, err
+	}
+	return MatchUnorderedSlice(elems, p.opts.Subset), nil
+}
+
+func withoutKey(m map[string]interface{}, key string) map[string]interface{} {
+	n := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			n[k] = v
+		}
+	}
+	return n
+}
+
+func keys(m map[string]interface{}) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
 }
 
 func convertToMap(expr interface{}) (map[string]interface{}, error) {
@@ -83,23 +188,115 @@ const (
 	// ExistsMatcher defines special matcher to match non-existant key
 	ExistsMatcher = "$exists"
 
+	// NotMatcher defines matcher which negates its value
+	NotMatcher = "$not"
+
 	// RegexpMatcher defines matcher to match regexp
 	RegexpMatcher = "$regexp"
+
+	// UnorderedMatcher defines matcher to match array regardless of order
+	UnorderedMatcher = "$unordered"
+
+	// CustomMatcher defines matcher which is registered by Register
+	CustomMatcher = "$matcher"
+
+	// GreaterThanMatcher defines matcher of number greater than value
+	GreaterThanMatcher = "$gt"
+
+	// GreaterThanOrEqualMatcher defines matcher of number greater than or equal to value
+	GreaterThanOrEqualMatcher = "$gte"
+
+	// LessThanMatcher defines matcher of number less than value
+	LessThanMatcher = "$lt"
+
+	// LessThanOrEqualMatcher defines matcher of number less than or equal to value
+	LessThanOrEqualMatcher = "$lte"
+
+	// ApproxMatcher defines matcher of number approximately equal to value
+	ApproxMatcher = "$approx"
+
+	// ToleranceMatcher defines absolute tolerance of $approx
+	ToleranceMatcher = "$tolerance"
+
+	// RelativeToleranceMatcher defines relative tolerance of $approx
+	RelativeToleranceMatcher = "$relTolerance"
+
+	// ContainsMatcher defines matcher of string containing value
+	ContainsMatcher = "$contains"
+
+	// HasPrefixMatcher defines matcher of string with prefix
+	HasPrefixMatcher = "$hasPrefix"
+
+	// HasSuffixMatcher defines matcher of string with suffix
+	HasSuffixMatcher = "$hasSuffix"
+
+	// TimeMatcher defines matcher of time in a layout, e.g. RFC3339,
+	// Unix, UnixMilli or a layout of golang
+	TimeMatcher = "$time"
+
+	// WithinMatcher defines max duration between time and now of $time
+	WithinMatcher = "$within"
+
+	// AfterMatcher defines time which should be before value of $time
+	AfterMatcher = "$after"
+
+	// BeforeMatcher defines time which should be after value of $time
+	BeforeMatcher = "$before"
+
+	// TypeMatcher defines matcher of json type of value, e.g. string
+	// or a list of types, e.g. [string, null]
+	TypeMatcher = "$type"
+
+	// LenMatcher defines matcher of array, object or string with length
+	LenMatcher = "$len"
+
+	// MinLenMatcher defines matcher of array, object or string
+	// with at least length
+	MinLenMatcher = "$minLen"
+
+	// MaxLenMatcher defines matcher of array, object or string
+	// with at most length
+	MaxLenMatcher = "$maxLen"
+
+	// TextMatcher defines matcher of string equal to value
+	// It is usually used with $ignoreCase or $ignoreSpace
+	TextMatcher = "$text"
+
+	// IgnoreCaseMatcher makes string matchers case-insensitive
+	IgnoreCaseMatcher = "$ignoreCase"
+
+	// IgnoreSpaceMatcher makes string matchers trim spaces and
+	// treat consecutive spaces as a single space
+	IgnoreSpaceMatcher = "$ignoreSpace"
 )
 
-func generateSliceMatcher(matcher []interface{}) (gomegatypes.GomegaMatcher, error) {
+func (p *parser) generateElements(matcher []interface{}) (Elements, error) {
 	elems := Elements{}
 	for _, expr := range matcher {
-		elem, err := generateMatcher(expr)
+		elem, err := p.generateMatcher(expr)
 		if err != nil {
 			return nil, err
 		}
 		elems = append(elems, elem)
 	}
+	return elems, nil
+}
+
+func (p *parser) generateSliceMatcher(matcher []interface{}) (gomegatypes.GomegaMatcher, error) {
+	elems, err := p.generateElements(matcher)
+	if err != nil {
+		return nil, err
+	}
+	if p.opts.Subset {
+		return MatchSubSlice(elems), nil
+	}
 	return MatchSlice(elems), nil
 }
 
-func generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+func (p *parser) generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+	if expr == nil {
+		return gomega.BeNil(), nil
+	}
 	t := reflect.TypeOf(expr)
 	switch t.Kind() {
 	case reflect.String, reflect.Bool:
@@ -111,23 +308,54 @@ func generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
 		if !ok {
 			return nil, fmt.Errorf("expr type %T is a slice(array) but can't be []interface{}", expr)
 		}
-		return generateSliceMatcher(s)
+		return p.generateSliceMatcher(s)
 	case reflect.Map:
 		m, ok := expr.(map[string]interface{})
 		if !ok {
 			return nil, fmt.Errorf("expr type %T is a map but can't be map[string]interface{}", expr)
 		}
-
-		return generateMapMatcher(m)
+		if sm, ok, err := p.generateSpecialMatcher(m); ok {
+			return sm, err
+		}
+		return p.generateMapMatcher(m)
 	}
 	return nil, fmt.Errorf("unexpected type %T: all kinds are from json.Unmarshal", expr)
 }
 
+// Options defines options of parsing matcher
+type Options struct {
+	// Subset allows extra elements in arrays
+	// Expected elements are still matched in order
+	// Extra fields in objects are always ignored
+	Subset bool
+}
+
+// parser generates matcher by options
+type parser struct {
+	opts Options
+}
+
 // Parse parse matcher of response and returns GomegaMatcher
 func Parse(matcher string) (gomegatypes.GomegaMatcher, error) {
+	return ParseWithOptions(matcher, Options{})
+}
+
+// ParseWithOptions parse matcher of response by options
+func ParseWithOptions(matcher string, opts Options) (gomegatypes.GomegaMatcher, error) {
 	m := map[string]interface{}{}
 	if err := json.Unmarshal([]byte(matcher), &m); err != nil {
 		return nil, err
 	}
-	return generateMatcher(m)
+	p := &parser{opts: opts}
+	return p.generateMatcher(m)
+}
+
+// ParseValue parse matcher of any json value and returns GomegaMatcher
+func ParseValue(matcher string) (gomegatypes.GomegaMatcher, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(matcher), &v); err != nil {
+		return nil, err
+	}
+	p := &parser{}
+	return p.generateMatcher(v)
 }
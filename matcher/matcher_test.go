@@ -0,0 +1,201 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		matcher  string
+		actual   string
+		matched  bool
+		hasError bool
+	}{
+		{`{"a": "b"}`, `{"a": "b"}`, true, false},
+		{`{"a": "b"}`, `{"a": "c"}`, false, false},
+		{`{"a": {"$regexp": "^[0-9]+$"}}`, `{"a": "123"}`, true, false},
+		{`{"a": {"$regexp": "^[0-9]+$"}}`, `{"a": "12a"}`, false, false},
+		{`{"a": {"$regexp": "^[0-9]+$"}}`, `{"a": 123}`, false, false},
+		{`{"a": {"$regexp": "^[0-9]+$"}}`, `{}`, false, false},
+		{`{"a": [{"$regexp": "^x"}, "y"]}`, `{"a": ["xx", "y"]}`, true, false},
+		{`{"a": {"$regexp": "^x", "$exists": true}}`, `{"a": "xx"}`, true, false},
+		{`{"a": {"$exists": false}}`, `{}`, true, false},
+		{`{"a": {"$exists": false}}`, `{"a": 1}`, false, false},
+		{`{"a": {"$not": 1}}`, `{"a": 2}`, true, false},
+		{`{"a": {"$not": 1}}`, `{"a": 1}`, false, false},
+		{`{"a": {"$not": {"$regexp": "^x"}}}`, `{"a": "yy"}`, true, false},
+		{`{"a": {"$not": {"b": 1}}}`, `{"a": {"b": 1}}`, false, false},
+		{`{"a": {"$not": {"$exists": true}}}`, `{}`, true, false},
+		{`{"a": {"$not": {"$exists": true}}}`, `{"a": 1}`, false, false},
+		{`{"a": {"$not": {"$regexp": "("}}}`, `{}`, false, true},
+		{`{"a": "$regexp"}`, `{"a": "$regexp"}`, true, false},
+		{`{"a": {"$unordered": [1, 2]}}`, `{"a": [2, 1]}`, true, false},
+		{`{"a": {"$unordered": [1, 2]}}`, `{"a": [2, 1, 3]}`, false, false},
+		{`{"a": {"$unordered": [1, 1]}}`, `{"a": [1, 2]}`, false, false},
+		{`{"a": {"$unordered": [{"$regexp": "^a"}, "ab"]}}`, `{"a": ["ab", "ac"]}`, true, false},
+		{`{"a": {"$unordered": [{"id": 1}, {"id": 2}]}}`, `{"a": [{"id": 2, "x": 1}, {"id": 1}]}`, true, false},
+		{`{"a": {"$unordered": 1}}`, `{}`, false, true},
+		{`{"a": {"$gt": 0}}`, `{"a": 1}`, true, false},
+		{`{"a": {"$gt": 0}}`, `{"a": 0}`, false, false},
+		{`{"a": {"$gte": 0, "$lte": 100}}`, `{"a": 100}`, true, false},
+		{`{"a": {"$gte": 0, "$lt": 100}}`, `{"a": 100}`, false, false},
+		{`{"a": {"$lt": 1.5}}`, `{"a": 1.25}`, true, false},
+		{`{"a": {"$gt": 0}}`, `{"a": "1"}`, false, false},
+		{`{"a": {"$gt": "0"}}`, `{}`, false, true},
+		{`{"a": {"$approx": 0.3}}`, `{"a": 0.3}`, true, false},
+		{`{"a": {"$approx": 0.3}}`, `{"a": 0.30000000000000004}`, false, false},
+		{`{"a": {"$approx": 0.3, "$tolerance": 1e-9}}`, `{"a": 0.30000000000000004}`, true, false},
+		{`{"a": {"$approx": 100, "$relTolerance": 0.01}}`, `{"a": 101}`, true, false},
+		{`{"a": {"$approx": 100, "$relTolerance": 0.01}}`, `{"a": 102}`, false, false},
+		{`{"a": {"$approx": 3, "$tolerance": 0.5}}`, `{"a": 3}`, true, false},
+		{`{"a": {"$tolerance": 0.5}}`, `{}`, false, true},
+		{`{"a": {"$approx": 1, "$tolerance": -1}}`, `{}`, false, true},
+		{`{"a": {"$contains": "not found"}}`, `{"a": "product 1 is not found"}`, true, false},
+		{`{"a": {"$contains": "not found"}}`, `{"a": "product 1 is found"}`, false, false},
+		{`{"a": {"$hasPrefix": "prod", "$hasSuffix": "found"}}`, `{"a": "product 1 is found"}`, true, false},
+		{`{"a": {"$hasSuffix": "1"}}`, `{"a": 1}`, false, false},
+		{`{"a": {"$contains": 1}}`, `{}`, false, true},
+		{`{"a": {"$text": "Not Found"}}`, `{"a": "not found"}`, false, false},
+		{`{"a": {"$text": "Not Found", "$ignoreCase": true}}`, `{"a": "not found"}`, true, false},
+		{`{"a": {"$text": "not found", "$ignoreSpace": true}}`, `{"a": " not\n  found "}`, true, false},
+		{`{"a": {"$contains": "IS NOT", "$ignoreCase": true, "$ignoreSpace": true}}`, `{"a": "product is\tnot found"}`, true, false},
+		{`{"a": {"$hasPrefix": "Prod", "$ignoreCase": false}}`, `{"a": "product"}`, false, false},
+		{`{"a": {"$ignoreCase": true}}`, `{}`, false, true},
+		{`{"a": {"$text": "a", "$ignoreSpace": "yes"}}`, `{}`, false, true},
+		{`{"a": {"$len": 3}}`, `{"a": [1, 2, 3]}`, true, false},
+		{`{"a": {"$len": 3}}`, `{"a": [1, 2]}`, false, false},
+		{`{"a": {"$minLen": 1, "$maxLen": 4}}`, `{"a": "测试"}`, true, false},
+		{`{"a": {"$maxLen": 4}}`, `{"a": "tests"}`, false, false},
+		{`{"a": {"$minLen": 2}}`, `{"a": {"b": 1, "c": 2}}`, true, false},
+		{`{"a": {"$len": 0}}`, `{"a": 1}`, false, false},
+		{`{"a": {"$len": 1.5}}`, `{}`, false, true},
+		{`{"a": {"$minLen": -1}}`, `{}`, false, true},
+		{`{"a": {"$type": "string"}}`, `{"a": "1"}`, true, false},
+		{`{"a": {"$type": "string"}}`, `{"a": 1}`, false, false},
+		{`{"a": {"$type": "integer"}}`, `{"a": 2}`, true, false},
+		{`{"a": {"$type": "integer"}}`, `{"a": 2.5}`, false, false},
+		{`{"a": {"$type": "number"}}`, `{"a": 2}`, true, false},
+		{`{"a": {"$type": ["object", "null"]}}`, `{"a": null}`, true, false},
+		{`{"a": {"$type": "array"}}`, `{"a": {}}`, false, false},
+		{`{"a": {"$type": "boolean"}}`, `{"a": false}`, true, false},
+		{`{"a": {"$type": "date"}}`, `{}`, false, true},
+		{`{"a": {"$type": []}}`, `{}`, false, true},
+		{`{"a": {"$regexp": "("}}`, `{}`, false, true},
+		{`{"a": {"$regexp": 1}}`, `{}`, false, true},
+		{`{"a": {"$regexp": "x", "b": 1}}`, `{}`, false, true},
+	}
+	for _, c := range cases {
+		m, err := Parse(c.matcher)
+		if c.hasError {
+			assert.Error(t, err, c.matcher)
+			continue
+		}
+		if !assert.NoError(t, err, c.matcher) {
+			continue
+		}
+		actual := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(c.actual), &actual))
+		matched, err := m.Match(actual)
+		assert.NoError(t, err, c.matcher)
+		assert.Equal(t, c.matched, matched, "matcher: %v, actual: %v", c.matcher, c.actual)
+	}
+}
+
+func TestCustomMatcher(t *testing.T) {
+	assert.NoError(t, Register("even", func(actual interface{}) error {
+		n, ok := actual.(float64)
+		if !ok || int(n)%2 != 0 {
+			return fmt.Errorf("%v is not an even number", actual)
+		}
+		return nil
+	}))
+	assert.EqualError(t, Register("even", func(actual interface{}) error {
+		return nil
+	}), "matcher even has been registered")
+	assert.EqualError(t, Register("odd", nil), "matcher odd should not be nil")
+	assert.EqualError(t, Register("", func(actual interface{}) error {
+		return nil
+	}), "name of matcher should not be empty")
+
+	m, err := Parse(`{"a": {"$matcher": "even"}}`)
+	assert.NoError(t, err)
+	matched, err := m.Match(map[string]interface{}{"a": float64(2)})
+	assert.NoError(t, err)
+	assert.True(t, matched)
+	matched, err = m.Match(map[string]interface{}{"a": float64(3)})
+	assert.NoError(t, err)
+	assert.False(t, matched)
+	assert.Contains(t, m.FailureMessage(map[string]interface{}{"a": float64(3)}), "3 is not an even number")
+
+	_, err = Parse(`{"a": {"$matcher": "unknown"}}`)
+	assert.Error(t, err)
+}
+
+func TestParseSubset(t *testing.T) {
+	cases := []struct {
+		matcher string
+		actual  string
+		matched bool
+	}{
+		{`{"a": [1, 2]}`, `{"a": [1, 2, 3]}`, true},
+		{`{"a": [1, 3]}`, `{"a": [1, 2, 3]}`, true},
+		{`{"a": [3, 1]}`, `{"a": [1, 2, 3]}`, false},
+		{`{"a": [{"id": 2}]}`, `{"a": [{"id": 1, "x": 1}, {"id": 2, "x": 2}]}`, true},
+		{`{"a": [{"b": [1]}]}`, `{"a": [{"b": [0, 1]}]}`, true},
+		{`{"a": [1, 1]}`, `{"a": [1]}`, false},
+		{`{"a": {"$unordered": [3, 1]}}`, `{"a": [1, 2, 3]}`, true},
+	}
+	for _, c := range cases {
+		m, err := ParseWithOptions(c.matcher, Options{Subset: true})
+		if !assert.NoError(t, err, c.matcher) {
+			continue
+		}
+		actual := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(c.actual), &actual))
+		matched, err := m.Match(actual)
+		assert.NoError(t, err, c.matcher)
+		assert.Equal(t, c.matched, matched, "matcher: %v, actual: %v", c.matcher, c.actual)
+	}
+}
+
+func TestTimeMatcher(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		matcher  string
+		actual   interface{}
+		matched  bool
+		hasError bool
+	}{
+		{`{"$time": "RFC3339"}`, "2020-01-02T03:04:05Z", true, false},
+		{`{"$time": "RFC3339"}`, "2020-01-02 03:04:05", false, false},
+		{`{"$time": "2006-01-02"}`, "2020-01-02", true, false},
+		{`{"$time": "RFC3339", "$within": "1m"}`, now.Add(-30 * time.Second).Format(time.RFC3339), true, false},
+		{`{"$time": "RFC3339", "$within": "1m"}`, now.Add(2 * time.Minute).Format(time.RFC3339), false, false},
+		{`{"$time": "Unix", "$within": "10s"}`, float64(now.Unix()), true, false},
+		{`{"$time": "UnixMilli", "$after": 1577934245000}`, float64(now.UnixNano() / int64(time.Millisecond)), true, false},
+		{`{"$time": "Unix"}`, "1577934245", true, false},
+		{`{"$time": "RFC3339", "$after": "2020-01-01T00:00:00Z", "$before": "2020-02-01T00:00:00Z"}`, "2020-01-02T03:04:05Z", true, false},
+		{`{"$time": "RFC3339", "$before": "2020-01-01T00:00:00Z"}`, "2020-01-02T03:04:05Z", false, false},
+		{`{"$time": "RFC3339", "$within": "1x"}`, nil, false, true},
+		{`{"$time": "RFC3339", "$after": "yesterday"}`, nil, false, true},
+		{`{"$within": "1m"}`, nil, false, true},
+	}
+	for _, c := range cases {
+		m, err := ParseValue(c.matcher)
+		if c.hasError {
+			assert.Error(t, err, c.matcher)
+			continue
+		}
+		if !assert.NoError(t, err, c.matcher) {
+			continue
+		}
+		matched, err := m.Match(c.actual)
+		assert.NoError(t, err, c.matcher)
+		assert.Equal(t, c.matched, matched, "matcher: %v, actual: %v, message: %v", c.matcher, c.actual, m.FailureMessage(c.actual))
+	}
+}
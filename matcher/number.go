@@ -0,0 +1,147 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// operators of numeric comparison matchers
+var operators = map[string]string{
+	GreaterThanMatcher:        ">",
+	GreaterThanOrEqualMatcher: ">=",
+	LessThanMatcher:           "<",
+	LessThanOrEqualMatcher:    "<=",
+}
+
+func generateCompareMatcher(key string, expr interface{}) (types.GomegaMatcher, error) {
+	bound, ok := toFloat(expr)
+	if !ok {
+		return nil, fmt.Errorf("value of %v MUST be a number, actual: %T", key, expr)
+	}
+	return &compareMatcher{
+		operator: operators[key],
+		bound:    bound,
+	}, nil
+}
+
+// compareMatcher compares actual number with bound
+type compareMatcher struct {
+	operator string
+	bound    float64
+}
+
+// Match implements types.GomegaMatcher
+// Value which is not a number is a failure
+func (m *compareMatcher) Match(actual interface{}) (bool, error) {
+	n, ok := toFloat(actual)
+	if !ok {
+		return false, nil
+	}
+	switch m.operator {
+	case ">":
+		return n > m.bound, nil
+	case ">=":
+		return n >= m.bound, nil
+	case "<":
+		return n < m.bound, nil
+	case "<=":
+		return n <= m.bound, nil
+	}
+	return false, fmt.Errorf("unknown operator %v", m.operator)
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *compareMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to be a number %v %v", m.operator, m.bound))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *compareMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to be a number %v %v", m.operator, m.bound))
+}
+
+// generateApproxMatcher generates matcher from $approx and its tolerances
+// Number is exactly matched if no tolerance is set
+func generateApproxMatcher(matcher map[string]interface{}) (types.GomegaMatcher, error) {
+	m := &approxMatcher{}
+	expected, ok := toFloat(matcher[ApproxMatcher])
+	if !ok {
+		return nil, fmt.Errorf("value of %v MUST be a number, actual: %T", ApproxMatcher, matcher[ApproxMatcher])
+	}
+	m.expected = expected
+	for _, k := range []string{ToleranceMatcher, RelativeToleranceMatcher} {
+		expr, ok := matcher[k]
+		if !ok {
+			continue
+		}
+		t, ok := toFloat(expr)
+		if !ok || t < 0 {
+			return nil, fmt.Errorf("value of %v MUST be a non-negative number, actual: %v", k, expr)
+		}
+		if k == ToleranceMatcher {
+			m.tolerance = t
+		} else {
+			m.relTolerance = t
+		}
+	}
+	return m, nil
+}
+
+// approxMatcher matches number within absolute or relative tolerance
+type approxMatcher struct {
+	expected     float64
+	tolerance    float64
+	relTolerance float64
+}
+
+// Match implements types.GomegaMatcher
+func (m *approxMatcher) Match(actual interface{}) (bool, error) {
+	n, ok := toFloat(actual)
+	if !ok {
+		return false, nil
+	}
+	diff := math.Abs(n - m.expected)
+	return diff <= m.tolerance || diff <= m.relTolerance*math.Abs(m.expected), nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *approxMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to be approximately %v %v", m.expected, m.describe()))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *approxMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to be approximately %v %v", m.expected, m.describe()))
+}
+
+func (m *approxMatcher) describe() string {
+	return fmt.Sprintf("(tolerance: %v, relative tolerance: %v)", m.tolerance, m.relTolerance)
+}
+
+// toFloat converts json number to float64
+// Numbers are decoded as float64 by default, but they may also
+// be json.Number or other kinds of numbers
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,49 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// MatchRegexp succeeds if actual is a string which matches the regexp
+// Unlike gomega.MatchRegexp, regexp is compiled when matcher is created
+// and non-string value is a failure instead of an error
+func MatchRegexp(pattern string) (types.GomegaMatcher, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $regexp %q: %v", pattern, err)
+	}
+	return &regexpMatcher{
+		re: re,
+	}, nil
+}
+
+// regexpMatcher matches string by regexp
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+// Match implements types.GomegaMatcher
+func (m *regexpMatcher) Match(actual interface{}) (bool, error) {
+	s, ok := actual.(string)
+	if !ok {
+		return false, nil
+	}
+	return m.re.MatchString(s), nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *regexpMatcher) FailureMessage(actual interface{}) string {
+	if _, ok := actual.(string); !ok {
+		return format.Message(actual, fmt.Sprintf("to be a string matching regexp %q", m.re.String()))
+	}
+	return format.Message(actual, fmt.Sprintf("to match regexp %q", m.re.String()))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *regexpMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to match regexp %q", m.re.String()))
+}
@@ -24,6 +24,28 @@ func MatchSlice(elements Elements) types.GomegaMatcher {
 	return m
 }
 
+// MatchSubSlice succeeds if every element matcher matches an element of a slice
+// in order. Extra elements of the slice are ignored.
+func MatchSubSlice(elements Elements) types.GomegaMatcher {
+	m := &Matcher{
+		Elements:    elements,
+		IgnoreExtra: true,
+	}
+	return m
+}
+
+// MatchUnorderedSlice succeeds if every element matcher matches a different
+// element of a slice regardless of position. Extra elements of the slice
+// are ignored if ignoreExtra is true.
+func MatchUnorderedSlice(elements Elements, ignoreExtra bool) types.GomegaMatcher {
+	m := &Matcher{
+		Elements:    elements,
+		IgnoreExtra: ignoreExtra,
+		Unordered:   true,
+	}
+	return m
+}
+
 //MatchElements succeeds if each element of a slice matches the element matcher it maps to
 //through the id function. It can ignore extra elements and/or missing elements.
 //  Expect([]string{"a", "c"}).To(MatchElements(idFn, IgnoreMissing|IgnoreExtra, matchers.Elements{
@@ -38,6 +60,13 @@ type Matcher struct {
 	// Matchers for each element.
 	Elements Elements
 
+	// IgnoreExtra ignores elements which are not matched
+	// by any element matcher
+	IgnoreExtra bool
+
+	// Unordered matches elements regardless of position
+	Unordered bool
+
 	// State.
 	failures []error
 }
@@ -68,6 +97,16 @@ func (m *Matcher) matchElements(actual interface{}) (errs []error) {
 
 	val := reflect.ValueOf(actual)
 	length := val.Len()
+	if m.Unordered {
+		if !m.IgnoreExtra && len(m.Elements) != length {
+			errs = append(errs, fmt.Errorf("unexpected slice length, expected: %v, actual: %v", len(m.Elements), length))
+			return errs
+		}
+		return m.matchUnorderedElements(val)
+	}
+	if m.IgnoreExtra {
+		return m.matchSubElements(val)
+	}
 	if len(m.Elements) != length {
 		errs = append(errs, fmt.Errorf("unexpected slice length, expected: %v, actual: %v", len(m.Elements), length))
 		return errs
@@ -95,6 +134,67 @@ func (m *Matcher) matchElements(actual interface{}) (errs []error) {
 	return errs
 }
 
+// matchSubElements matches element matchers to elements in order
+// Each element matcher matches the first matched element after
+// the element matched by previous one
+func (m *Matcher) matchSubElements(val reflect.Value) (errs []error) {
+	next := 0
+	for i, matcher := range m.Elements {
+		found := false
+		for ; next < val.Len() && !found; next++ {
+			match, err := matcher.Match(val.Index(next).Interface())
+			found = err == nil && match
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("expected element %v is not matched by any element in order", i))
+			return errs
+		}
+	}
+	return errs
+}
+
+// matchUnorderedElements finds a maximum matching between element
+// matchers and elements, so an element is never matched twice
+func (m *Matcher) matchUnorderedElements(val reflect.Value) (errs []error) {
+	length := val.Len()
+	// candidates[i] are indexes of elements matched by element matcher i
+	candidates := make([][]int, len(m.Elements))
+	for i, matcher := range m.Elements {
+		for j := 0; j < length; j++ {
+			match, err := matcher.Match(val.Index(j).Interface())
+			if err == nil && match {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	// owner[j] is index of element matcher which matches element j
+	owner := make([]int, length)
+	for j := range owner {
+		owner[j] = -1
+	}
+	var assign func(i int, visited []bool) bool
+	assign = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owner[j] == -1 || assign(owner[j], visited) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range m.Elements {
+		if !assign(i, make([]bool, length)) {
+			errs = append(errs, fmt.Errorf("expected element %v is not matched by any element", i))
+		}
+	}
+	return errs
+}
+
 // FailureMessage implements types.GomegaMatcher
 func (m *Matcher) FailureMessage(actual interface{}) (message string) {
 	failure := errorsutil.AggregateError(m.failures)
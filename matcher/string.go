@@ -0,0 +1,119 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// string matchers and their descriptions
+var stringMatchers = map[string]struct {
+	match       func(s, fragment string) bool
+	description string
+}{
+	ContainsMatcher:  {strings.Contains, "contain"},
+	HasPrefixMatcher: {strings.HasPrefix, "have prefix"},
+	HasSuffixMatcher: {strings.HasSuffix, "have suffix"},
+	TextMatcher: {func(s, fragment string) bool {
+		return s == fragment
+	}, "equal"},
+}
+
+// hasStringMatcher returns true if any key of matcher is a string matcher
+func hasStringMatcher(matcher map[string]interface{}) bool {
+	for k := range matcher {
+		if _, ok := stringMatchers[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// generateStringMatcher generates string matcher of key and its options
+func generateStringMatcher(key string, matcher map[string]interface{}) (types.GomegaMatcher, error) {
+	expr := matcher[key]
+	fragment, ok := expr.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of %v MUST be a string, actual: %T", key, expr)
+	}
+	sm := stringMatchers[key]
+	m := &stringMatcher{
+		match:       sm.match,
+		description: sm.description,
+	}
+	for _, k := range []string{IgnoreCaseMatcher, IgnoreSpaceMatcher} {
+		expr, ok := matcher[k]
+		if !ok {
+			continue
+		}
+		b, ok := expr.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value of %v MUST be a bool, actual: %T", k, expr)
+		}
+		if k == IgnoreCaseMatcher {
+			m.ignoreCase = b
+		} else {
+			m.ignoreSpace = b
+		}
+	}
+	m.fragment = m.normalize(fragment)
+	return m, nil
+}
+
+// stringMatcher matches string by a fragment
+type stringMatcher struct {
+	fragment    string
+	match       func(s, fragment string) bool
+	description string
+
+	ignoreCase  bool
+	ignoreSpace bool
+}
+
+// normalize returns string which is compared by options
+func (m *stringMatcher) normalize(s string) string {
+	if m.ignoreSpace {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if m.ignoreCase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// options returns description of options
+func (m *stringMatcher) options() string {
+	opts := []string{}
+	if m.ignoreCase {
+		opts = append(opts, "ignoring case")
+	}
+	if m.ignoreSpace {
+		opts = append(opts, "ignoring spaces")
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(opts, " and ")
+}
+
+// Match implements types.GomegaMatcher
+// Value which is not a string is a failure
+func (m *stringMatcher) Match(actual interface{}) (bool, error) {
+	s, ok := actual.(string)
+	if !ok {
+		return false, nil
+	}
+	return m.match(m.normalize(s), m.fragment), nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *stringMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to be a string and %v %q%v", m.description, m.fragment, m.options()))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *stringMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to %v %q%v", m.description, m.fragment, m.options()))
+}
@@ -0,0 +1,148 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// timeLayouts are names of layouts which can be used in $time
+// Unix and UnixMilli are also supported
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+}
+
+// generateTimeMatcher generates matcher from $time and its options
+func generateTimeMatcher(matcher map[string]interface{}) (types.GomegaMatcher, error) {
+	layout, ok := matcher[TimeMatcher].(string)
+	if !ok {
+		return nil, fmt.Errorf("value of %v MUST be a layout, actual: %v", TimeMatcher, matcher[TimeMatcher])
+	}
+	m := &timeMatcher{layout: layout}
+	if expr, ok := matcher[WithinMatcher]; ok {
+		s, ok := expr.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %v MUST be a duration, actual: %v", WithinMatcher, expr)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("value of %v MUST be a non-negative duration, actual: %v", WithinMatcher, expr)
+		}
+		m.within = &d
+	}
+	for _, k := range []string{AfterMatcher, BeforeMatcher} {
+		expr, ok := matcher[k]
+		if !ok {
+			continue
+		}
+		t, err := parseTime(layout, expr)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse value of %v: %v", k, err)
+		}
+		if k == AfterMatcher {
+			m.after = &t
+		} else {
+			m.before = &t
+		}
+	}
+	return m, nil
+}
+
+// parseTime parses time in layout from a string or a number
+func parseTime(layout string, v interface{}) (time.Time, error) {
+	switch layout {
+	case "Unix", "UnixMilli":
+		n, ok := toFloat(v)
+		if s, isString := v.(string); isString {
+			f, err := strconv.ParseFloat(s, 64)
+			n, ok = f, err == nil
+		}
+		if !ok {
+			return time.Time{}, fmt.Errorf("%v is not a %v timestamp", v, layout)
+		}
+		if layout == "UnixMilli" {
+			n /= 1000
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%v is not a string", v)
+	}
+	if l, ok := timeLayouts[layout]; ok {
+		layout = l
+	}
+	return time.Parse(layout, s)
+}
+
+// timeMatcher matches time in layout which is optionally
+// within a duration of now, or after or before a time
+type timeMatcher struct {
+	layout string
+	within *time.Duration
+	after  *time.Time
+	before *time.Time
+
+	// reason is reason of the last failure
+	reason string
+}
+
+// Match implements types.GomegaMatcher
+func (m *timeMatcher) Match(actual interface{}) (bool, error) {
+	t, err := parseTime(m.layout, actual)
+	if err != nil {
+		m.reason = fmt.Sprintf("can't be parsed: %v", err)
+		return false, nil
+	}
+	if m.within != nil {
+		now := time.Now()
+		if diff := t.Sub(now); diff > *m.within || diff < -*m.within {
+			m.reason = fmt.Sprintf("is %v from now", diff.Round(time.Millisecond))
+			return false, nil
+		}
+	}
+	if m.after != nil && !t.After(*m.after) {
+		m.reason = fmt.Sprintf("is not after %v", m.after.Format(time.RFC3339Nano))
+		return false, nil
+	}
+	if m.before != nil && !t.Before(*m.before) {
+		m.reason = fmt.Sprintf("is not before %v", m.before.Format(time.RFC3339Nano))
+		return false, nil
+	}
+	m.reason = ""
+	return true, nil
+}
+
+// describe returns description of conditions
+func (m *timeMatcher) describe() string {
+	conds := []string{"a time in layout " + m.layout}
+	if m.within != nil {
+		conds = append(conds, fmt.Sprintf("within %v of now", *m.within))
+	}
+	if m.after != nil {
+		conds = append(conds, "after "+m.after.Format(time.RFC3339Nano))
+	}
+	if m.before != nil {
+		conds = append(conds, "before "+m.before.Format(time.RFC3339Nano))
+	}
+	return strings.Join(conds, ", ")
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *timeMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to be %v, but it %v", m.describe(), m.reason))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *timeMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to be %v", m.describe()))
+}
@@ -0,0 +1,106 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// jsonTypes are valid types of $type
+// integer is a number without fraction
+var jsonTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"null":    true,
+}
+
+// generateTypeMatcher generates matcher from a type or a list of types
+func generateTypeMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	names := []string{}
+	switch e := expr.(type) {
+	case string:
+		names = append(names, e)
+	case []interface{}:
+		for _, v := range e {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("value of %v MUST be a type or a list of types, actual: %v", TypeMatcher, expr)
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, fmt.Errorf("value of %v MUST be a type or a list of types, actual: %v", TypeMatcher, expr)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("value of %v should not be empty", TypeMatcher)
+	}
+	for _, name := range names {
+		if !jsonTypes[name] {
+			return nil, fmt.Errorf("unknown type %v of %v", name, TypeMatcher)
+		}
+	}
+	return &typeMatcher{types: names}, nil
+}
+
+// typeMatcher matches json type of value
+type typeMatcher struct {
+	types []string
+}
+
+// typeOf returns json type of decoded json value
+func typeOf(actual interface{}) string {
+	switch v := actual.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	if f, ok := toFloat(actual); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", actual)
+}
+
+// Match implements types.GomegaMatcher
+// Integer is also a number
+func (m *typeMatcher) Match(actual interface{}) (bool, error) {
+	actualType := typeOf(actual)
+	for _, t := range m.types {
+		if t == actualType || (t == "number" && actualType == "integer") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *typeMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to be type %v, actual type: %v", strings.Join(m.types, " or "), typeOf(actual)))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *typeMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to be type %v", strings.Join(m.types, " or ")))
+}
@@ -0,0 +1,109 @@
+package framework
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caicloud/aloe/data"
+)
+
+// scope defines states inherited from parent contexts
+type scope struct {
+	tags []string
+
+	// focused means one of parent contexts is focused
+	focused bool
+
+	// once means context can be constructed once for all cases
+	once bool
+
+	// depth is number of parent contexts
+	depth int
+
+	// cleaners are names of cleaners run by parent contexts after each case
+	cleaners map[string]bool
+}
+
+// child returns scope of sub context
+func (s scope) child(c *data.Dir) scope {
+	return scope{
+		tags:     append(append([]string{}, s.tags...), c.Context.Tags...),
+		focused:  s.focused || c.Context.Focus,
+		once:     s.once && c.Context.Once,
+		depth:    s.depth + 1,
+		cleaners: s.cleaners,
+	}
+}
+
+// hasFocus returns whether any case or context is focused
+func hasFocus(dir *data.Dir) bool {
+	if dir.Context.Skip {
+		return false
+	}
+	if dir.Context.Focus {
+		return true
+	}
+	for _, f := range dir.Files {
+		if f.Case.Focus && !f.Case.Skip {
+			return true
+		}
+	}
+	for _, d := range dir.Dirs {
+		if hasFocus(&d) {
+			return true
+		}
+	}
+	return false
+}
+
+// onceContext is a context which is constructed once for its cases
+type onceContext struct {
+	path string
+
+	// teardown cleans resources of context after its cases
+	teardown func() error
+}
+
+// onceStack is the chain of once contexts of running case, the
+// context at index i is at depth i. Contexts are torn down when a
+// case out of them is run or suite is finished instead of counting
+// their cases, because cases can be filtered, shuffled and sharded
+// by ginkgo
+type onceStack []*onceContext
+
+// enter makes c the once context at depth and returns whether it should
+// be constructed, contexts at and below depth are torn down before
+func (s *onceStack) enter(depth int, c *onceContext) (bool, error) {
+	if len(*s) > depth && (*s)[depth] == c {
+		return false, nil
+	}
+	err := s.release(depth)
+	*s = append(*s, c)
+	return true, err
+}
+
+// release tears down contexts at and below depth from the innermost one
+// All of them are torn down even if some of them are failed
+func (s *onceStack) release(depth int) error {
+	errs := []string{}
+	for len(*s) > depth {
+		c := (*s)[len(*s)-1]
+		*s = (*s)[:len(*s)-1]
+		if err := c.teardown(); err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", c.path, err))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("can't tear down once contexts: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// releaseInner returns body of case in context at depth, once contexts
+// below the depth are left by the case and torn down before it
+func (gf *genericFramework) releaseInner(depth int, body func()) func() {
+	return func() {
+		gf.expectNoError(gf.onces.release(depth + 1))
+		body()
+	}
+}
@@ -0,0 +1,150 @@
+package framework
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testContext simulates a context registered by walk
+type testContext struct {
+	oc   *onceContext
+	once bool
+}
+
+// runCase simulates hooks of contexts in chain and records events
+func runCase(t *testing.T, s *onceStack, events *[]string, chain []*testContext, name string) {
+	for depth, c := range chain {
+		if !c.once {
+			assert.NoError(t, s.release(depth), "case: %v", name)
+			*events = append(*events, "construct "+c.oc.path)
+			continue
+		}
+		construct, err := s.enter(depth, c.oc)
+		assert.NoError(t, err, "case: %v", name)
+		if construct {
+			*events = append(*events, "construct "+c.oc.path)
+		}
+	}
+	assert.NoError(t, s.release(len(chain)), "case: %v", name)
+	*events = append(*events, "run "+name)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !chain[i].once {
+			*events = append(*events, "teardown "+chain[i].oc.path)
+		}
+	}
+}
+
+func TestOnceStack(t *testing.T) {
+	var events []string
+	newContext := func(path string, once bool) *testContext {
+		oc := &onceContext{path: path}
+		oc.teardown = func() error {
+			events = append(events, "teardown "+path)
+			return nil
+		}
+		return &testContext{oc: oc, once: once}
+	}
+	a := newContext("a", true)
+	ab := newContext("a/b", true)
+	ac := newContext("a/c", false)
+	d := newContext("d", false)
+	chains := map[string][]*testContext{
+		"a/1":   {a},
+		"a/b/1": {a, ab},
+		"a/b/2": {a, ab},
+		"a/c/1": {a, ac},
+		"d/1":   {d},
+	}
+
+	cases := []struct {
+		description string
+		// cases are run in order
+		cases    []string
+		expected []string
+	}{
+		{
+			description: "all cases in order",
+			cases:       []string{"a/1", "a/b/1", "a/b/2", "a/c/1", "d/1"},
+			expected: []string{
+				"construct a", "run a/1",
+				"construct a/b", "run a/b/1", "run a/b/2",
+				"teardown a/b", "construct a/c", "run a/c/1", "teardown a/c",
+				"teardown a", "construct d", "run d/1", "teardown d",
+			},
+		},
+		{
+			description: "only focused cases",
+			cases:       []string{"a/b/2"},
+			expected: []string{
+				"construct a", "construct a/b", "run a/b/2",
+				"teardown a/b", "teardown a",
+			},
+		},
+		{
+			description: "last case of context is skipped",
+			cases:       []string{"a/b/1", "d/1"},
+			expected: []string{
+				"construct a", "construct a/b", "run a/b/1",
+				"teardown a/b", "teardown a", "construct d", "run d/1", "teardown d",
+			},
+		},
+		{
+			description: "random order of all specs",
+			cases:       []string{"a/b/1", "d/1", "a/1", "a/b/2"},
+			expected: []string{
+				"construct a", "construct a/b", "run a/b/1",
+				"teardown a/b", "teardown a", "construct d", "run d/1", "teardown d",
+				"construct a", "run a/1",
+				"construct a/b", "run a/b/2",
+				"teardown a/b", "teardown a",
+			},
+		},
+		{
+			description: "case of outer context between cases of inner context",
+			cases:       []string{"a/b/1", "a/1", "a/b/2"},
+			expected: []string{
+				"construct a", "construct a/b", "run a/b/1",
+				"teardown a/b", "run a/1",
+				"construct a/b", "run a/b/2",
+				"teardown a/b", "teardown a",
+			},
+		},
+	}
+	for _, c := range cases {
+		events = nil
+		s := &onceStack{}
+		for _, name := range c.cases {
+			runCase(t, s, &events, chains[name], name)
+		}
+		// remaining contexts are torn down after suite
+		assert.NoError(t, s.release(0), "description: %v", c.description)
+		assert.Equal(t, c.expected, events, "description: %v", c.description)
+		assert.Empty(t, *s, "description: %v", c.description)
+	}
+}
+
+func TestOnceStackErrors(t *testing.T) {
+	s := &onceStack{}
+	torn := []string{}
+	for _, path := range []string{"a", "a/b", "a/b/c"} {
+		path := path
+		_, err := s.enter(len(*s), &onceContext{path: path, teardown: func() error {
+			torn = append(torn, path)
+			if path == "a" {
+				return nil
+			}
+			return assert.AnError
+		}})
+		assert.NoError(t, err)
+	}
+	err := s.release(0)
+	// all contexts are torn down even if some of them are failed
+	assert.Equal(t, []string{"a/b/c", "a/b", "a"}, torn)
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "a/b/c: "), err.Error())
+		assert.True(t, strings.Contains(err.Error(), "a/b: "), err.Error())
+	}
+	assert.Empty(t, *s)
+}
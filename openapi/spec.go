@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// methods are http methods which can be operations of path item
+var methods = map[string]bool{
+	"get":     true,
+	"put":     true,
+	"post":    true,
+	"delete":  true,
+	"options": true,
+	"head":    true,
+	"patch":   true,
+	"trace":   true,
+}
+
+// Operation defines an operation documented in spec
+type Operation struct {
+	// Method is upper case http method, e.g. GET
+	Method string
+	// Path is path pattern of operation, e.g. /users/{id}
+	Path string
+
+	segments []string
+	// pointer is json pointer of operation in spec
+	pointer string
+}
+
+// Spec defines operations of an OpenAPI 3 or swagger 2 spec
+type Spec struct {
+	// Operations are sorted by path and method
+	Operations []*Operation
+
+	// prefix is base path of all operations
+	prefix string
+
+	// doc is spec in json which is used to resolve schemas
+	doc map[string]interface{}
+
+	lock    sync.Mutex
+	schemas map[string]*gojsonschema.Schema
+}
+
+type document struct {
+	Swagger  string                                `json:"swagger"`
+	OpenAPI  string                                `json:"openapi"`
+	BasePath string                                `json:"basePath"`
+	Servers  []server                              `json:"servers"`
+	Paths    map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+type server struct {
+	URL string `json:"url"`
+}
+
+// Load reads spec from a json or yaml file
+func Load(filename string) (*Spec, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("can't read spec %v: %v", filename, err)
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse spec %v: %v", filename, err)
+	}
+	return spec, nil
+}
+
+// Parse parses spec from json or yaml
+func Parse(data []byte) (*Spec, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := document{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Swagger == "" && doc.OpenAPI == "" {
+		return nil, fmt.Errorf("neither openapi nor swagger version is defined")
+	}
+	spec := &Spec{
+		prefix:  doc.BasePath,
+		schemas: map[string]*gojsonschema.Schema{},
+	}
+	if err := json.Unmarshal(data, &spec.doc); err != nil {
+		return nil, err
+	}
+	// path of the first server is used as base path of OpenAPI 3
+	if len(doc.Servers) > 0 {
+		u, err := url.Parse(doc.Servers[0].URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url of server %v: %v", doc.Servers[0].URL, err)
+		}
+		spec.prefix = u.Path
+	}
+	spec.prefix = strings.TrimSuffix(spec.prefix, "/")
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !methods[strings.ToLower(method)] {
+				continue
+			}
+			spec.Operations = append(spec.Operations, &Operation{
+				Method:   strings.ToUpper(method),
+				Path:     path,
+				segments: split(path),
+				pointer:  "#/paths/" + escape(path) + "/" + method,
+			})
+		}
+	}
+	sort.Slice(spec.Operations, func(i, j int) bool {
+		a, b := spec.Operations[i], spec.Operations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return spec, nil
+}
+
+// Find returns operation of method and path of a request
+// Literal segments are preferred to parameters if more than one
+// operations are matched, nil is returned if no operation is matched
+func (s *Spec) Find(method, path string) *Operation {
+	if !strings.HasPrefix(path, s.prefix) {
+		return nil
+	}
+	rest := path[len(s.prefix):]
+	if rest != "" && rest[0] != '/' {
+		return nil
+	}
+	segments := split(rest)
+	var found *Operation
+	literals := -1
+	for _, op := range s.Operations {
+		if op.Method != strings.ToUpper(method) {
+			continue
+		}
+		n, ok := op.match(segments)
+		if ok && n > literals {
+			found, literals = op, n
+		}
+	}
+	return found
+}
+
+// match returns number of literal segments if path is matched
+func (op *Operation) match(segments []string) (int, bool) {
+	if len(segments) != len(op.segments) {
+		return 0, false
+	}
+	literals := 0
+	for i, seg := range op.segments {
+		if isParameter(seg) {
+			if segments[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if seg != segments[i] {
+			return 0, false
+		}
+		literals++
+	}
+	return literals, true
+}
+
+func isParameter(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
+
+func split(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
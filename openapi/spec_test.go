@@ -0,0 +1,111 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFind(t *testing.T) {
+	cases := []struct {
+		spec   string
+		method string
+		path   string
+		found  string
+	}{
+		{`
+openapi: 3.0.0
+paths:
+  /users:
+    get: {}
+    post: {}
+  /users/{id}:
+    get: {}
+    parameters: []
+  /users/me:
+    get: {}
+`, "GET", "/users/1", "GET /users/{id}"},
+		{`
+openapi: 3.0.0
+paths:
+  /users/{id}:
+    get: {}
+  /users/me:
+    get: {}
+`, "get", "/users/me", "GET /users/me"},
+		{`
+openapi: 3.0.0
+paths:
+  /users:
+    get: {}
+`, "DELETE", "/users", ""},
+		{`
+openapi: 3.0.0
+paths:
+  /users/{id}:
+    get: {}
+`, "GET", "/users/1/roles", ""},
+		{`
+openapi: 3.0.0
+servers:
+- url: http://localhost/api/v1/
+paths:
+  /users:
+    get: {}
+`, "GET", "/api/v1/users", "GET /users"},
+		{`
+swagger: "2.0"
+basePath: /api
+paths:
+  /:
+    get: {}
+`, "GET", "/api", "GET /"},
+		{`
+swagger: "2.0"
+basePath: /api
+paths:
+  /users:
+    get: {}
+`, "GET", "/users", ""},
+		{`
+swagger: "2.0"
+basePath: /api
+paths:
+  /v2/users:
+    get: {}
+`, "GET", "/apiv2/users", ""},
+	}
+	for _, c := range cases {
+		spec, err := Parse([]byte(c.spec))
+		assert.NoError(t, err)
+		op := spec.Find(c.method, c.path)
+		if c.found == "" {
+			assert.Nil(t, op, "%v %v", c.method, c.path)
+			continue
+		}
+		if assert.NotNil(t, op, "%v %v", c.method, c.path) {
+			assert.Equal(t, c.found, op.Method+" "+op.Path)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		spec       string
+		operations int
+		hasError   bool
+	}{
+		{`{"openapi": "3.0.0", "paths": {"/a": {"get": {}, "put": {}}, "/b": {"summary": "b", "delete": {}}}}`, 3, false},
+		{`paths: {}`, 0, true},
+		{`openapi: [`, 0, true},
+	}
+	for _, c := range cases {
+		spec, err := Parse([]byte(c.spec))
+		if c.hasError {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Len(t, spec.Operations, c.operations)
+	}
+}
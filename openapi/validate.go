@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Validate validates body of response by schema of the operation which is
+// found by method and path of request, and declared for status code
+// Failures cite json pointer of schema in spec and invalid field in body
+// Nothing is validated if operation or schema of response is not declared
+func (s *Spec) Validate(method, path string, status int, contentType string, body []byte) []error {
+	op := s.Find(method, path)
+	if op == nil {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	location, ok := s.responseSchema(op, status, mediaType)
+	if !ok {
+		return nil
+	}
+	// body which is neither json nor decoded to json, e.g. text/plain,
+	// can't be validated by json schema
+	if !json.Valid(body) && !isJSON(mediaType) {
+		return nil
+	}
+	schema, err := s.schema(location)
+	if err != nil {
+		return []error{fmt.Errorf("can't load openapi schema %v: %v", location, err)}
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+	if err != nil {
+		return []error{fmt.Errorf("can't validate body by openapi schema %v: %v", location, err)}
+	}
+	errs := []error{}
+	for _, re := range result.Errors() {
+		pointer := strings.TrimPrefix(re.Context().String("/"), "(root)")
+		if pointer == "" {
+			pointer = "/"
+		}
+		errs = append(errs, fmt.Errorf("openapi schema %v is not matched at %v: %v", location, pointer, re.Description()))
+	}
+	return errs
+}
+
+// responseSchema returns json pointer of response schema of operation
+// Response of status code is preferred to range, e.g. 2XX, and default
+func (s *Spec) responseSchema(op *Operation, status int, mediaType string) (string, bool) {
+	code := strconv.Itoa(status)
+	var location string
+	var resp map[string]interface{}
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		location = op.pointer + "/responses/" + escape(key)
+		if resp, _ = s.lookup(location).(map[string]interface{}); resp != nil {
+			break
+		}
+	}
+	if resp == nil {
+		return "", false
+	}
+	// response may be defined in components or responses of swagger
+	if ref, ok := resp["$ref"].(string); ok {
+		location = ref
+		if resp, _ = s.lookup(location).(map[string]interface{}); resp == nil {
+			return "", false
+		}
+	}
+	// schema of swagger 2
+	if _, ok := resp["schema"]; ok {
+		return location + "/schema", true
+	}
+	// schema of OpenAPI 3 is defined by media type
+	content, _ := resp["content"].(map[string]interface{})
+	candidates := []string{mediaType}
+	if i := strings.Index(mediaType, "/"); i >= 0 {
+		candidates = append(candidates, mediaType[:i]+"/*")
+	}
+	candidates = append(candidates, "*/*")
+	for _, mt := range candidates {
+		media, _ := content[mt].(map[string]interface{})
+		if _, ok := media["schema"]; ok {
+			return location + "/content/" + escape(mt) + "/schema", true
+		}
+	}
+	return "", false
+}
+
+// schema returns compiled json schema at location of spec
+// References in schema are resolved in spec
+func (s *Spec) schema(location string) (*gojsonschema.Schema, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if schema, ok := s.schemas[location]; ok {
+		return schema, nil
+	}
+	// OpenAPI schemas are extended subsets of json schema
+	root := toJSONSchema(s.doc, false).(map[string]interface{})
+	root["$ref"] = location
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(root))
+	if err != nil {
+		return nil, err
+	}
+	s.schemas[location] = schema
+	return schema, nil
+}
+
+var (
+	// nameKeys are keys of objects whose keys are names instead
+	// of keywords, e.g. properties of schema
+	nameKeys = map[string]bool{
+		"properties":        true,
+		"patternProperties": true,
+		"definitions":       true,
+		"dependencies":      true,
+		"paths":             true,
+		"schemas":           true,
+		"responses":         true,
+		"parameters":        true,
+		"requestBodies":     true,
+		"headers":           true,
+		"content":           true,
+		"examples":          true,
+		"links":             true,
+		"callbacks":         true,
+		"securitySchemes":   true,
+	}
+	// openapiKeys are keywords of OpenAPI which aren't in json schema
+	openapiKeys = []string{"discriminator", "xml", "externalDocs", "example", "nullable", "x-nullable"}
+)
+
+// toJSONSchema returns a copy of v where OpenAPI schemas are converted
+// to json schemas, e.g. nullable is converted to a union with null type
+// Keys of v are names if names is true
+func toJSONSchema(v interface{}, names bool) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			list = append(list, toJSONSchema(item, false))
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = toJSONSchema(item, !names && nameKeys[k])
+		}
+		if names {
+			return m
+		}
+		// nullable of OpenAPI 3 and x-nullable of swagger 2
+		nullable := m["nullable"] == true || m["x-nullable"] == true
+		for _, k := range openapiKeys {
+			delete(m, k)
+		}
+		if !nullable {
+			return m
+		}
+		if enum, ok := m["enum"].([]interface{}); ok {
+			m["enum"] = append(enum, nil)
+		}
+		if t, ok := m["type"].(string); ok {
+			m["type"] = []interface{}{t, "null"}
+			return m
+		}
+		// schema without type, e.g. a reference, is nullable by a union
+		return map[string]interface{}{
+			"anyOf": []interface{}{m, map[string]interface{}{"type": "null"}},
+		}
+	}
+	return v
+}
+
+// lookup returns value at json pointer in spec, e.g. #/paths/~1users
+func (s *Spec) lookup(pointer string) interface{} {
+	if !strings.HasPrefix(pointer, "#/") {
+		return nil
+	}
+	var v interface{} = s.doc
+	for _, token := range strings.Split(pointer[2:], "/") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		token = strings.Replace(token, "~1", "/", -1)
+		v = m[strings.Replace(token, "~0", "~", -1)]
+	}
+	return v
+}
+
+// escape escapes token of json pointer
+func escape(token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	return strings.Replace(token, "/", "~1", -1)
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
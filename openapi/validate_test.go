@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validateSpec = `
+openapi: 3.0.0
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+            text/plain:
+              schema:
+                type: string
+        4XX:
+          $ref: "#/components/responses/Error"
+  /users:
+    get:
+      responses:
+        default:
+          content:
+            "*/*":
+              schema:
+                type: array
+components:
+  schemas:
+    User:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+  responses:
+    Error:
+      content:
+        application/json:
+          schema:
+            type: object
+            required: [message]
+`
+
+func TestValidate(t *testing.T) {
+	spec, err := Parse([]byte(validateSpec))
+	assert.NoError(t, err)
+	cases := []struct {
+		method      string
+		path        string
+		status      int
+		contentType string
+		body        string
+		errs        []string
+	}{
+		{"GET", "/users/1", 200, "application/json", `{"name": "a"}`, []string{}},
+		{"GET", "/users/1", 200, "application/json; charset=utf-8", `{"name": 1}`, []string{
+			"openapi schema #/paths/~1users~1{id}/get/responses/200/content/application~1json/schema is not matched at /name: Invalid type. Expected: string, given: integer",
+		}},
+		{"GET", "/users/1", 200, "text/plain", `hello`, nil},
+		{"GET", "/users/1", 404, "application/json", `{}`, []string{
+			"openapi schema #/components/responses/Error/content/application~1json/schema is not matched at /: message is required",
+		}},
+		{"GET", "/users/1", 500, "application/json", `{}`, nil},
+		{"GET", "/users", 200, "application/yaml", `{}`, []string{
+			"openapi schema #/paths/~1users/get/responses/default/content/*~1*/schema is not matched at /: Invalid type. Expected: array, given: object",
+		}},
+		{"POST", "/users", 200, "application/json", `{}`, nil},
+		{"GET", "/roles", 200, "application/json", `{}`, nil},
+	}
+	for _, c := range cases {
+		errs := spec.Validate(c.method, c.path, c.status, c.contentType, []byte(c.body))
+		if c.errs == nil {
+			assert.Nil(t, errs, "%v %v %v", c.method, c.path, c.status)
+			continue
+		}
+		msgs := []string{}
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		assert.Equal(t, c.errs, msgs, "%v %v %v", c.method, c.path, c.status)
+	}
+}
+
+func TestSwaggerValidate(t *testing.T) {
+	spec, err := Parse([]byte(`
+swagger: "2.0"
+basePath: /api
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/User"
+definitions:
+  User:
+    type: object
+    required: [name]
+`))
+	assert.NoError(t, err)
+	assert.Len(t, spec.Validate("GET", "/api/users", 200, "application/json", []byte(`[{"name": "a"}]`)), 0)
+	assert.Equal(t, []error{}, spec.Validate("GET", "/api/users", 200, "application/json", []byte(`[]`)))
+	errs := spec.Validate("GET", "/api/users", 200, "application/json", []byte(`[{}]`))
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "openapi schema #/paths/~1users/get/responses/200/schema is not matched at /0: name is required", errs[0].Error())
+	}
+}
+
+const nullableSpec = `
+openapi: 3.0.0
+paths:
+  /products/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Product"
+components:
+  schemas:
+    Product:
+      type: object
+      discriminator:
+        propertyName: kind
+      properties:
+        kind:
+          type: string
+          enum: [fruit]
+          nullable: true
+        description:
+          type: string
+          nullable: true
+          example: "fresh"
+        category:
+          $ref: "#/components/schemas/Category"
+          nullable: true
+        # property whose name is a keyword of OpenAPI
+        example:
+          type: string
+    Category:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+`
+
+func TestValidateNullable(t *testing.T) {
+	spec, err := Parse([]byte(nullableSpec))
+	assert.NoError(t, err)
+	cases := []struct {
+		body  string
+		valid bool
+	}{
+		{`{"kind": "fruit", "description": "apple", "category": {"name": "food"}, "example": "a"}`, true},
+		{`{"kind": null, "description": null, "category": null}`, true},
+		{`{"kind": "meat"}`, false},
+		{`{"description": 1}`, false},
+		{`{"category": {}}`, false},
+		{`{"example": null}`, false},
+	}
+	for _, c := range cases {
+		errs := spec.Validate("GET", "/products/1", 200, "application/json", []byte(c.body))
+		if c.valid {
+			assert.Empty(t, errs, c.body)
+			continue
+		}
+		assert.NotEmpty(t, errs, c.body)
+	}
+}
@@ -0,0 +1,57 @@
+package framework
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/caicloud/aloe/preset"
+	"github.com/caicloud/aloe/types"
+)
+
+// preset returns a copy of round trip which is merged with
+// presets and modified by presetters of context
+func (gf *genericFramework) preset(ctx *types.Context, rt *types.RoundTrip) (*types.RoundTrip, error) {
+	merged := types.MergeRoundTrip(*rt, ctx.RoundTripTemplate)
+	if len(ctx.Presetters) == 0 {
+		return &merged, nil
+	}
+	newRt := merged
+	// copy headers and hooks to avoid modifying round trip in test data
+	newRt.Request.Headers = map[string]string{}
+	for k, v := range merged.Request.Headers {
+		newRt.Request.Headers[k] = v
+	}
+	newRt.Request.Hooks = append([]types.RequestHook{}, merged.Request.Hooks...)
+	for _, pc := range ctx.Presetters {
+		p, ok := gf.presetters[pc.Name]
+		if !ok {
+			return nil, fmt.Errorf("presetter %v is not registered", pc.Name)
+		}
+		args := map[string]string{}
+		for k, v := range pc.Args {
+			rendered, err := v.Render(ctx.Variables)
+			if err != nil {
+				return nil, fmt.Errorf("can't render arg %v of presetter %v: %v", k, pc.Name, err)
+			}
+			args[k] = rendered
+		}
+		err := p.Preset(&newRt, args)
+		// secrets are added even if presetter is failed to mask its error
+		if sp, ok := p.(preset.SecretPresetter); ok {
+			gf.secrets.addValues(sp.Secrets(args)...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("presetter %v error: %v", pc.Name, err)
+		}
+		if rp, ok := p.(preset.RequestPresetter); ok {
+			name := pc.Name
+			newRt.Request.Hooks = append(newRt.Request.Hooks, func(req *http.Request) error {
+				if err := rp.PresetRequest(req, args); err != nil {
+					return fmt.Errorf("presetter %v error: %v", name, err)
+				}
+				return nil
+			})
+		}
+	}
+	return &newRt, nil
+}
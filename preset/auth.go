@@ -0,0 +1,60 @@
+package preset
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/caicloud/aloe/types"
+)
+
+const (
+	// AuthPresetterName is name of auth presetter
+	AuthPresetterName = "auth"
+
+	// TokenArg defines bearer token
+	TokenArg = "token"
+
+	// UsernameArg defines username of basic auth
+	UsernameArg = "username"
+
+	// PasswordArg defines password of basic auth
+	PasswordArg = "password"
+)
+
+type authPresetter struct{}
+
+// NewAuthPresetter returns a presetter which adds Authorization header
+// Bearer token is used if token is in args, otherwise basic auth
+// is used with username and password
+func NewAuthPresetter() Presetter {
+	return &authPresetter{}
+}
+
+// Name implements Presetter
+func (p *authPresetter) Name() string {
+	return AuthPresetterName
+}
+
+// Preset implements Presetter
+func (p *authPresetter) Preset(rt *types.RoundTrip, args map[string]string) error {
+	if token, ok := args[TokenArg]; ok {
+		setHeader(rt, "Authorization", "Bearer "+token)
+		return nil
+	}
+	username, ok := args[UsernameArg]
+	if !ok {
+		return fmt.Errorf("either %v or %v should be set", TokenArg, UsernameArg)
+	}
+	cred := username + ":" + args[PasswordArg]
+	setHeader(rt, "Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cred)))
+	return nil
+}
+
+// Secrets implements SecretPresetter
+func (p *authPresetter) Secrets(args map[string]string) []string {
+	if token, ok := args[TokenArg]; ok {
+		return []string{token}
+	}
+	cred := args[UsernameArg] + ":" + args[PasswordArg]
+	return []string{args[PasswordArg], base64.StdEncoding.EncodeToString([]byte(cred))}
+}
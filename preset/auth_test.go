@@ -0,0 +1,30 @@
+package preset
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthPresetter(t *testing.T) {
+	cases := []struct {
+		args    map[string]string
+		header  string
+		secrets []string
+	}{
+		{map[string]string{TokenArg: "tok-123"}, "Bearer tok-123", []string{"tok-123"}},
+		{map[string]string{UsernameArg: "admin", PasswordArg: "secret"}, "Basic YWRtaW46c2VjcmV0", []string{"secret", "YWRtaW46c2VjcmV0"}},
+	}
+	p := NewAuthPresetter()
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, p.Preset(rt, c.args), "%v", c.args) {
+			continue
+		}
+		assert.Equal(t, c.header, rt.Request.Headers["Authorization"], "%v", c.args)
+		// encoded credentials are also masked
+		assert.Equal(t, c.secrets, p.(SecretPresetter).Secrets(c.args), "%v", c.args)
+	}
+	assert.Error(t, p.Preset(&types.RoundTrip{}, map[string]string{}))
+}
@@ -0,0 +1,31 @@
+package preset
+
+import (
+	"github.com/caicloud/aloe/types"
+)
+
+const (
+	// HeaderPresetterName is name of header presetter
+	HeaderPresetterName = "header"
+)
+
+type headerPresetter struct{}
+
+// NewHeaderPresetter returns a presetter which adds headers to request
+// Key of args is header name and value of args is header value
+func NewHeaderPresetter() Presetter {
+	return &headerPresetter{}
+}
+
+// Name implements Presetter
+func (p *headerPresetter) Name() string {
+	return HeaderPresetterName
+}
+
+// Preset implements Presetter
+func (p *headerPresetter) Preset(rt *types.RoundTrip, args map[string]string) error {
+	for k, v := range args {
+		setHeader(rt, k, v)
+	}
+	return nil
+}
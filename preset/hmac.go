@@ -0,0 +1,193 @@
+package preset
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+)
+
+const (
+	// HMACPresetterName is name of hmac presetter
+	HMACPresetterName = "hmac"
+
+	// SecretArg defines secret key of hmac
+	SecretArg = "secret"
+
+	// AlgorithmArg defines hash of hmac, sha256 by default
+	// sha1, sha256 and sha512 are supported
+	AlgorithmArg = "algorithm"
+
+	// SignedPartsArg defines comma separated parts of request which are
+	// signed in order, default is method,path,timestamp,body
+	// method, path, query, host, timestamp, body and header:<name>
+	// are supported
+	SignedPartsArg = "signedParts"
+
+	// SeparatorArg defines separator of signed parts, default is "\n"
+	SeparatorArg = "separator"
+
+	// SignatureHeaderArg defines header of signature, default is X-Signature
+	SignatureHeaderArg = "signatureHeader"
+
+	// SignaturePrefixArg defines prefix of signature in header
+	// e.g. "HMAC-SHA256 "
+	SignaturePrefixArg = "signaturePrefix"
+
+	// EncodingArg defines encoding of signature, hex by default
+	// hex and base64 are supported
+	EncodingArg = "encoding"
+
+	// TimestampHeaderArg defines header of timestamp, default is X-Timestamp
+	TimestampHeaderArg = "timestampHeader"
+
+	// TimestampFormatArg defines format of timestamp, default is unix
+	// unix, unixMilli and rfc3339 are supported
+	TimestampFormatArg = "timestampFormat"
+)
+
+// now returns current time, it is replaced in tests
+var now = time.Now
+
+var hmacHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+type hmacPresetter struct{}
+
+// NewHMACPresetter returns a presetter which signs built request by hmac
+// Signature is computed over final method, url, headers and body of
+// request, and added into headers with timestamp
+func NewHMACPresetter() RequestPresetter {
+	return &hmacPresetter{}
+}
+
+// Name implements Presetter
+func (p *hmacPresetter) Name() string {
+	return HMACPresetterName
+}
+
+// Preset implements Presetter
+// Args are validated before request is built
+func (p *hmacPresetter) Preset(rt *types.RoundTrip, args map[string]string) error {
+	if args[SecretArg] == "" {
+		return fmt.Errorf("%v should be set", SecretArg)
+	}
+	if _, ok := hmacHashes[valueOf(args, AlgorithmArg, "sha256")]; !ok {
+		return fmt.Errorf("unknown algorithm %v", args[AlgorithmArg])
+	}
+	switch valueOf(args, EncodingArg, "hex") {
+	case "hex", "base64":
+	default:
+		return fmt.Errorf("unknown encoding %v", args[EncodingArg])
+	}
+	if _, err := p.timestamp(args); err != nil {
+		return err
+	}
+	for _, part := range signedParts(args) {
+		switch part {
+		case "method", "path", "query", "host", "timestamp", "body":
+		default:
+			if !strings.HasPrefix(part, "header:") {
+				return fmt.Errorf("unknown signed part %v", part)
+			}
+		}
+	}
+	return nil
+}
+
+// Secrets implements SecretPresetter
+func (p *hmacPresetter) Secrets(args map[string]string) []string {
+	return []string{args[SecretArg]}
+}
+
+// PresetRequest implements RequestPresetter
+func (p *hmacPresetter) PresetRequest(req *http.Request, args map[string]string) error {
+	ts, err := p.timestamp(args)
+	if err != nil {
+		return err
+	}
+	values := []string{}
+	for _, part := range signedParts(args) {
+		var v string
+		switch part {
+		case "method":
+			v = req.Method
+		case "path":
+			v = req.URL.EscapedPath()
+		case "query":
+			v = req.URL.RawQuery
+		case "host":
+			v = req.URL.Host
+		case "timestamp":
+			v = ts
+		case "body":
+			body, err := ReadBody(req)
+			if err != nil {
+				return err
+			}
+			v = string(body)
+		default:
+			v = req.Header.Get(strings.TrimPrefix(part, "header:"))
+		}
+		values = append(values, v)
+	}
+	mac := hmac.New(hmacHashes[valueOf(args, AlgorithmArg, "sha256")], []byte(args[SecretArg]))
+	separator := "\n"
+	if sep, ok := args[SeparatorArg]; ok {
+		separator = sep
+	}
+	mac.Write([]byte(strings.Join(values, separator)))
+	sum := mac.Sum(nil)
+	signature := hex.EncodeToString(sum)
+	if valueOf(args, EncodingArg, "hex") == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+	req.Header.Set(valueOf(args, TimestampHeaderArg, "X-Timestamp"), ts)
+	req.Header.Set(valueOf(args, SignatureHeaderArg, "X-Signature"), args[SignaturePrefixArg]+signature)
+	return nil
+}
+
+func (p *hmacPresetter) timestamp(args map[string]string) (string, error) {
+	now := now()
+	switch format := valueOf(args, TimestampFormatArg, "unix"); format {
+	case "unix":
+		return strconv.FormatInt(now.Unix(), 10), nil
+	case "unixMilli":
+		return strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10), nil
+	case "rfc3339":
+		return now.UTC().Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unknown timestamp format %v", format)
+	}
+}
+
+func signedParts(args map[string]string) []string {
+	parts := []string{}
+	for _, part := range strings.Split(valueOf(args, SignedPartsArg, "method,path,timestamp,body"), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// valueOf returns value of arg or default value if it is not set
+func valueOf(args map[string]string, arg, defaultValue string) string {
+	if v, ok := args[arg]; ok && v != "" {
+		return v
+	}
+	return defaultValue
+}
@@ -0,0 +1,154 @@
+package preset
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 4231 and RFC 2202 test case 2
+const (
+	rfcKey  = "Jefe"
+	rfcData = "what do ya want for nothing?"
+)
+
+func TestHMACPresetter(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time {
+		return time.Unix(1700000000, 123*int64(time.Millisecond))
+	}
+
+	cases := []struct {
+		description string
+		method      string
+		url         string
+		header      map[string]string
+		body        string
+		args        map[string]string
+		timestamp   string
+		signature   string
+	}{
+		{
+			description: "sha256 of rfc 4231",
+			method:      "POST",
+			url:         "http://example.com/",
+			body:        rfcData,
+			args:        map[string]string{SecretArg: rfcKey, SignedPartsArg: "body"},
+			timestamp:   "1700000000",
+			signature:   "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			description: "sha1 of rfc 2202",
+			method:      "POST",
+			url:         "http://example.com/",
+			body:        rfcData,
+			args:        map[string]string{SecretArg: rfcKey, SignedPartsArg: "body", AlgorithmArg: "sha1"},
+			timestamp:   "1700000000",
+			signature:   "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79",
+		},
+		{
+			description: "sha512 of rfc 4231",
+			method:      "POST",
+			url:         "http://example.com/",
+			body:        rfcData,
+			args:        map[string]string{SecretArg: rfcKey, SignedPartsArg: "body", AlgorithmArg: "sha512"},
+			timestamp:   "1700000000",
+			signature:   "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+		{
+			description: "base64 encoding with prefix",
+			method:      "POST",
+			url:         "http://example.com/",
+			body:        rfcData,
+			args: map[string]string{
+				SecretArg:          rfcKey,
+				SignedPartsArg:     "body",
+				EncodingArg:        "base64",
+				SignaturePrefixArg: "HMAC-SHA256 ",
+			},
+			timestamp: "1700000000",
+			signature: "HMAC-SHA256 W9zBRr9gdU5qBCQmCJV1x1oAPwidJzmDnexYuWTsOEM=",
+		},
+		{
+			// signed "POST\n/api/products\n1700000000\n{\"name\":\"apple\"}"
+			description: "default signed parts",
+			method:      "POST",
+			url:         "http://example.com/api/products",
+			body:        `{"name":"apple"}`,
+			args:        map[string]string{SecretArg: "secret"},
+			timestamp:   "1700000000",
+			signature:   "3d9ce56c6a59c5ff81bb2d510983b864c59b9d31eae395c30e793d580aef66c6",
+		},
+		{
+			// signed "GET\n/api/products\n1700000000123\n"
+			description: "unix milli timestamp and empty body",
+			method:      "GET",
+			url:         "http://example.com/api/products",
+			args:        map[string]string{SecretArg: "secret", TimestampFormatArg: "unixMilli"},
+			timestamp:   "1700000000123",
+			signature:   "e789ef9d4deb44dffd4960f058892632dc83e2dbf32052c827396aa85e81aa0b",
+		},
+		{
+			// signed "GET|example.com|page=1&size=10|2023-11-14T22:13:20Z|v1"
+			description: "custom signed parts and separator",
+			method:      "GET",
+			url:         "http://example.com/api/products?page=1&size=10",
+			header:      map[string]string{"X-Version": "v1"},
+			args: map[string]string{
+				SecretArg:          "secret",
+				SignedPartsArg:     "method, host, query, timestamp, header:X-Version",
+				SeparatorArg:       "|",
+				TimestampFormatArg: "rfc3339",
+				TimestampHeaderArg: "X-Date",
+				SignatureHeaderArg: "X-Sign",
+			},
+			timestamp: "2023-11-14T22:13:20Z",
+			signature: "d158f41b380db6a5b4fe37525bc6024a7a2ad8bba1ba3981687574febcebfb15",
+		},
+	}
+	p := NewHMACPresetter()
+	for _, c := range cases {
+		if !assert.NoError(t, p.Preset(&types.RoundTrip{}, c.args), c.description) {
+			continue
+		}
+		req, err := http.NewRequest(c.method, c.url, strings.NewReader(c.body))
+		if !assert.NoError(t, err, c.description) {
+			continue
+		}
+		for k, v := range c.header {
+			req.Header.Set(k, v)
+		}
+		if !assert.NoError(t, p.PresetRequest(req, c.args), c.description) {
+			continue
+		}
+		assert.Equal(t, c.timestamp, req.Header.Get(valueOf(c.args, TimestampHeaderArg, "X-Timestamp")), c.description)
+		assert.Equal(t, c.signature, req.Header.Get(valueOf(c.args, SignatureHeaderArg, "X-Signature")), c.description)
+		// body can still be sent after it is signed
+		body, err := ReadBody(req)
+		assert.NoError(t, err, c.description)
+		assert.Equal(t, c.body, string(body), c.description)
+	}
+}
+
+func TestHMACPresetterInvalidArgs(t *testing.T) {
+	cases := []struct {
+		args map[string]string
+		err  string
+	}{
+		{map[string]string{}, "secret should be set"},
+		{map[string]string{SecretArg: "secret", AlgorithmArg: "md5"}, "unknown algorithm md5"},
+		{map[string]string{SecretArg: "secret", EncodingArg: "base32"}, "unknown encoding base32"},
+		{map[string]string{SecretArg: "secret", TimestampFormatArg: "rfc1123"}, "unknown timestamp format rfc1123"},
+		{map[string]string{SecretArg: "secret", SignedPartsArg: "method,cookie"}, "unknown signed part cookie"},
+	}
+	for _, c := range cases {
+		err := NewHMACPresetter().Preset(&types.RoundTrip{}, c.args)
+		if assert.Error(t, err, "%v", c.args) {
+			assert.Equal(t, c.err, err.Error(), "%v", c.args)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+)
+
+const (
+	// OAuth2PresetterName is name of oauth2 presetter
+	OAuth2PresetterName = "oauth2"
+
+	// TokenURLArg defines token endpoint of oauth2
+	TokenURLArg = "tokenURL"
+
+	// ClientIDArg defines client id of oauth2
+	ClientIDArg = "clientID"
+
+	// ClientSecretArg defines client secret of oauth2
+	ClientSecretArg = "clientSecret"
+
+	// ScopesArg defines space separated scopes of oauth2
+	ScopesArg = "scopes"
+)
+
+// expiryDelta is used to refresh token before it is expired
+const expiryDelta = 10 * time.Second
+
+type token struct {
+	accessToken string
+	// zero expiry means token never expires
+	expiry time.Time
+}
+
+func (t *token) valid() bool {
+	return t.expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.expiry)
+}
+
+type oauth2Presetter struct {
+	client *http.Client
+
+	lock   sync.Mutex
+	tokens map[string]*token
+}
+
+// NewOAuth2Presetter returns a presetter which gets token by client
+// credentials grant of oauth2 and adds a bearer Authorization header
+// Token is cached and refreshed when it is expired
+// It is registered by framework with its client, e.g. with tls config of
+// WithClientOptions. If client is nil, http.DefaultClient is used
+func NewOAuth2Presetter(client *http.Client) Presetter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &oauth2Presetter{
+		client: client,
+		tokens: map[string]*token{},
+	}
+}
+
+// Name implements Presetter
+func (p *oauth2Presetter) Name() string {
+	return OAuth2PresetterName
+}
+
+// Preset implements Presetter
+func (p *oauth2Presetter) Preset(rt *types.RoundTrip, args map[string]string) error {
+	for _, arg := range []string{TokenURLArg, ClientIDArg, ClientSecretArg} {
+		if args[arg] == "" {
+			return fmt.Errorf("%v should be set", arg)
+		}
+	}
+	t, err := p.token(args[TokenURLArg], args[ClientIDArg], args[ClientSecretArg], args[ScopesArg])
+	if err != nil {
+		return err
+	}
+	setHeader(rt, "Authorization", "Bearer "+t.accessToken)
+	return nil
+}
+
+// Secrets implements SecretPresetter
+// Client secret and the cached token are secrets
+func (p *oauth2Presetter) Secrets(args map[string]string) []string {
+	secrets := []string{args[ClientSecretArg]}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if t, ok := p.tokens[tokenKey(args[TokenURLArg], args[ClientIDArg], args[ScopesArg])]; ok {
+		secrets = append(secrets, t.accessToken)
+	}
+	return secrets
+}
+
+// tokenKey returns key of cached token
+func tokenKey(tokenURL, clientID, scopes string) string {
+	return strings.Join([]string{tokenURL, clientID, scopes}, "\n")
+}
+
+func (p *oauth2Presetter) token(tokenURL, clientID, clientSecret, scopes string) (*token, error) {
+	key := tokenKey(tokenURL, clientID, scopes)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if t, ok := p.tokens[key]; ok && t.valid() {
+		return t, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if scopes != "" {
+		form.Set("scope", scopes)
+	}
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't get token from %v: %v", tokenURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't get token from %v, status code: %v, body: %v", tokenURL, resp.StatusCode, string(body))
+	}
+
+	tr := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("can't unmarshal token response: %v", err)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("access_token is empty in token response")
+	}
+	t := &token{
+		accessToken: tr.AccessToken,
+	}
+	if tr.ExpiresIn > 0 {
+		t.expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	p.tokens[key] = t
+	return t, nil
+}
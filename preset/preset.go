@@ -0,0 +1,54 @@
+package preset
+
+import (
+	"net/http"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// Presetter defines an interface to preset round trip
+// e.g. add common headers of round trips
+type Presetter interface {
+	// Name returns name of presetter
+	// It is referenced by presetters in context config
+	Name() string
+
+	// Preset modifies round trip with args
+	// Values of args has been rendered by variables of context
+	Preset(rt *types.RoundTrip, args map[string]string) error
+}
+
+// RequestPresetter defines a presetter which also modifies http request
+// after it is built, e.g. sign request by its final body
+type RequestPresetter interface {
+	Presetter
+
+	// PresetRequest modifies built request with args
+	// It is called each time request is built, e.g. when it is retried
+	// Only headers of grpc requests are used and sent as metadata
+	PresetRequest(req *http.Request, args map[string]string) error
+}
+
+// SecretPresetter defines a presetter whose args or outputs are secrets
+// e.g. tokens, they are masked in all outputs like secret variables
+type SecretPresetter interface {
+	Presetter
+
+	// Secrets returns secrets of round trip which is preset with args
+	// It is called after Preset even if it is failed
+	Secrets(args map[string]string) []string
+}
+
+// setHeader sets header of request if it is not set
+// Headers of request always win
+func setHeader(rt *types.RoundTrip, key, value string) {
+	if rt.Request.Headers == nil {
+		rt.Request.Headers = map[string]string{}
+	}
+	for k := range rt.Request.Headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(key) {
+			return
+		}
+	}
+	rt.Request.Headers[key] = value
+}
@@ -0,0 +1,100 @@
+package preset
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+)
+
+const (
+	// QueryPresetterName is name of query presetter
+	QueryPresetterName = "query"
+
+	// OverrideArg defines whether query parameters of request
+	// will be overridden by query presetter
+	OverrideArg = "$override"
+)
+
+type queryPresetter struct{}
+
+// NewQueryPresetter returns a presetter which adds query parameters
+// Key of args is parameter name and value of args is parameter value
+// Parameters of request will not be overridden unless $override is true,
+// and overridden parameters in api are replaced
+func NewQueryPresetter() Presetter {
+	return &queryPresetter{}
+}
+
+// Name implements Presetter
+func (p *queryPresetter) Name() string {
+	return QueryPresetterName
+}
+
+// Preset implements Presetter
+func (p *queryPresetter) Preset(rt *types.RoundTrip, args map[string]string) error {
+	override := false
+	if o, ok := args[OverrideArg]; ok {
+		b, err := strconv.ParseBool(o)
+		if err != nil {
+			return err
+		}
+		override = b
+	}
+	existed := existedQuery(rt)
+	if rt.Request.Query == nil {
+		rt.Request.Query = map[string]types.Templates{}
+	} else {
+		// copy query to avoid modifying round trip in test data
+		query := map[string]types.Templates{}
+		for k, v := range rt.Request.Query {
+			query[k] = v
+		}
+		rt.Request.Query = query
+	}
+	replaced := append([]string{}, rt.Request.ReplaceQuery...)
+	for k, v := range args {
+		if k == OverrideArg {
+			continue
+		}
+		if existed[k] {
+			if !override {
+				continue
+			}
+			replaced = append(replaced, k)
+		}
+		t, err := types.NewTemplate(template.Escape(v))
+		if err != nil {
+			return err
+		}
+		rt.Request.Query[k] = types.Templates{*t}
+	}
+	rt.Request.ReplaceQuery = replaced
+	return nil
+}
+
+// existedQuery returns keys of query parameters in api and query of request
+func existedQuery(rt *types.RoundTrip) map[string]bool {
+	existed := map[string]bool{}
+	for k := range rt.Request.Query {
+		existed[k] = true
+	}
+	if rt.Request.API == nil {
+		return existed
+	}
+	api := rt.Request.API.Raw()
+	i := strings.Index(api, "?")
+	if i < 0 {
+		return existed
+	}
+	q, err := url.ParseQuery(api[i+1:])
+	if err != nil {
+		return existed
+	}
+	for k := range q {
+		existed[k] = true
+	}
+	return existed
+}
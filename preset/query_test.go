@@ -0,0 +1,39 @@
+package preset
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryPresetter(t *testing.T) {
+	cases := []struct {
+		args     map[string]string
+		keys     []string
+		replaced []string
+	}{
+		{map[string]string{"page": "2", "size": "10"}, []string{"size", "sort"}, []string{}},
+		{map[string]string{"page": "2", "sort": "name", OverrideArg: "true"}, []string{"page", "sort"}, []string{"page", "sort"}},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		raw := `{"api": "GET /products?page=1", "query": {"sort": "id"}}`
+		if !assert.NoError(t, json.Unmarshal([]byte(raw), &rt.Request)) {
+			return
+		}
+		if !assert.NoError(t, NewQueryPresetter().Preset(rt, c.args), "%v", c.args) {
+			continue
+		}
+		keys := []string{}
+		for k := range rt.Request.Query {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sort.Strings(rt.Request.ReplaceQuery)
+		assert.Equal(t, c.keys, keys, "%v", c.args)
+		assert.Equal(t, c.replaced, rt.Request.ReplaceQuery, "%v", c.args)
+	}
+}
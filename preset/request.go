@@ -0,0 +1,69 @@
+package preset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// RequestPresetFunc modifies built request with args
+type RequestPresetFunc func(req *http.Request, args map[string]string) error
+
+type requestPresetter struct {
+	name string
+	fn   RequestPresetFunc
+}
+
+// NewRequestPresetter returns a presetter which only modifies built request
+// by fn, e.g. method, url, headers and body of request
+func NewRequestPresetter(name string, fn RequestPresetFunc) RequestPresetter {
+	return &requestPresetter{
+		name: name,
+		fn:   fn,
+	}
+}
+
+// Name implements Presetter
+func (p *requestPresetter) Name() string {
+	return p.name
+}
+
+// Preset implements Presetter
+func (p *requestPresetter) Preset(rt *types.RoundTrip, args map[string]string) error {
+	return nil
+}
+
+// PresetRequest implements RequestPresetter
+func (p *requestPresetter) PresetRequest(req *http.Request, args map[string]string) error {
+	return p.fn(req, args)
+}
+
+// ReadBody returns body of built request without consuming it
+func ReadBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("can't get body of request: %v", err)
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// SetBody replaces body of built request
+// Content length is updated and body can still be got by GetBody
+func SetBody(req *http.Request, body []byte) {
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+}
@@ -0,0 +1,106 @@
+package preset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestPresetter(t *testing.T) {
+	p := NewRequestPresetter("tenant", func(req *http.Request, args map[string]string) error {
+		if args["tenant"] == "" {
+			return fmt.Errorf("tenant should be set")
+		}
+		req.Header.Set("X-Tenant", args["tenant"])
+		return nil
+	})
+	assert.Equal(t, "tenant", p.Name())
+	rt := &types.RoundTrip{}
+	// round trip is not modified
+	assert.NoError(t, p.Preset(rt, nil))
+	assert.Equal(t, &types.RoundTrip{}, rt)
+
+	req, err := http.NewRequest("GET", "http://example.com/products", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, p.PresetRequest(req, map[string]string{"tenant": "acme"}))
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant"))
+	assert.EqualError(t, p.PresetRequest(req, map[string]string{}), "tenant should be set")
+}
+
+func TestReadBody(t *testing.T) {
+	cases := []struct {
+		description string
+		request     func() *http.Request
+		expected    string
+	}{
+		{
+			description: "request without body",
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "http://example.com", nil)
+				return req
+			},
+			expected: "",
+		},
+		{
+			description: "request with body",
+			request: func() *http.Request {
+				req, _ := http.NewRequest("POST", "http://example.com", strings.NewReader(`{"name":"apple"}`))
+				return req
+			},
+			expected: `{"name":"apple"}`,
+		},
+	}
+	for _, c := range cases {
+		req := c.request()
+		for i := 0; i < 2; i++ {
+			// body is not consumed by reading
+			body, err := ReadBody(req)
+			assert.NoError(t, err, c.description)
+			assert.Equal(t, c.expected, string(body), c.description)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "http://example.com", strings.NewReader("apple"))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, fmt.Errorf("closed")
+	}
+	_, err := ReadBody(req)
+	assert.EqualError(t, err, "can't get body of request: closed")
+}
+
+func TestSetBody(t *testing.T) {
+	cases := []struct {
+		body []byte
+	}{
+		{[]byte(`{"name":"apple"}`)},
+		{[]byte{}},
+		{nil},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "http://example.com", bytes.NewReader([]byte(`{"name": "banana"}`)))
+		if !assert.NoError(t, err) {
+			return
+		}
+		SetBody(req, c.body)
+		assert.Equal(t, int64(len(c.body)), req.ContentLength, "%q", c.body)
+		sent, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err, "%q", c.body)
+		assert.Equal(t, string(c.body), string(sent), "%q", c.body)
+		if len(c.body) == 0 {
+			assert.Equal(t, http.NoBody, req.Body, "%q", c.body)
+		}
+		// body can be got again, e.g. when request is retried
+		got, err := ReadBody(req)
+		assert.NoError(t, err, "%q", c.body)
+		assert.Equal(t, string(c.body), string(got), "%q", c.body)
+	}
+}
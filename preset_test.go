@@ -0,0 +1,61 @@
+package framework
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caicloud/aloe/preset"
+	"github.com/caicloud/aloe/roundtrip"
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingTransport counts requests sent by it
+type countingTransport struct {
+	requests []string
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req.Method+" "+req.URL.Path)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOAuth2PresetterClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "tok-123"}`))
+	}))
+	defer s.Close()
+
+	transport := &countingTransport{}
+	gf := NewFrameworkWithOptions(s.URL, func() {}, nil,
+		WithClientOptions(roundtrip.WithTransport(transport)),
+	).(*genericFramework)
+	ctx := &types.Context{
+		Variables: map[string]template.Variable{},
+		Presetters: []types.PresetConfig{{
+			Name: preset.OAuth2PresetterName,
+			Args: map[string]types.Template{
+				preset.TokenURLArg:     *mustTemplate(t, s.URL+"/token"),
+				preset.ClientIDArg:     *mustTemplate(t, "aloe"),
+				preset.ClientSecretArg: *mustTemplate(t, "secret"),
+			},
+		}},
+	}
+	rt, err := gf.preset(ctx, &types.RoundTrip{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Bearer tok-123", rt.Request.Headers["Authorization"])
+	// token is got by client of framework
+	assert.Equal(t, []string{"POST /token"}, transport.requests)
+}
+
+func mustTemplate(t *testing.T, raw string) *types.Template {
+	tmpl, err := types.NewTemplate(raw)
+	if err != nil {
+		t.Fatalf("can't parse template %v: %v", raw, err)
+	}
+	return tmpl
+}
@@ -0,0 +1,190 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/caicloud/aloe/openapi"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/onsi/ginkgo/types"
+)
+
+// CoverageOperation defines an operation and its hits in coverage report
+type CoverageOperation struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Hits   int    `json:"hits"`
+}
+
+// CoverageReport defines json coverage report of operations in spec
+type CoverageReport struct {
+	Total int `json:"total"`
+	// Percent is percent of covered operations
+	Percent   float64             `json:"percent"`
+	Covered   []CoverageOperation `json:"covered"`
+	Uncovered []CoverageOperation `json:"uncovered"`
+	// Undocumented are requests which are not found in spec
+	Undocumented []CoverageOperation `json:"undocumented,omitempty"`
+}
+
+// CoverageReporter records requests of suite and writes a json report of
+// operations in OpenAPI spec which are and aren't covered by them
+type CoverageReporter struct {
+	filename string
+	spec     *openapi.Spec
+	config   config.GinkgoConfigType
+
+	lock         sync.Mutex
+	hits         map[*openapi.Operation]int
+	undocumented map[string]int
+}
+
+var _ reporters.Reporter = &CoverageReporter{}
+
+// NewCoverageReporter returns a coverage reporter of spec which writes report to filename
+func NewCoverageReporter(filename string, spec *openapi.Spec) *CoverageReporter {
+	return &CoverageReporter{
+		filename:     filename,
+		spec:         spec,
+		hits:         map[*openapi.Operation]int{},
+		undocumented: map[string]int{},
+	}
+}
+
+// Hit records a request with method and path
+func (r *CoverageReporter) Hit(method, path string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if op := r.spec.Find(method, path); op != nil {
+		r.hits[op]++
+		return
+	}
+	r.undocumented[strings.ToUpper(method)+" "+path]++
+}
+
+// SpecSuiteWillBegin implements reporters.Reporter
+func (r *CoverageReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.config = config
+}
+
+// BeforeSuiteDidRun implements reporters.Reporter
+func (r *CoverageReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+}
+
+// SpecWillRun implements reporters.Reporter
+func (r *CoverageReporter) SpecWillRun(specSummary *types.SpecSummary) {
+}
+
+// SpecDidComplete implements reporters.Reporter
+func (r *CoverageReporter) SpecDidComplete(specSummary *types.SpecSummary) {
+}
+
+// AfterSuiteDidRun implements reporters.Reporter
+func (r *CoverageReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+}
+
+// SpecSuiteDidEnd implements reporters.Reporter
+func (r *CoverageReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	// requests of parallel nodes are merged into report of the first node
+	first, err := syncResult(r.filename, r.config, r.Report(), r.merge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't merge coverage report %v: %v\n", r.filename, err)
+	}
+	if !first {
+		return
+	}
+	if err := r.write(r.filename); err != nil {
+		fmt.Fprintf(os.Stderr, "can't write coverage report %v: %v\n", r.filename, err)
+	}
+}
+
+// merge adds hits in coverage report of another node
+func (r *CoverageReporter) merge(data []byte) error {
+	report := &CoverageReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return fmt.Errorf("can't decode coverage report: %v", err)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, co := range report.Covered {
+		found := false
+		for _, op := range r.spec.Operations {
+			if op.Method == co.Method && op.Path == co.Path {
+				r.hits[op] += co.Hits
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("can't find operation %v %v in spec", co.Method, co.Path)
+		}
+	}
+	for _, co := range report.Undocumented {
+		r.undocumented[co.Method+" "+co.Path] += co.Hits
+	}
+	return nil
+}
+
+// Report returns coverage report of recorded requests
+func (r *CoverageReporter) Report() *CoverageReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	report := &CoverageReport{
+		Total:     len(r.spec.Operations),
+		Covered:   []CoverageOperation{},
+		Uncovered: []CoverageOperation{},
+	}
+	for _, op := range r.spec.Operations {
+		co := CoverageOperation{
+			Method: op.Method,
+			Path:   op.Path,
+			Hits:   r.hits[op],
+		}
+		if co.Hits == 0 {
+			report.Uncovered = append(report.Uncovered, co)
+			continue
+		}
+		report.Covered = append(report.Covered, co)
+	}
+	if report.Total > 0 {
+		percent := float64(len(report.Covered)) * 100 / float64(report.Total)
+		report.Percent = math.Round(percent*100) / 100
+	}
+	for api, hits := range r.undocumented {
+		s := strings.SplitN(api, " ", 2)
+		report.Undocumented = append(report.Undocumented, CoverageOperation{
+			Method: s[0],
+			Path:   s[1],
+			Hits:   hits,
+		})
+	}
+	sort.Slice(report.Undocumented, func(i, j int) bool {
+		a, b := report.Undocumented[i], report.Undocumented[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return report
+}
+
+func (r *CoverageReporter) write(filename string) error {
+	data, err := json.MarshalIndent(r.Report(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,106 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caicloud/aloe/openapi"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const coverageSpec = `
+openapi: 3.0.0
+paths:
+  /products:
+    get: {}
+    post: {}
+  /products/{id}:
+    get: {}
+    delete: {}
+`
+
+func newCoverageReporter(t *testing.T, filename string) *CoverageReporter {
+	spec, err := openapi.Parse([]byte(coverageSpec))
+	if err != nil {
+		t.Fatalf("can't parse spec: %v", err)
+	}
+	return NewCoverageReporter(filename, spec)
+}
+
+func TestCoverageReport(t *testing.T) {
+	r := newCoverageReporter(t, "")
+	r.Hit("GET", "/products")
+	r.Hit("get", "/products/1")
+	r.Hit("GET", "/products/2")
+	r.Hit("PUT", "/orders/1")
+	report := r.Report()
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 50.0, report.Percent)
+	assert.Equal(t, []CoverageOperation{
+		{Method: "GET", Path: "/products", Hits: 1},
+		{Method: "GET", Path: "/products/{id}", Hits: 2},
+	}, report.Covered)
+	assert.Equal(t, []CoverageOperation{
+		{Method: "POST", Path: "/products"},
+		{Method: "DELETE", Path: "/products/{id}"},
+	}, report.Uncovered)
+	assert.Equal(t, []CoverageOperation{
+		{Method: "PUT", Path: "/orders/1", Hits: 1},
+	}, report.Undocumented)
+}
+
+func TestCoverageReportParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe-coverage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "coverage.json")
+
+	nodes := []*CoverageReporter{}
+	for node := 1; node <= 2; node++ {
+		r := newCoverageReporter(t, filename)
+		r.SpecSuiteWillBegin(config.GinkgoConfigType{
+			ParallelNode:  node,
+			ParallelTotal: 2,
+			SyncHost:      "http://127.0.0.1:8888",
+			RandomSeed:    1,
+		}, &types.SuiteSummary{})
+		nodes = append(nodes, r)
+	}
+	nodes[0].Hit("GET", "/products")
+	nodes[1].Hit("GET", "/products")
+	nodes[1].Hit("POST", "/products")
+	nodes[1].Hit("PUT", "/orders/1")
+
+	// the first node waits for other nodes
+	done := make(chan struct{})
+	go func() {
+		nodes[0].SpecSuiteDidEnd(&types.SuiteSummary{})
+		close(done)
+	}()
+	nodes[1].SpecSuiteDidEnd(&types.SuiteSummary{})
+	<-done
+
+	data, err := ioutil.ReadFile(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	report := &CoverageReport{}
+	assert.NoError(t, json.Unmarshal(data, report))
+	assert.Equal(t, 50.0, report.Percent)
+	assert.Equal(t, []CoverageOperation{
+		{Method: "GET", Path: "/products", Hits: 2},
+		{Method: "POST", Path: "/products", Hits: 1},
+	}, report.Covered)
+	assert.Equal(t, []CoverageOperation{
+		{Method: "PUT", Path: "/orders/1", Hits: 1},
+	}, report.Undocumented)
+	// only merged report is left
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
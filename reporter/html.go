@@ -0,0 +1,362 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/onsi/ginkgo/types"
+)
+
+// HTMLStep defines a step of case in html report
+type HTMLStep struct {
+	Text string
+	// Details are requests and responses of the step
+	Details []string
+	// Failure is failure message if case is failed in the step
+	Failure string
+}
+
+// HTMLCase defines a case in html report
+type HTMLCase struct {
+	Name     string
+	State    string
+	Duration time.Duration
+	Steps    []HTMLStep
+	// Failure is failure message if case is failed without steps
+	Failure string
+}
+
+// HTMLContext defines a context in html report
+type HTMLContext struct {
+	Name     string
+	Contexts []*HTMLContext
+	Cases    []*HTMLCase
+	Passed   int
+	Failed   int
+}
+
+// HTMLReporter writes a self-contained html report when suite is finished
+// Steps and details of cases are added by framework when cases are run
+type HTMLReporter struct {
+	filename string
+	mask     func(string) string
+	config   config.GinkgoConfigType
+
+	lock    sync.Mutex
+	suite   string
+	root    *HTMLContext
+	current *HTMLCase
+}
+
+var _ reporters.Reporter = &HTMLReporter{}
+
+// NewHTMLReporter returns a html reporter which writes report to filename
+// All texts in report are masked, e.g. secret variables
+func NewHTMLReporter(filename string, mask func(string) string) *HTMLReporter {
+	return &HTMLReporter{
+		filename: filename,
+		mask:     mask,
+		current:  &HTMLCase{},
+	}
+}
+
+// Step adds a step into current case
+func (r *HTMLReporter) Step(text string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.current.Steps = append(r.current.Steps, HTMLStep{Text: text})
+}
+
+// Detail adds a detail into the last step of current case
+// e.g. request and response of the step
+// Details before any step are added into a setup step, e.g. context
+func (r *HTMLReporter) Detail(text string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.current.Steps) == 0 {
+		r.current.Steps = append(r.current.Steps, HTMLStep{Text: "Setup"})
+	}
+	last := &r.current.Steps[len(r.current.Steps)-1]
+	last.Details = append(last.Details, text)
+}
+
+// SpecSuiteWillBegin implements reporters.Reporter
+func (r *HTMLReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.suite = summary.SuiteDescription
+	r.root = &HTMLContext{Name: summary.SuiteDescription}
+	r.config = config
+}
+
+// BeforeSuiteDidRun implements reporters.Reporter
+func (r *HTMLReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.handleSetupSummary("BeforeSuite", setupSummary)
+}
+
+// SpecWillRun implements reporters.Reporter
+func (r *HTMLReporter) SpecWillRun(specSummary *types.SpecSummary) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.current = &HTMLCase{}
+}
+
+// SpecDidComplete implements reporters.Reporter
+func (r *HTMLReporter) SpecDidComplete(specSummary *types.SpecSummary) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	c := r.current
+	r.current = &HTMLCase{}
+
+	texts := specSummary.ComponentTexts
+	if len(texts) > 1 {
+		// the first component text is always top level of ginkgo
+		texts = texts[1:]
+	}
+	c.Name = texts[len(texts)-1]
+	c.State = stateOf(specSummary.State)
+	c.Duration = specSummary.RunTime
+	if specSummary.Failed() {
+		r.fail(c, failureMessage(specSummary.Failure))
+	}
+	ctx := r.root
+	for _, name := range texts[:len(texts)-1] {
+		ctx = ctx.child(name)
+	}
+	ctx.Cases = append(ctx.Cases, c)
+}
+
+// AfterSuiteDidRun implements reporters.Reporter
+func (r *HTMLReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.handleSetupSummary("AfterSuite", setupSummary)
+}
+
+// SpecSuiteDidEnd implements reporters.Reporter
+func (r *HTMLReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	// secrets are masked when all of them are known
+	r.root.maskWith(r.mask)
+	result := &htmlResult{
+		Root:     r.root,
+		Passed:   summary.NumberOfPassedSpecs,
+		Failed:   summary.NumberOfFailedSpecs,
+		Skipped:  summary.NumberOfSkippedSpecs + summary.NumberOfPendingSpecs,
+		Duration: summary.RunTime,
+	}
+	// contexts and cases of parallel nodes are merged into report of the
+	// first node, and duration is the longest one of nodes
+	first, err := syncResult(r.filename, r.config, result, result.merge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't merge html report %v: %v\n", r.filename, err)
+	}
+	if !first {
+		return
+	}
+	r.root.count()
+	if err := r.write(r.filename, result); err != nil {
+		fmt.Fprintf(os.Stderr, "can't write html report %v: %v\n", r.filename, err)
+	}
+}
+
+// htmlResult is result of a node which is written into html report
+type htmlResult struct {
+	Root     *HTMLContext  `json:"root"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+	Duration time.Duration `json:"duration"`
+}
+
+// merge adds contexts, cases and counts in result of another node
+func (r *htmlResult) merge(data []byte) error {
+	other := &htmlResult{}
+	if err := json.Unmarshal(data, other); err != nil {
+		return fmt.Errorf("can't decode html result: %v", err)
+	}
+	if other.Root != nil {
+		r.Root.merge(other.Root)
+	}
+	r.Passed += other.Passed
+	r.Failed += other.Failed
+	r.Skipped += other.Skipped
+	if other.Duration > r.Duration {
+		r.Duration = other.Duration
+	}
+	return nil
+}
+
+// fail adds failure message into the last step of case
+// It is the step where case is failed
+func (r *HTMLReporter) fail(c *HTMLCase, msg string) {
+	if len(c.Steps) == 0 {
+		c.Failure = msg
+		return
+	}
+	c.Steps[len(c.Steps)-1].Failure = msg
+}
+
+func (r *HTMLReporter) handleSetupSummary(name string, setupSummary *types.SetupSummary) {
+	if setupSummary.State == types.SpecStatePassed {
+		return
+	}
+	c := &HTMLCase{
+		Name:     name,
+		State:    stateOf(setupSummary.State),
+		Duration: setupSummary.RunTime,
+	}
+	r.fail(c, failureMessage(setupSummary.Failure))
+	r.root.Cases = append(r.root.Cases, c)
+}
+
+func (r *HTMLReporter) write(filename string, result *htmlResult) error {
+	if dir := filepath.Dir(filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return htmlTemplate.Execute(f, map[string]interface{}{
+		"Suite":    r.suite,
+		"Root":     result.Root,
+		"Passed":   result.Passed,
+		"Failed":   result.Failed,
+		"Skipped":  result.Skipped,
+		"Duration": result.Duration.Round(time.Millisecond),
+	})
+}
+
+// child returns sub context with name, it is created if not found
+func (c *HTMLContext) child(name string) *HTMLContext {
+	for _, sub := range c.Contexts {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	sub := &HTMLContext{Name: name}
+	c.Contexts = append(c.Contexts, sub)
+	return sub
+}
+
+// merge adds sub contexts and cases of another context
+// Contexts with the same name are merged, cases are appended in order
+func (c *HTMLContext) merge(other *HTMLContext) {
+	for _, sub := range other.Contexts {
+		c.child(sub.Name).merge(sub)
+	}
+	c.Cases = append(c.Cases, other.Cases...)
+}
+
+// maskWith masks all texts in context and sub contexts
+func (c *HTMLContext) maskWith(mask func(string) string) {
+	c.Name = mask(c.Name)
+	for _, sub := range c.Contexts {
+		sub.maskWith(mask)
+	}
+	for _, cs := range c.Cases {
+		cs.Name = mask(cs.Name)
+		cs.Failure = mask(cs.Failure)
+		for i := range cs.Steps {
+			step := &cs.Steps[i]
+			step.Text = mask(step.Text)
+			step.Failure = mask(step.Failure)
+			for j := range step.Details {
+				step.Details[j] = mask(step.Details[j])
+			}
+		}
+	}
+}
+
+// count counts passed and failed cases in context and sub contexts
+func (c *HTMLContext) count() {
+	c.Passed, c.Failed = 0, 0
+	for _, sub := range c.Contexts {
+		sub.count()
+		c.Passed += sub.Passed
+		c.Failed += sub.Failed
+	}
+	for _, cs := range c.Cases {
+		switch cs.State {
+		case "passed":
+			c.Passed++
+		case "failed", "timeout", "panicked":
+			c.Failed++
+		}
+	}
+}
+
+func stateOf(state types.SpecState) string {
+	switch state {
+	case types.SpecStatePassed:
+		return "passed"
+	case types.SpecStateFailed:
+		return "failed"
+	case types.SpecStateTimedOut:
+		return "timeout"
+	case types.SpecStatePanicked:
+		return "panicked"
+	case types.SpecStatePending:
+		return "pending"
+	case types.SpecStateSkipped:
+		return "skipped"
+	}
+	return "unknown"
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Suite}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+.ctx { margin-left: 1.5em; }
+summary { cursor: pointer; }
+.case { margin: 0.3em 0 0.3em 1.5em; }
+.state { display: inline-block; min-width: 5em; padding: 0 0.4em; border-radius: 3px; color: #fff; text-align: center; }
+.passed { background: #2e7d32; }
+.failed, .timeout, .panicked { background: #c62828; }
+.pending, .skipped, .unknown { background: #9e9e9e; }
+.duration { color: #777; }
+.failure { color: #c62828; white-space: pre-wrap; }
+.failed-step { font-weight: bold; color: #c62828; }
+pre { background: #f5f5f5; padding: 0.5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Suite}}</h1>
+<p>{{.Passed}} passed, {{.Failed}} failed, {{.Skipped}} skipped in {{.Duration}}</p>
+{{template "context" .Root}}
+</body>
+</html>
+{{define "context"}}
+{{range .Contexts}}
+<details class="ctx" {{if .Failed}}open{{end}}>
+<summary>{{.Name}} <span class="duration">({{.Passed}} passed, {{.Failed}} failed)</span></summary>
+{{template "context" .}}
+</details>
+{{end}}
+{{range .Cases}}
+<details class="case" {{if or (eq .State "failed") (eq .State "timeout") (eq .State "panicked")}}open{{end}}>
+<summary><span class="state {{.State}}">{{.State}}</span> {{.Name}} <span class="duration">{{.Duration}}</span></summary>
+{{if .Failure}}<pre class="failure">{{.Failure}}</pre>{{end}}
+<ol>
+{{range .Steps}}
+<li>
+<span {{if .Failure}}class="failed-step"{{end}}>{{.Text}}</span>
+{{range .Details}}<details><summary>request and response</summary><pre>{{.}}</pre></details>{{end}}
+{{if .Failure}}<pre class="failure">{{.Failure}}</pre>{{end}}
+</li>
+{{end}}
+</ol>
+</details>
+{{end}}
+{{end}}
+`))
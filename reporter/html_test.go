@@ -0,0 +1,111 @@
+package reporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// runHTMLReporter runs cases of products on a node
+// Names of cases are prefixed by node, and case create is failed
+func runHTMLReporter(r *HTMLReporter, c config.GinkgoConfigType, node string, runTime time.Duration) {
+	r.SpecSuiteWillBegin(c, &types.SuiteSummary{SuiteDescription: "Shop"})
+
+	r.SpecWillRun(&types.SpecSummary{})
+	r.Detail("POST /login token=secret")
+	r.Step("create product")
+	r.Detail("POST /products\n201 Created")
+	r.Step("get product")
+	r.Detail("GET /products/1\n404 Not Found")
+	r.SpecDidComplete(&types.SpecSummary{
+		ComponentTexts: []string{"[Top Level]", "Products", "Create", node + " create"},
+		State:          types.SpecStateFailed,
+		RunTime:        1500 * time.Millisecond,
+		Failure: types.SpecFailure{
+			Message:  "status code is not matched, expected: 200, actual: 404",
+			Location: types.CodeLocation{FileName: "framework.go", LineNumber: 10},
+		},
+	})
+
+	r.SpecWillRun(&types.SpecSummary{})
+	r.Step("list products")
+	r.SpecDidComplete(&types.SpecSummary{
+		ComponentTexts: []string{"[Top Level]", "Products", node + " list"},
+		State:          types.SpecStatePassed,
+		RunTime:        200 * time.Millisecond,
+	})
+
+	r.SpecDidComplete(&types.SpecSummary{
+		ComponentTexts: []string{"[Top Level]", "Orders", node + " delete"},
+		State:          types.SpecStateSkipped,
+	})
+	r.SpecSuiteDidEnd(&types.SuiteSummary{
+		NumberOfPassedSpecs:  1,
+		NumberOfFailedSpecs:  1,
+		NumberOfSkippedSpecs: 1,
+		RunTime:              runTime,
+	})
+}
+
+func maskSecret(text string) string {
+	return strings.Replace(text, "secret", "******", -1)
+}
+
+func TestHTMLReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe-html")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "report.html")
+
+	runHTMLReporter(NewHTMLReporter(filename, maskSecret), config.GinkgoConfigType{}, "node1", 2*time.Second)
+	assertGolden(t, "testdata/report.html", filename)
+}
+
+func TestHTMLReportParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe-html")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "report.html")
+
+	configOf := func(node int) config.GinkgoConfigType {
+		return config.GinkgoConfigType{
+			ParallelNode:  node,
+			ParallelTotal: 2,
+			SyncHost:      "http://127.0.0.1:8888",
+			RandomSeed:    1,
+		}
+	}
+	// the first node waits for other nodes
+	done := make(chan struct{})
+	go func() {
+		runHTMLReporter(NewHTMLReporter(filename, maskSecret), configOf(1), "node1", 2*time.Second)
+		close(done)
+	}()
+	runHTMLReporter(NewHTMLReporter(filename, maskSecret), configOf(2), "node2", 3*time.Second)
+	<-done
+
+	assertGolden(t, "testdata/report_parallel.html", filename)
+	// only merged report is left
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func assertGolden(t *testing.T, golden, filename string) {
+	expected, err := ioutil.ReadFile(golden)
+	if !assert.NoError(t, err) {
+		return
+	}
+	actual, err := ioutil.ReadFile(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, string(expected), string(actual))
+}
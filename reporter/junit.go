@@ -0,0 +1,193 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/onsi/ginkgo/types"
+)
+
+// JUnitTestSuite defines testsuite of junit xml
+type JUnitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase defines testcase of junit xml
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure defines failure of testcase
+type JUnitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:",chardata"`
+}
+
+// JUnitReporter writes junit xml report when suite is finished
+// Contexts of a case are joined by "." as classname, so hierarchy of
+// data dirs can be displayed
+type JUnitReporter struct {
+	filename string
+	mask     func(string) string
+	suite    JUnitTestSuite
+	config   config.GinkgoConfigType
+}
+
+var _ reporters.Reporter = &JUnitReporter{}
+
+// NewJUnitReporter returns a junit reporter which writes report to filename
+func NewJUnitReporter(filename string) *JUnitReporter {
+	return NewJUnitReporterWithMask(filename, func(s string) string {
+		return s
+	})
+}
+
+// NewJUnitReporterWithMask returns a junit reporter which masks
+// failure messages and outputs of cases, e.g. secret variables
+func NewJUnitReporterWithMask(filename string, mask func(string) string) *JUnitReporter {
+	return &JUnitReporter{
+		filename: filename,
+		mask:     mask,
+	}
+}
+
+// SpecSuiteWillBegin implements reporters.Reporter
+func (r *JUnitReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.suite = JUnitTestSuite{
+		Name:      summary.SuiteDescription,
+		TestCases: []JUnitTestCase{},
+	}
+	r.config = config
+}
+
+// BeforeSuiteDidRun implements reporters.Reporter
+func (r *JUnitReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.handleSetupSummary("BeforeSuite", setupSummary)
+}
+
+// SpecWillRun implements reporters.Reporter
+func (r *JUnitReporter) SpecWillRun(specSummary *types.SpecSummary) {
+}
+
+// SpecDidComplete implements reporters.Reporter
+func (r *JUnitReporter) SpecDidComplete(specSummary *types.SpecSummary) {
+	classname, name := r.names(specSummary.ComponentTexts)
+	tc := JUnitTestCase{
+		Name:      name,
+		ClassName: classname,
+		Time:      specSummary.RunTime.Seconds(),
+	}
+	switch specSummary.State {
+	case types.SpecStateFailed, types.SpecStateTimedOut, types.SpecStatePanicked:
+		tc.Failure = &JUnitFailure{
+			Type:    failureType(specSummary.State),
+			Message: r.mask(failureMessage(specSummary.Failure)),
+		}
+		tc.SystemOut = r.mask(specSummary.CapturedOutput)
+	case types.SpecStateSkipped, types.SpecStatePending:
+		tc.Skipped = &struct{}{}
+	}
+	r.suite.TestCases = append(r.suite.TestCases, tc)
+}
+
+// AfterSuiteDidRun implements reporters.Reporter
+func (r *JUnitReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.handleSetupSummary("AfterSuite", setupSummary)
+}
+
+// SpecSuiteDidEnd implements reporters.Reporter
+func (r *JUnitReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.suite.Tests = summary.NumberOfSpecsThatWillBeRun
+	r.suite.Failures = summary.NumberOfFailedSpecs
+	r.suite.Skipped = summary.NumberOfSkippedSpecs + summary.NumberOfPendingSpecs
+	r.suite.Time = summary.RunTime.Seconds()
+	filename := r.filename
+	// each parallel node writes its own report
+	if r.config.ParallelTotal > 1 {
+		ext := filepath.Ext(filename)
+		filename = fmt.Sprintf("%v_%v%v", strings.TrimSuffix(filename, ext), r.config.ParallelNode, ext)
+	}
+	if err := r.write(filename); err != nil {
+		fmt.Fprintf(os.Stderr, "can't write junit report %v: %v\n", filename, err)
+	}
+}
+
+func (r *JUnitReporter) write(filename string) error {
+	if dir := filepath.Dir(filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(f)
+	encoder.Indent("", "  ")
+	return encoder.Encode(r.suite)
+}
+
+func (r *JUnitReporter) handleSetupSummary(name string, setupSummary *types.SetupSummary) {
+	if setupSummary.State == types.SpecStatePassed {
+		return
+	}
+	r.suite.TestCases = append(r.suite.TestCases, JUnitTestCase{
+		Name:      name,
+		ClassName: r.suite.Name,
+		Time:      setupSummary.RunTime.Seconds(),
+		Failure: &JUnitFailure{
+			Type:    failureType(setupSummary.State),
+			Message: r.mask(failureMessage(setupSummary.Failure)),
+		},
+		SystemOut: r.mask(setupSummary.CapturedOutput),
+	})
+}
+
+// names returns classname and name of a case
+// The first component text is always top level of ginkgo
+func (r *JUnitReporter) names(texts []string) (string, string) {
+	if len(texts) < 2 {
+		return r.suite.Name, strings.Join(texts, " ")
+	}
+	contexts := texts[1 : len(texts)-1]
+	if len(contexts) == 0 {
+		return r.suite.Name, texts[len(texts)-1]
+	}
+	return strings.Join(contexts, "."), texts[len(texts)-1]
+}
+
+func failureType(state types.SpecState) string {
+	switch state {
+	case types.SpecStateFailed:
+		return "Failure"
+	case types.SpecStateTimedOut:
+		return "Timeout"
+	case types.SpecStatePanicked:
+		return "Panic"
+	}
+	return ""
+}
+
+func failureMessage(failure types.SpecFailure) string {
+	return fmt.Sprintf("%v\n%v\n%v", failure.ComponentCodeLocation.String(), failure.Message, failure.Location.String())
+}
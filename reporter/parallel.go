@@ -0,0 +1,115 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+)
+
+var (
+	// partialTimeout is timeout of waiting for results of other nodes
+	partialTimeout = time.Minute
+	// partialInterval is interval of checking results of other nodes
+	partialInterval = 100 * time.Millisecond
+)
+
+// partial is result of a parallel node which is merged by the first node
+type partial struct {
+	// Run identifies a parallel run, results of other runs are ignored
+	Run    string          `json:"run"`
+	Result json.RawMessage `json:"result"`
+}
+
+// runOf returns id of parallel run which is the same on all nodes
+func runOf(c config.GinkgoConfigType) string {
+	return fmt.Sprintf("%v/%v", c.SyncHost, c.RandomSeed)
+}
+
+// partialFilename returns file of result of node beside filename
+func partialFilename(filename string, node int) string {
+	dir, base := filepath.Split(filename)
+	return filepath.Join(dir, fmt.Sprintf(".%v.node%v", base, node))
+}
+
+// syncResult makes results of parallel nodes merged by the first node
+// Other nodes write result into partial files beside filename and return
+// false, the first node waits for them, decodes them by merge in order
+// of nodes and returns true. Results which are not written in time are
+// reported by error after others are merged
+func syncResult(filename string, c config.GinkgoConfigType, result interface{}, merge func(data []byte) error) (bool, error) {
+	if c.ParallelTotal <= 1 {
+		return true, nil
+	}
+	run := runOf(c)
+	if c.ParallelNode != 1 {
+		return false, writePartial(partialFilename(filename, c.ParallelNode), run, result)
+	}
+
+	missing := []string{}
+	for node := 2; node <= c.ParallelTotal; node++ {
+		name := partialFilename(filename, node)
+		p, err := waitPartial(name, run)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("node %v: %v", node, err))
+			continue
+		}
+		os.Remove(name)
+		if err := merge(p.Result); err != nil {
+			missing = append(missing, fmt.Sprintf("node %v: %v", node, err))
+		}
+	}
+	if len(missing) != 0 {
+		return true, fmt.Errorf("can't merge results of parallel nodes: %v", strings.Join(missing, "; "))
+	}
+	return true, nil
+}
+
+// writePartial writes result atomically so that it is never read partly
+func writePartial(filename, run string, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(partial{Run: run, Result: data})
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// waitPartial waits until result of run is written to filename
+func waitPartial(filename, run string) (*partial, error) {
+	deadline := time.Now().Add(partialTimeout)
+	for {
+		data, err := ioutil.ReadFile(filename)
+		if err == nil {
+			p := &partial{}
+			if err := json.Unmarshal(data, p); err != nil {
+				return nil, fmt.Errorf("can't decode %v: %v", filename, err)
+			}
+			// file may be left by a previous run
+			if p.Run == run {
+				return p, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%v isn't written in %v", filename, partialTimeout)
+		}
+		time.Sleep(partialInterval)
+	}
+}
@@ -0,0 +1,64 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe-partial")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "report")
+	defer func(timeout time.Duration) {
+		partialTimeout = timeout
+	}(partialTimeout)
+	partialTimeout = 300 * time.Millisecond
+
+	c := config.GinkgoConfigType{ParallelTotal: 3, SyncHost: "http://127.0.0.1:8888", RandomSeed: 1}
+	merge := func(results *[]int) func(data []byte) error {
+		return func(data []byte) error {
+			n := 0
+			if err := json.Unmarshal(data, &n); err != nil {
+				return err
+			}
+			*results = append(*results, n)
+			return nil
+		}
+	}
+
+	// result of node 3 is left by a previous run
+	previous := c
+	previous.RandomSeed = 2
+	previous.ParallelNode = 3
+	first, err := syncResult(filename, previous, 0, nil)
+	assert.NoError(t, err)
+	assert.False(t, first)
+
+	c.ParallelNode = 2
+	first, err = syncResult(filename, c, 2, nil)
+	assert.NoError(t, err)
+	assert.False(t, first)
+
+	results := []int{}
+	c.ParallelNode = 1
+	first, err = syncResult(filename, c, 1, merge(&results))
+	assert.True(t, first)
+	// results of other nodes are still merged
+	assert.Error(t, err)
+	assert.Equal(t, []int{2}, results)
+
+	// specs are not run in parallel
+	results = []int{}
+	first, err = syncResult(filename, config.GinkgoConfigType{ParallelTotal: 1}, 1, merge(&results))
+	assert.True(t, first)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
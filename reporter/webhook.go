@@ -0,0 +1,191 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/onsi/ginkgo/types"
+)
+
+const (
+	// DefaultWebhookText is default template of text in webhook summary
+	DefaultWebhookText = "{{.Suite}}: {{.Passed}} passed, {{.Failed}} failed, {{.Skipped}} skipped in {{.Duration}}" +
+		"{{range .FailedCases}}\n- {{.}}{{end}}"
+
+	// webhookTimeout is timeout of posting summary to webhook
+	webhookTimeout = 10 * time.Second
+)
+
+// WebhookSummary defines summary of suite which is posted to webhook
+// Text can be used by slack incoming webhooks
+type WebhookSummary struct {
+	Suite       string   `json:"suite"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	Skipped     int      `json:"skipped"`
+	Duration    string   `json:"duration"`
+	FailedCases []string `json:"failedCases,omitempty"`
+	Text        string   `json:"text"`
+}
+
+// WebhookReporter posts a json summary to webhook when suite is finished
+// Errors of posting are printed and don't fail the suite
+type WebhookReporter struct {
+	url     string
+	text    *template.Template
+	mask    func(string) string
+	client  *http.Client
+	summary WebhookSummary
+	config  config.GinkgoConfigType
+}
+
+var _ reporters.Reporter = &WebhookReporter{}
+
+// NewWebhookReporter returns a webhook reporter which posts summary to url
+// Text is a go template of summary, DefaultWebhookText is used if it is empty
+func NewWebhookReporter(url, text string, mask func(string) string) (*WebhookReporter, error) {
+	if text == "" {
+		text = DefaultWebhookText
+	}
+	t, err := template.New("webhook").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse text of webhook: %v", err)
+	}
+	if mask == nil {
+		mask = func(s string) string {
+			return s
+		}
+	}
+	return &WebhookReporter{
+		url:  url,
+		text: t,
+		mask: mask,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+		},
+	}, nil
+}
+
+// SpecSuiteWillBegin implements reporters.Reporter
+func (r *WebhookReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.summary = WebhookSummary{
+		Suite: summary.SuiteDescription,
+	}
+	r.config = config
+}
+
+// BeforeSuiteDidRun implements reporters.Reporter
+func (r *WebhookReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.handleSetupSummary("BeforeSuite", setupSummary)
+}
+
+// SpecWillRun implements reporters.Reporter
+func (r *WebhookReporter) SpecWillRun(specSummary *types.SpecSummary) {
+}
+
+// SpecDidComplete implements reporters.Reporter
+func (r *WebhookReporter) SpecDidComplete(specSummary *types.SpecSummary) {
+	switch specSummary.State {
+	case types.SpecStateFailed, types.SpecStateTimedOut, types.SpecStatePanicked:
+		texts := specSummary.ComponentTexts
+		if len(texts) > 1 {
+			// the first component text is always top level of ginkgo
+			texts = texts[1:]
+		}
+		r.summary.FailedCases = append(r.summary.FailedCases, r.mask(strings.Join(texts, " ")))
+	}
+}
+
+// AfterSuiteDidRun implements reporters.Reporter
+func (r *WebhookReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.handleSetupSummary("AfterSuite", setupSummary)
+}
+
+// SpecSuiteDidEnd implements reporters.Reporter
+func (r *WebhookReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.summary.Passed = summary.NumberOfPassedSpecs
+	r.summary.Failed = summary.NumberOfFailedSpecs
+	r.summary.Skipped = summary.NumberOfSkippedSpecs + summary.NumberOfPendingSpecs
+	r.summary.Duration = summary.RunTime.Round(time.Millisecond).String()
+	// summaries of parallel nodes are merged and posted by the first node
+	first, err := syncResult(webhookFilename(r.config), r.config, r.summary, r.merge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't merge summary of webhook: %v\n", err)
+	}
+	if !first {
+		return
+	}
+	if err := r.post(); err != nil {
+		fmt.Fprintf(os.Stderr, "can't post summary to webhook: %v\n", err)
+	}
+}
+
+// webhookFilename returns file in temp dir which is shared by nodes of run
+func webhookFilename(c config.GinkgoConfigType) string {
+	run := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '-'
+	}, runOf(c))
+	return filepath.Join(os.TempDir(), "aloe-webhook-"+run+".json")
+}
+
+// merge adds counts and failed cases in summary of another node
+// Nodes are run concurrently, so the longest duration is kept
+func (r *WebhookReporter) merge(data []byte) error {
+	summary := WebhookSummary{}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return fmt.Errorf("can't decode summary: %v", err)
+	}
+	r.summary.Passed += summary.Passed
+	r.summary.Failed += summary.Failed
+	r.summary.Skipped += summary.Skipped
+	r.summary.FailedCases = append(r.summary.FailedCases, summary.FailedCases...)
+	duration, err := time.ParseDuration(summary.Duration)
+	if err != nil {
+		return fmt.Errorf("can't parse duration of summary: %v", err)
+	}
+	if current, err := time.ParseDuration(r.summary.Duration); err != nil || duration > current {
+		r.summary.Duration = summary.Duration
+	}
+	return nil
+}
+
+func (r *WebhookReporter) post() error {
+	buf := bytes.Buffer{}
+	if err := r.text.Execute(&buf, r.summary); err != nil {
+		return fmt.Errorf("can't render text: %v", err)
+	}
+	r.summary.Text = r.mask(buf.String())
+	body, err := json.Marshal(r.summary)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Post(r.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+func (r *WebhookReporter) handleSetupSummary(name string, setupSummary *types.SetupSummary) {
+	if setupSummary.State == types.SpecStatePassed {
+		return
+	}
+	r.summary.FailedCases = append(r.summary.FailedCases, name)
+}
@@ -0,0 +1,113 @@
+package reporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookServer records summaries posted to it
+type webhookServer struct {
+	lock      sync.Mutex
+	summaries []WebhookSummary
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	summary := WebhookSummary{}
+	if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.summaries = append(s.summaries, summary)
+}
+
+// runWebhookReporter runs a suite with a failed spec on a node
+func runWebhookReporter(r *WebhookReporter, c config.GinkgoConfigType, failed string, passed int, runTime time.Duration) {
+	r.SpecSuiteWillBegin(c, &types.SuiteSummary{SuiteDescription: "Products"})
+	r.SpecDidComplete(&types.SpecSummary{
+		ComponentTexts: []string{"[Top Level]", "Products", failed},
+		State:          types.SpecStateFailed,
+	})
+	r.SpecSuiteDidEnd(&types.SuiteSummary{
+		NumberOfPassedSpecs:  passed,
+		NumberOfFailedSpecs:  1,
+		NumberOfSkippedSpecs: 1,
+		RunTime:              runTime,
+	})
+}
+
+func TestWebhookReporter(t *testing.T) {
+	s := &webhookServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	r, err := NewWebhookReporter(server.URL, "", func(text string) string {
+		return strings.Replace(text, "secret", "******", -1)
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	runWebhookReporter(r, config.GinkgoConfigType{}, "create with secret", 2, 1500*time.Millisecond)
+	assert.Equal(t, []WebhookSummary{{
+		Suite:       "Products",
+		Passed:      2,
+		Failed:      1,
+		Skipped:     1,
+		Duration:    "1.5s",
+		FailedCases: []string{"Products create with ******"},
+		Text:        "Products: 2 passed, 1 failed, 1 skipped in 1.5s\n- Products create with ******",
+	}}, s.summaries)
+}
+
+func TestWebhookReporterParallel(t *testing.T) {
+	s := &webhookServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	nodes := []*WebhookReporter{}
+	for node := 1; node <= 2; node++ {
+		r, err := NewWebhookReporter(server.URL, "{{.Passed}}/{{.Failed}}/{{.Skipped}} in {{.Duration}}", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		nodes = append(nodes, r)
+	}
+	configOf := func(node int) config.GinkgoConfigType {
+		return config.GinkgoConfigType{
+			ParallelNode:  node,
+			ParallelTotal: 2,
+			SyncHost:      server.URL,
+			RandomSeed:    1,
+		}
+	}
+
+	// the first node waits for other nodes
+	done := make(chan struct{})
+	go func() {
+		runWebhookReporter(nodes[0], configOf(1), "create", 2, time.Second)
+		close(done)
+	}()
+	runWebhookReporter(nodes[1], configOf(2), "delete", 3, 2*time.Second)
+	<-done
+
+	// only the first node posts merged summary
+	assert.Equal(t, []WebhookSummary{{
+		Suite:       "Products",
+		Passed:      5,
+		Failed:      2,
+		Skipped:     2,
+		Duration:    "2s",
+		FailedCases: []string{"Products create", "Products delete"},
+		Text:        "5/2/2 in 2s",
+	}}, s.summaries)
+}
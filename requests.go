@@ -0,0 +1,69 @@
+package framework
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+)
+
+// requestLog records method and path of requests sent in a case
+type requestLog struct {
+	lock     sync.Mutex
+	requests []string
+}
+
+// Record implements types.RequestRecorder
+func (l *requestLog) Record(req *http.Request) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.requests = append(l.requests, req.Method+" "+req.URL.Path)
+}
+
+// sent returns recorded requests
+func (l *requestLog) sent() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string{}, l.requests...)
+}
+
+// checkRequests returns error if sent requests are not expected
+// Sequence of requests is rendered by variables of case
+func checkRequests(expected *types.RequestsExpectation, sent []string, vs map[string]template.Variable) error {
+	if expected.Count != nil && *expected.Count != len(sent) {
+		return fmt.Errorf("expected %v requests, actual %v:%v", *expected.Count, len(sent), listRequests(sent))
+	}
+	if expected.Sequence == nil {
+		return nil
+	}
+	sequence := make([]string, 0, len(expected.Sequence))
+	for i := range expected.Sequence {
+		rendered, err := expected.Sequence[i].Render(vs)
+		if err != nil {
+			return fmt.Errorf("can't render request %v of sequence: %v", i+1, err)
+		}
+		if j := strings.IndexByte(rendered, '?'); j != -1 {
+			rendered = rendered[:j]
+		}
+		sequence = append(sequence, strings.Join(strings.Fields(rendered), " "))
+	}
+	matched := len(sequence) == len(sent)
+	for i := 0; matched && i < len(sent); i++ {
+		matched = sequence[i] == sent[i]
+	}
+	if !matched {
+		return fmt.Errorf("requests are not matched, expected:%v\nactual:%v", listRequests(sequence), listRequests(sent))
+	}
+	return nil
+}
+
+func listRequests(requests []string) string {
+	lines := ""
+	for i, r := range requests {
+		lines += fmt.Sprintf("\n\t%v. %v", i+1, r)
+	}
+	return lines
+}
@@ -0,0 +1,67 @@
+package framework
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRequests(t *testing.T) {
+	vs := map[string]template.Variable{
+		"productID": {Name: "productID", Raw: []byte("p1"), Type: template.StringType},
+	}
+	sent := []string{"POST /products", "GET /products/p1"}
+	cases := []struct {
+		description string
+		expected    string
+		err         string
+	}{
+		{"count is matched", `{"count": 2}`, ""},
+		{"sequence is matched", `{"sequence": ["POST /products", "GET /products/%{productID}"]}`, ""},
+		{"query and spaces are ignored", `{"count": 2, "sequence": ["POST  /products", "GET /products/%{productID}?fields=name"]}`, ""},
+		{
+			"count is not matched", `{"count": 1}`,
+			"expected 1 requests, actual 2:\n\t1. POST /products\n\t2. GET /products/p1",
+		},
+		{
+			"order is not matched", `{"sequence": ["GET /products/%{productID}", "POST /products"]}`,
+			"requests are not matched, expected:\n\t1. GET /products/p1\n\t2. POST /products\nactual:\n\t1. POST /products\n\t2. GET /products/p1",
+		},
+		{
+			"request is missing", `{"sequence": ["POST /products"]}`,
+			"requests are not matched, expected:\n\t1. POST /products\nactual:\n\t1. POST /products\n\t2. GET /products/p1",
+		},
+		{"unknown variable", `{"sequence": ["GET /products/%{orderID}"]}`, "can't render request 1 of sequence"},
+	}
+	for _, c := range cases {
+		expected := &types.RequestsExpectation{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.expected), expected), c.description) {
+			continue
+		}
+		err := checkRequests(expected, sent, vs)
+		if c.err == "" {
+			assert.NoError(t, err, c.description)
+			continue
+		}
+		if assert.Error(t, err, c.description) {
+			assert.Contains(t, err.Error(), c.err, c.description)
+		}
+	}
+}
+
+func TestRequestLog(t *testing.T) {
+	l := &requestLog{}
+	for _, raw := range []string{"http://localhost/products?page=1", "http://localhost/products/p1"} {
+		req, err := http.NewRequest("GET", raw, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		l.Record(req)
+	}
+	// query of requests is not recorded
+	assert.Equal(t, []string{"GET /products", "GET /products/p1"}, l.sent())
+}
@@ -0,0 +1,283 @@
+package roundtrip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// newBody returns body of request and its default content type
+// Content type is used if it is not set in headers
+func newBody(ctx *types.Context, reqConf *types.Request) (io.Reader, string, error) {
+	if reqConf.BodyFile != nil {
+		if reqConf.Body != nil || reqConf.Multipart != nil || reqConf.BodyType == types.GraphQLBody {
+			return nil, "", fmt.Errorf("bodyFile can't be used with body, multipart or graphql")
+		}
+		return newFileBody(ctx, reqConf)
+	}
+	if reqConf.Multipart != nil {
+		if reqConf.Body != nil {
+			return nil, "", fmt.Errorf("body and multipart can't be both set")
+		}
+		return newMultipartBody(ctx, reqConf.Multipart)
+	}
+	if reqConf.BodyType == types.GraphQLBody {
+		if reqConf.Body != nil {
+			return nil, "", fmt.Errorf("body can't be set for graphql, use graphql instead")
+		}
+		return newGraphQLBody(ctx, reqConf.GraphQL)
+	}
+	if reqConf.Body == nil {
+		return nil, "", nil
+	}
+	rendered, err := renderBody(ctx, reqConf.Body, reqConf.BodyType)
+	if err != nil {
+		return nil, "", err
+	}
+	return encodeBody(rendered, reqConf.BodyType)
+}
+
+// renderBody renders template of body, typed variables are only
+// unquoted in bodies of json, e.g. "%{count}" => 3
+func renderBody(ctx *types.Context, t *types.Template, bodyType types.BodyType) (string, error) {
+	switch bodyType {
+	case "", types.JSONBody, types.FormBody:
+		return t.RenderJSON(ctx.Variables)
+	}
+	return t.Render(ctx.Variables)
+}
+
+// encodeBody encodes rendered body by body type
+func encodeBody(rendered string, bodyType types.BodyType) (io.Reader, string, error) {
+	switch bodyType {
+	case "", types.JSONBody:
+		return bytes.NewBufferString(rendered), "application/json", nil
+	case types.FormBody:
+		return newFormBody(rendered)
+	case types.XMLBody:
+		return bytes.NewBufferString(rendered), "application/xml", nil
+	case types.BinaryBody:
+		data, err := decodeBase64(rendered)
+		if err != nil {
+			return nil, "", fmt.Errorf("binary body should be base64 encoded: %v", err)
+		}
+		return bytes.NewReader(data), "application/octet-stream", nil
+	}
+	return nil, "", fmt.Errorf("unknown body type %v", bodyType)
+}
+
+// decodeBase64 decodes base64 which may be wrapped into lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+// newFileBody reads body from file, content of file is rendered
+// as a template and then encoded by body type except binary
+func newFileBody(ctx *types.Context, reqConf *types.Request) (io.Reader, string, error) {
+	p, err := reqConf.BodyFile.Render(ctx.Variables)
+	if err != nil {
+		return nil, "", err
+	}
+	content, p, err := readFile(ctx, p)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't read body file: %v", err)
+	}
+	if reqConf.BodyType == types.BinaryBody {
+		return bytes.NewReader(content), "application/octet-stream", nil
+	}
+	t, err := types.NewTemplate(string(content))
+	if err != nil {
+		return nil, "", fmt.Errorf("can't parse body file %v: %v", p, err)
+	}
+	rendered, err := renderBody(ctx, t, reqConf.BodyType)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't render body file %v: %v", p, err)
+	}
+	return encodeBody(rendered, reqConf.BodyType)
+}
+
+// newGraphQLBody wraps query and variables into a graphql request
+func newGraphQLBody(ctx *types.Context, conf *types.GraphQL) (io.Reader, string, error) {
+	if conf == nil || conf.Query == nil {
+		return nil, "", fmt.Errorf("query of graphql should be set")
+	}
+	query, err := conf.Query.Render(ctx.Variables)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't render query of graphql: %v", err)
+	}
+	req := struct {
+		Query         string          `json:"query"`
+		Variables     json.RawMessage `json:"variables,omitempty"`
+		OperationName string          `json:"operationName,omitempty"`
+	}{
+		Query:         query,
+		OperationName: conf.OperationName,
+	}
+	if conf.Variables != nil {
+		rendered, err := conf.Variables.RenderJSON(ctx.Variables)
+		if err != nil {
+			return nil, "", fmt.Errorf("can't render variables of graphql: %v", err)
+		}
+		vs := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(rendered), &vs); err != nil {
+			return nil, "", fmt.Errorf("variables of graphql should be a json object: %v", err)
+		}
+		req.Variables = json.RawMessage(rendered)
+	}
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewBuffer(body), "application/json", nil
+}
+
+// newFormBody encodes a json object to form
+// Value of field can be string, number, boolean or an array of them
+func newFormBody(rendered string) (io.Reader, string, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(rendered), &fields); err != nil {
+		return nil, "", fmt.Errorf("form body should be a json object: %v", err)
+	}
+	form := url.Values{}
+	for k, v := range fields {
+		vs, ok := v.([]interface{})
+		if !ok {
+			vs = []interface{}{v}
+		}
+		for _, elem := range vs {
+			switch e := elem.(type) {
+			case string:
+				form.Add(k, e)
+			case float64:
+				form.Add(k, strconv.FormatFloat(e, 'f', -1, 64))
+			case bool:
+				form.Add(k, strconv.FormatBool(e))
+			default:
+				return nil, "", fmt.Errorf("unsupported type %T of form field %v", elem, k)
+			}
+		}
+	}
+	return strings.NewReader(form.Encode()), "application/x-www-form-urlencoded", nil
+}
+
+func newMultipartBody(ctx *types.Context, conf *types.Multipart) (io.Reader, string, error) {
+	buf := bytes.Buffer{}
+	w := multipart.NewWriter(&buf)
+
+	// sort fields to keep body stable
+	names := make([]string, 0, len(conf.Fields))
+	for name := range conf.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := conf.Fields[name]
+		value, err := renderTemplate(ctx, &field)
+		if err != nil {
+			return nil, "", fmt.Errorf("can't render field %v: %v", name, err)
+		}
+		if err := w.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for i := range conf.Files {
+		if err := writeFilePart(ctx, w, &conf.Files[i]); err != nil {
+			return nil, "", fmt.Errorf("can't write file %v: %v", conf.Files[i].Name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+func writeFilePart(ctx *types.Context, w *multipart.Writer, f *types.FilePart) error {
+	var content []byte
+	filename, err := renderTemplate(ctx, f.Filename)
+	if err != nil {
+		return err
+	}
+	switch {
+	case f.Path != nil && f.Content != nil:
+		return fmt.Errorf("path and content can't be both set")
+	case f.Path != nil:
+		path, err := renderTemplate(ctx, f.Path)
+		if err != nil {
+			return err
+		}
+		content, path, err = readFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		if filename == "" {
+			filename = filepath.Base(path)
+		}
+	default:
+		rendered, err := renderTemplate(ctx, f.Content)
+		if err != nil {
+			return err
+		}
+		content = []byte(rendered)
+	}
+
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(f.Name), escapeQuotes(filename)))
+	h.Set("Content-Type", contentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// renderTemplate renders template and returns empty string if template is nil
+func renderTemplate(ctx *types.Context, t *types.Template) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	return t.Render(ctx.Variables)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes is copied from mime/multipart
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// readFile reads file of round trip and returns its content and path
+// Relative path is relative to dir of test data, and file is read
+// from file system of test data if it is set
+func readFile(ctx *types.Context, p string) ([]byte, string, error) {
+	if ctx.FS != nil && !path.IsAbs(p) {
+		p = path.Join(ctx.Dir, p)
+		content, err := fs.ReadFile(ctx.FS, p)
+		return content, p, err
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(ctx.Dir, p)
+	}
+	content, err := ioutil.ReadFile(p)
+	return content, p, err
+}
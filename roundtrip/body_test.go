@@ -0,0 +1,101 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+)
+
+func TestNewGraphQLBody(t *testing.T) {
+	ctx := &types.Context{
+		Variables: map[string]template.Variable{
+			"id": {Raw: []byte("p1"), Name: "id", Type: template.StringType},
+		},
+	}
+	cases := []struct {
+		conf     string
+		expected string
+		hasError bool
+	}{
+		{
+			`{"query": "{ products { id } }"}`,
+			`{"query":"{ products { id } }"}`, false,
+		},
+		{
+			`{"query": "query($id: ID!) { product(id: $id) { id } }", "variables": {"id": "%{id}"}, "operationName": "get"}`,
+			`{"query":"query($id: ID!) { product(id: $id) { id } }","variables":{"id":"p1"},"operationName":"get"}`, false,
+		},
+		{`{"variables": {"id": "%{id}"}}`, "", true},
+		{`{"query": "{ products { id } }", "variables": ["%{id}"]}`, "", true},
+	}
+	for _, c := range cases {
+		conf := types.GraphQL{}
+		if err := json.Unmarshal([]byte(c.conf), &conf); err != nil {
+			t.Fatalf("can't parse %v: %v", c.conf, err)
+		}
+		body, contentType, err := newGraphQLBody(ctx, &conf)
+		if c.hasError {
+			assert.Error(t, err, c.conf)
+			continue
+		}
+		assert.NoError(t, err, c.conf)
+		assert.Equal(t, "application/json", contentType)
+		b, _ := ioutil.ReadAll(body)
+		assert.Equal(t, c.expected, string(b))
+	}
+}
+
+func TestNewBody(t *testing.T) {
+	ctx := &types.Context{
+		Dir: "cases",
+		FS: fstest.MapFS{
+			"cases/user.json": {Data: []byte(`{"name": "%{name}"}`)},
+			"cases/logo.png":  {Data: []byte("\x89PNG%{")},
+		},
+		Variables: map[string]template.Variable{
+			"name":  {Raw: []byte("alice"), Name: "name", Type: template.StringType},
+			"count": {Raw: []byte("3"), Name: "count", Type: template.NumberType, Typed: true},
+		},
+	}
+	cases := []struct {
+		conf        string
+		expected    string
+		contentType string
+		hasError    bool
+	}{
+		{`{"bodyFile": "user.json"}`, `{"name": "alice"}`, "application/json", false},
+		{`{"body": {"name": "%{name}"}, "bodyType": "json"}`, `{"name": "alice"}`, "application/json", false},
+		{`{"body": "<name>%{name}</name>", "bodyType": "xml"}`, `<name>alice</name>`, "application/xml", false},
+		{`{"bodyFile": "user.json", "bodyType": "form"}`, `name=alice`, "application/x-www-form-urlencoded", false},
+		{`{"bodyFile": "logo.png", "bodyType": "binary"}`, "\x89PNG%{", "application/octet-stream", false},
+		{`{"bodyFile": "logo.png"}`, "", "", true},
+		{`{"bodyFile": "missing.json"}`, "", "", true},
+		{`{"bodyFile": "user.json", "body": {}}`, "", "", true},
+		{`{"body": "iVBO\nRw==", "bodyType": "binary"}`, "\x89PNG", "application/octet-stream", false},
+		{`{"body": "%{name}", "bodyType": "binary"}`, "", "", true},
+		// typed variables are only unquoted in json
+		{`{"body": {"count": "%{count}"}}`, `{"count": 3}`, "application/json", false},
+		{`{"body": "<count a=\"%{count}\"/>", "bodyType": "xml"}`, `<count a="3"/>`, "application/xml", false},
+	}
+	for _, c := range cases {
+		conf := types.Request{}
+		if err := json.Unmarshal([]byte(c.conf), &conf); err != nil {
+			t.Fatalf("can't parse %v: %v", c.conf, err)
+		}
+		body, contentType, err := newBody(ctx, &conf)
+		if c.hasError {
+			assert.Error(t, err, c.conf)
+			continue
+		}
+		assert.NoError(t, err, c.conf)
+		assert.Equal(t, c.contentType, contentType)
+		b, _ := ioutil.ReadAll(body)
+		assert.Equal(t, c.expected, string(b))
+	}
+}
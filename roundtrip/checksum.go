@@ -0,0 +1,83 @@
+package roundtrip
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// hashes are supported algorithms of checksum
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// checksum defines expected checksum of body
+type checksum struct {
+	algorithm string
+	sum       []byte
+}
+
+// parseChecksum parses checksum like sha256:9f86d08...
+func parseChecksum(s string) (*checksum, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("checksum %v should be algorithm:hex", s)
+	}
+	algorithm := strings.ToLower(parts[0])
+	if _, ok := hashes[algorithm]; !ok {
+		return nil, fmt.Errorf("unknown algorithm %v of checksum", parts[0])
+	}
+	sum, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("checksum %v should be hex encoded: %v", s, err)
+	}
+	return &checksum{
+		algorithm: algorithm,
+		sum:       sum,
+	}, nil
+}
+
+// sumOf returns checksum of data by algorithm
+func sumOf(algorithm string, data []byte) []byte {
+	h := hashes[algorithm]()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// match returns error if checksum of body is not matched
+func (c *checksum) match(body []byte) error {
+	return c.matchSum(sumOf(c.algorithm, body))
+}
+
+// hash returns a new hash of algorithm of checksum
+func (c *checksum) hash() hash.Hash {
+	return hashes[c.algorithm]()
+}
+
+// matchSum returns error if sum of body is not matched
+func (c *checksum) matchSum(actual []byte) error {
+	if bytes.Equal(actual, c.sum) {
+		return nil
+	}
+	return fmt.Errorf("checksum of body is not matched, expected: %v:%x, actual: %v:%x",
+		c.algorithm, c.sum, c.algorithm, actual)
+}
+
+// matchBytes returns error if body is not same as expected bytes
+// Sizes and checksums are reported instead of bytes
+func matchBytes(expected, body []byte) error {
+	if bytes.Equal(expected, body) {
+		return nil
+	}
+	return fmt.Errorf("binary body is not matched, expected: %v bytes (sha256:%x), actual: %v bytes (sha256:%x)",
+		len(expected), sumOf("sha256", expected), len(body), sumOf("sha256", body))
+}
@@ -0,0 +1,45 @@
+package roundtrip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	cases := []struct {
+		checksum string
+		body     string
+		matched  bool
+		hasError bool
+	}{
+		{"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "test", true, false},
+		{"SHA256:9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08", "test", true, false},
+		{"md5:098f6bcd4621d373cade4e832627b4f6", "test", true, false},
+		{"sha1:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", "test", true, false},
+		{"md5:098f6bcd4621d373cade4e832627b4f6", "tests", false, false},
+		{"crc32:d87f7e0c", "test", false, true},
+		{"sha256:xyz", "test", false, true},
+		{"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "test", false, true},
+	}
+	for _, c := range cases {
+		cs, err := parseChecksum(c.checksum)
+		if c.hasError {
+			assert.Error(t, err, c.checksum)
+			continue
+		}
+		assert.NoError(t, err, c.checksum)
+		if c.matched {
+			assert.NoError(t, cs.match([]byte(c.body)), c.checksum)
+		} else {
+			assert.Error(t, cs.match([]byte(c.body)), c.checksum)
+		}
+	}
+}
+
+func TestMatchBytes(t *testing.T) {
+	assert.NoError(t, matchBytes([]byte{0x89, 0x50}, []byte{0x89, 0x50}))
+	assert.EqualError(t, matchBytes([]byte("test"), []byte{}),
+		"binary body is not matched, expected: 4 bytes (sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08), "+
+			"actual: 0 bytes (sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855)")
+}
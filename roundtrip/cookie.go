@@ -0,0 +1,127 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caicloud/aloe/matcher"
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/gomega"
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+// cookieMatcher matches a cookie set by response
+type cookieMatcher struct {
+	name string
+
+	// attributes means matcher matches attributes of cookie,
+	// otherwise it only matches value of cookie
+	attributes bool
+
+	m gomegatypes.GomegaMatcher
+}
+
+// newCookieMatchers returns matchers of cookies sorted by name
+func newCookieMatchers(ctx *types.Context, cookies map[string]types.Template) ([]cookieMatcher, error) {
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ms := []cookieMatcher{}
+	for _, name := range names {
+		t := cookies[name]
+		rendered, err := t.Render(ctx.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("can't render matcher of cookie %v: %v", name, err)
+		}
+		cm := cookieMatcher{name: name}
+		obj := map[string]interface{}{}
+		switch {
+		case json.Unmarshal([]byte(rendered), &obj) != nil:
+			// other values are matched with value of cookie literally
+			cm.m = gomega.Equal(rendered)
+		case isSpecial(obj):
+			cm.m, err = matcher.ParseValue(rendered)
+		default:
+			cm.attributes = true
+			cm.m, err = matcher.Parse(rendered)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't parse matcher of cookie %v: %v", name, err)
+		}
+		ms = append(ms, cm)
+	}
+	return ms, nil
+}
+
+// isSpecial returns true if all keys of object are special matchers
+// e.g. {"$regexp": "^[0-9a-f]+$"}
+func isSpecial(obj map[string]interface{}) bool {
+	for k := range obj {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return len(obj) != 0
+}
+
+// match returns error if cookie is not set or not matched
+func (cm *cookieMatcher) match(resp *http.Response) error {
+	c := findCookie(resp, cm.name)
+	if c == nil {
+		return fmt.Errorf("cookie %v is not set by response", cm.name)
+	}
+	var actual interface{} = c.Value
+	if cm.attributes {
+		actual = cookieAttributes(c)
+	}
+	matched, err := cm.m.Match(actual)
+	if err != nil {
+		return fmt.Errorf("can't match cookie %v: %v", cm.name, err)
+	}
+	if !matched {
+		return fmt.Errorf("cookie %v is not matched: \n%v", cm.name, cm.m.FailureMessage(actual))
+	}
+	return nil
+}
+
+// findCookie returns the last cookie with name set by response
+func findCookie(resp *http.Response, name string) *http.Cookie {
+	var found *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == name {
+			found = c
+		}
+	}
+	return found
+}
+
+// cookieAttributes returns attributes of cookie in json types
+// maxAge is 0 if it is not set and -1 if cookie is deleted
+func cookieAttributes(c *http.Cookie) map[string]interface{} {
+	attrs := map[string]interface{}{
+		"value":    c.Value,
+		"path":     c.Path,
+		"domain":   c.Domain,
+		"maxAge":   float64(c.MaxAge),
+		"secure":   c.Secure,
+		"httpOnly": c.HttpOnly,
+	}
+	if !c.Expires.IsZero() {
+		attrs["expires"] = c.Expires.UTC().Format(time.RFC3339)
+	}
+	switch c.SameSite {
+	case http.SameSiteLaxMode:
+		attrs["sameSite"] = "Lax"
+	case http.SameSiteStrictMode:
+		attrs["sameSite"] = "Strict"
+	case http.SameSiteNoneMode:
+		attrs["sameSite"] = "None"
+	}
+	return attrs
+}
@@ -0,0 +1,51 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caicloud/aloe/types"
+)
+
+func TestCookieMatchers(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{
+			"Set-Cookie": []string{
+				"session=abc123; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Strict",
+				"csrf=token; Path=/api",
+			},
+		},
+	}
+	cases := []struct {
+		cookies string
+		matched bool
+	}{
+		{`{"session": "abc123"}`, true},
+		{`{"session": "abc"}`, false},
+		{`{"session": {"$regexp": "^abc[0-9]+$"}}`, true},
+		{`{"session": {"value": "abc123", "path": "/", "httpOnly": true, "secure": true, "sameSite": "Strict", "maxAge": {"$gt": 0}}}`, true},
+		{`{"session": {"httpOnly": false}}`, false},
+		{`{"csrf": {"path": "/api", "secure": false}}`, true},
+		{`{"missing": "x"}`, false},
+	}
+	for _, c := range cases {
+		conf := map[string]types.Template{}
+		if err := json.Unmarshal([]byte(c.cookies), &conf); err != nil {
+			t.Fatalf("can't parse %v: %v", c.cookies, err)
+		}
+		ms, err := newCookieMatchers(&types.Context{}, conf)
+		if !assert.NoError(t, err, c.cookies) {
+			continue
+		}
+		var matchErr error
+		for i := range ms {
+			if err := ms[i].match(resp); err != nil {
+				matchErr = err
+			}
+		}
+		assert.Equal(t, c.matched, matchErr == nil, "%v: %v", c.cookies, matchErr)
+	}
+}
@@ -0,0 +1,60 @@
+package roundtrip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// redactedValue replaces values of redacted headers
+	redactedValue = "<redacted>"
+)
+
+// Curl returns an equivalent curl command of request
+// Values of redacted headers are replaced
+func Curl(req *http.Request, redactedHeaders ...string) (string, error) {
+	redacted := map[string]bool{}
+	for _, h := range redactedHeaders {
+		redacted[http.CanonicalHeaderKey(h)] = true
+	}
+
+	args := []string{"curl", "-X", req.Method, shellQuote(req.URL.String())}
+
+	names := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		for _, v := range req.Header[k] {
+			if redacted[http.CanonicalHeaderKey(k)] {
+				v = redactedValue
+			}
+			args = append(args, "-H", shellQuote(k+": "+v))
+		}
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("can't get body of request: %v", err)
+		}
+		defer body.Close()
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return "", fmt.Errorf("can't read body of request: %v", err)
+		}
+		if len(data) != 0 {
+			args = append(args, "--data-binary", shellQuote(string(data)))
+		}
+	}
+	return strings.Join(args, " "), nil
+}
+
+// shellQuote quotes s by single quotes
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,46 @@
+package roundtrip
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurl(t *testing.T) {
+	cases := []struct {
+		method   string
+		url      string
+		headers  map[string]string
+		body     string
+		redacted []string
+		expected string
+	}{
+		{
+			"GET", "http://localhost:8080/products?a=1", nil, "", nil,
+			`curl -X GET 'http://localhost:8080/products?a=1'`,
+		},
+		{
+			"POST", "http://localhost:8080/products",
+			map[string]string{"Content-Type": "application/json", "Authorization": "Bearer xxx"},
+			`{"title": "it's"}`, []string{"authorization"},
+			`curl -X POST 'http://localhost:8080/products' -H 'Authorization: <redacted>' -H 'Content-Type: application/json' --data-binary '{"title": "it'\''s"}'`,
+		},
+		{
+			"DELETE", "http://localhost:8080/products/1",
+			map[string]string{"Authorization": "Bearer xxx"}, "", nil,
+			`curl -X DELETE 'http://localhost:8080/products/1' -H 'Authorization: Bearer xxx'`,
+		},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, c.url, strings.NewReader(c.body))
+		assert.NoError(t, err)
+		for k, v := range c.headers {
+			req.Header.Set(k, v)
+		}
+		cmd, err := Curl(req, c.redacted...)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, cmd)
+	}
+}
@@ -0,0 +1,74 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sync"
+
+	"github.com/ghodss/yaml"
+)
+
+// Decoder decodes body of response into a value which can be
+// marshaled to json, e.g. map[string]interface{}
+// Decoded value is matched and used to define variables as json
+type Decoder func(body []byte) (interface{}, error)
+
+var (
+	decodersLock sync.RWMutex
+	decoders     = map[string]Decoder{}
+)
+
+func init() {
+	for _, ct := range []string{"application/yaml", "application/x-yaml", "text/yaml"} {
+		decoders[ct] = decodeYAML
+	}
+}
+
+// RegisterDecoder registers a decoder of responses with content type
+// e.g. text/csv. Responses of unknown content types are decoded as json
+func RegisterDecoder(contentType string, d Decoder) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %v: %v", contentType, err)
+	}
+	decodersLock.Lock()
+	defer decodersLock.Unlock()
+	if _, ok := decoders[mediaType]; ok {
+		return fmt.Errorf("decoder of %v has been registered", mediaType)
+	}
+	decoders[mediaType] = d
+	return nil
+}
+
+// decode converts body of response to json by decoder of content type
+// Body is returned as it is if there is no decoder of content type
+func decode(contentType string, body []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return body, nil
+	}
+	decodersLock.RLock()
+	d, ok := decoders[mediaType]
+	decodersLock.RUnlock()
+	if !ok {
+		return body, nil
+	}
+	v, err := d(body)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode body of %v: %v", mediaType, err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode body of %v: %v", mediaType, err)
+	}
+	return b, nil
+}
+
+func decodeYAML(body []byte) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,54 @@
+package roundtrip
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	assert.NoError(t, RegisterDecoder("text/csv; charset=utf-8", func(body []byte) (interface{}, error) {
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		if len(lines) < 1 {
+			return nil, fmt.Errorf("no header")
+		}
+		header := strings.Split(lines[0], ",")
+		rows := []map[string]string{}
+		for _, line := range lines[1:] {
+			row := map[string]string{}
+			for i, v := range strings.Split(line, ",") {
+				row[header[i]] = v
+			}
+			rows = append(rows, row)
+		}
+		return map[string]interface{}{"rows": rows}, nil
+	}))
+	assert.Error(t, RegisterDecoder("text/csv", nil), "duplicated decoder")
+	assert.Error(t, RegisterDecoder("", nil), "invalid content type")
+
+	cases := []struct {
+		contentType string
+		body        string
+		out         string
+		hasError    bool
+	}{
+		{"application/json", `{"a": 1}`, `{"a": 1}`, false},
+		{"", `{"a": 1}`, `{"a": 1}`, false},
+		{"application/yaml", "a: 1\nb: [x]\n", `{"a":1,"b":["x"]}`, false},
+		{"TEXT/YAML; charset=utf-8", "a: 1", `{"a":1}`, false},
+		{"application/yaml", "a: [", "", true},
+		{"text/csv", "id,name\n1,apple\n", `{"rows":[{"id":"1","name":"apple"}]}`, false},
+	}
+	for _, c := range cases {
+		out, err := decode(c.contentType, []byte(c.body))
+		if c.hasError {
+			assert.Error(t, err, c.contentType)
+			continue
+		}
+		if assert.NoError(t, err, c.contentType) {
+			assert.Equal(t, c.out, string(out), c.contentType)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package roundtrip
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DumpRequest returns method, url, headers and body of request
+// Values of redacted headers are replaced
+func DumpRequest(req *http.Request, redactedHeaders ...string) (string, error) {
+	out := fmt.Sprintf("%v %v\n", req.Method, req.URL)
+	out += dumpHeader(req.Header, redactedHeaders)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("can't get body of request: %v", err)
+		}
+		defer body.Close()
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return "", fmt.Errorf("can't read body of request: %v", err)
+		}
+		out += dumpBody(data)
+	}
+	return out, nil
+}
+
+// DumpResponse returns status, headers and body of response
+// Body of response is read and replaced, so it can still be read
+func DumpResponse(resp *http.Response) (string, error) {
+	out := DumpResponseHeader(resp)
+	if resp.Body != nil {
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("can't read body of response: %v", err)
+		}
+		body := ioutil.NopCloser(bytes.NewReader(data))
+		// duration of response is kept
+		if tb, ok := resp.Body.(*timedBody); ok {
+			tb.ReadCloser = body
+		} else {
+			resp.Body = body
+		}
+		out += dumpBody(data)
+	}
+	return out, nil
+}
+
+// DumpResponseHeader returns status and headers of response
+// Body of response is not read, e.g. a streamed body
+func DumpResponseHeader(resp *http.Response) string {
+	out := fmt.Sprintf("%v %v", resp.Proto, resp.Status)
+	if d, ok := Duration(resp); ok {
+		out += fmt.Sprintf(" (%v)", d)
+	}
+	return out + "\n" + dumpHeader(resp.Header, nil)
+}
+
+func dumpHeader(header http.Header, redactedHeaders []string) string {
+	redacted := map[string]bool{}
+	for _, h := range redactedHeaders {
+		redacted[http.CanonicalHeaderKey(h)] = true
+	}
+	names := make([]string, 0, len(header))
+	for k := range header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	out := ""
+	for _, k := range names {
+		for _, v := range header[k] {
+			if redacted[http.CanonicalHeaderKey(k)] {
+				v = redactedValue
+			}
+			out += k + ": " + v + "\n"
+		}
+	}
+	return out
+}
+
+func dumpBody(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	return "\n" + strings.TrimRight(string(data), "\n") + "\n"
+}
@@ -0,0 +1,46 @@
+package roundtrip
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpRequest(t *testing.T) {
+	req, err := http.NewRequest("POST", "http://localhost/products?a=1", bytes.NewReader([]byte(`{"name": "apple"}`)))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer t")
+	out, err := DumpRequest(req, "authorization")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST http://localhost/products?a=1\n"+
+		"Authorization: <redacted>\n"+
+		"Content-Type: application/json\n"+
+		"\n"+
+		`{"name": "apple"}`+"\n", out)
+}
+
+func TestDumpResponse(t *testing.T) {
+	resp := &http.Response{
+		Proto:  "HTTP/1.1",
+		Status: "201 Created",
+		Header: http.Header{"Location": []string{"/products/1"}},
+		Body: &timedBody{
+			ReadCloser: ioutil.NopCloser(bytes.NewReader([]byte("{}\n"))),
+			duration:   1000,
+		},
+	}
+	out, err := DumpResponse(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 201 Created (1µs)\nLocation: /products/1\n\n{}\n", out)
+
+	// body can still be read
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n", string(body))
+	_, ok := Duration(resp)
+	assert.True(t, ok)
+}
@@ -0,0 +1,89 @@
+package roundtrip
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// compress encodes body by content encoding of request
+func compress(body io.Reader, encoding types.ContentEncoding) (io.Reader, error) {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case types.GzipEncoding:
+		w = gzip.NewWriter(buf)
+	case types.DeflateEncoding:
+		w = zlib.NewWriter(buf)
+	default:
+		return nil, fmt.Errorf("unknown content encoding %v", encoding)
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// decompress decodes body of response by its content encoding,
+// so that decoded body is matched
+func decompress(resp *http.Response) error {
+	var r io.Reader
+	var err error
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(resp.Body)
+		if err == io.EOF {
+			// empty body, e.g. response of HEAD
+			return nil
+		}
+	case "deflate":
+		r, err = newDeflateReader(resp.Body)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't decode %v body of response: %v", encoding, err)
+	}
+	resp.Body = &decodedBody{
+		Reader: r,
+		Closer: resp.Body,
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// newDeflateReader returns reader of zlib or raw deflate stream
+// Deflate in http should be zlib, but some servers send raw deflate
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(body)
+	header, _ := br.Peek(2)
+	if len(header) == 0 {
+		// empty body
+		return br, nil
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodedBody reads decoded body and closes original body
+type decodedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,56 @@
+package roundtrip
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caicloud/aloe/types"
+)
+
+func TestDecompress(t *testing.T) {
+	body := `{"id": "1"}`
+	rawDeflate := &bytes.Buffer{}
+	w, _ := flate.NewWriter(rawDeflate, flate.DefaultCompression)
+	w.Write([]byte(body))
+	w.Close()
+	compressed := func(encoding types.ContentEncoding) []byte {
+		r, err := compress(strings.NewReader(body), encoding)
+		if err != nil {
+			t.Fatalf("can't compress body by %v: %v", encoding, err)
+		}
+		b, _ := ioutil.ReadAll(r)
+		return b
+	}
+	cases := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"", []byte(body)},
+		{"gzip", compressed(types.GzipEncoding)},
+		{"deflate", compressed(types.DeflateEncoding)},
+		{"deflate", rawDeflate.Bytes()},
+	}
+	for _, c := range cases {
+		resp := &http.Response{
+			Header: http.Header{},
+			Body:   ioutil.NopCloser(bytes.NewReader(c.body)),
+		}
+		if c.encoding != "" {
+			resp.Header.Set("Content-Encoding", c.encoding)
+		}
+		assert.NoError(t, decompress(resp), c.encoding)
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err, c.encoding)
+		assert.Equal(t, body, string(b), c.encoding)
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	}
+
+	_, err := compress(strings.NewReader(body), "br")
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package roundtrip
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/indent"
+	"github.com/caicloud/aloe/utils/jsonutil"
+	errorsutil "github.com/onsi/gomega/gstruct/errors"
+)
+
+// maxFailureLength is max length of messages and values in failures
+// which are not rendered fully
+const maxFailureLength = 512
+
+// bodyFailure returns error of mismatched body by failure verbosity
+// Mismatches of fields are rendered without full body unless
+// failures are full
+func (m *ResponseMatcher) bodyFailure(actual map[string]interface{}) error {
+	nesting, ok := m.bodyMatcher.(errorsutil.NestingMatcher)
+	if m.verbosity == types.FullFailures || !ok {
+		return errors.New(indent.Indent(m.truncate(m.bodyMatcher.FailureMessage(actual)), "\t"))
+	}
+	lines := []string{}
+	for _, f := range flattenFailures(nesting.Failures()) {
+		lines = append(lines, m.fieldFailure(f, actual))
+	}
+	return errors.New(indent.Indent(strings.Join(lines, "\n"), "\t"))
+}
+
+// fieldFailure returns failure of a mismatched field with its jsonpath
+// Expected and actual values are rendered in paths failures
+func (m *ResponseMatcher) fieldFailure(f error, actual map[string]interface{}) string {
+	ne, ok := f.(*errorsutil.NestedError)
+	if !ok {
+		return m.truncate(f.Error())
+	}
+	path := "$" + ne.Path
+	expected, found := selectValue(m.expectedBody, path)
+	if m.verbosity != types.PathFailures || !found {
+		return fmt.Sprintf("%v: %v", path, m.truncate(ne.Err.Error()))
+	}
+	value, found := selectValue(actual, path)
+	if !found {
+		value = "<missing>"
+	}
+	return fmt.Sprintf("%v: expected %v, actual %v", path, expected, value)
+}
+
+// flattenFailures returns failures of fields in aggregated failures
+func flattenFailures(failures []error) []error {
+	flattened := []error{}
+	for _, f := range failures {
+		if ag, ok := f.(errorsutil.AggregateError); ok {
+			flattened = append(flattened, flattenFailures(ag)...)
+			continue
+		}
+		flattened = append(flattened, f)
+	}
+	return flattened
+}
+
+// selectValue returns truncated json of the only value selected by path
+func selectValue(v interface{}, path string) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	selected, err := jsonutil.SelectByJSONPath(v, path)
+	if err != nil || len(selected) != 1 {
+		return "", false
+	}
+	buf := bytes.Buffer{}
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(selected[0]); err != nil {
+		return "", false
+	}
+	return truncate(strings.TrimSpace(buf.String())), true
+}
+
+// truncate truncates message unless failures are full
+func (m *ResponseMatcher) truncate(msg string) string {
+	if m.verbosity == types.FullFailures {
+		return msg
+	}
+	return truncate(msg)
+}
+
+// truncate returns message whose length is at most maxFailureLength
+// and length of the truncated part is appended
+func truncate(msg string) string {
+	if len(msg) <= maxFailureLength {
+		return msg
+	}
+	n := maxFailureLength
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return fmt.Sprintf("%v... (%v more bytes)", msg[:n], len(msg)-n)
+}
@@ -0,0 +1,65 @@
+package roundtrip
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyFailure(t *testing.T) {
+	expected := `{"name": "a", "items": [{"id": 1}, {"id": 2}], "extra": {"$exists": false}}`
+	actual := `{"name": "b", "items": [{"id": 1}, {"id": 3}], "extra": "x", "description": "` + strings.Repeat("x", 1000) + `"}`
+	cases := []struct {
+		verbosity types.FailureVerbosity
+		contains  []string
+		excludes  []string
+	}{
+		{types.FullFailures, []string{`"description"`, `<string>: b`}, nil},
+		{types.TruncatedFailures, []string{"$.name: Expected", "$.items[1].id: Expected"}, []string{`"description"`}},
+		{types.PathFailures, []string{
+			`$.name: expected "a", actual "b"`,
+			`$.items[1].id: expected 2, actual 3`,
+			`$.extra: expected {"$exists":false}, actual "x"`,
+		}, []string{`"description"`, "to equal"}},
+	}
+	for _, c := range cases {
+		body, err := types.NewTemplate(expected)
+		assert.NoError(t, err)
+		rt := &types.RoundTrip{
+			Response: types.Response{
+				StatusCode: types.NewStatusCode(http.StatusOK),
+				Body:       body,
+			},
+		}
+		m, err := MatchResponse(&types.Context{FailureVerbosity: c.verbosity}, rt)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(actual))),
+		}
+		matched, err := m.Match(resp)
+		assert.NoError(t, err)
+		assert.False(t, matched)
+		msg := m.FailureMessage(resp)
+		for _, s := range c.contains {
+			assert.Contains(t, msg, s, "verbosity %v", c.verbosity)
+		}
+		for _, s := range c.excludes {
+			assert.NotContains(t, msg, s, "verbosity %v", c.verbosity)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc"))
+	long := strings.Repeat("a", maxFailureLength-1) + "世界"
+	assert.Equal(t, strings.Repeat("a", maxFailureLength-1)+"... (6 more bytes)", truncate(long))
+}
@@ -0,0 +1,119 @@
+package roundtrip
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/jsonutil"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// golden defines a golden file of response body
+type golden struct {
+	// path is path of golden file
+	path string
+
+	// expected is content of golden file, it is nil in update mode
+	expected []byte
+
+	// update means golden file is written by body instead of matched
+	update bool
+}
+
+// newGolden returns golden file at path which is relative to dir of context
+// Golden file is always written on disk in update mode
+func newGolden(ctx *types.Context, p string) (*golden, error) {
+	if ctx.UpdateGolden {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(ctx.Dir, p)
+		}
+		return &golden{
+			path:   p,
+			update: true,
+		}, nil
+	}
+	content, path, err := readFile(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("can't read golden file %v, it can be written in update golden mode: %v", p, err)
+	}
+	return &golden{
+		path:     path,
+		expected: content,
+	}, nil
+}
+
+// match writes body into golden file in update mode, or returns
+// error with unified diff if body is not same as golden file
+func (g *golden) match(body []byte, ignored []string) error {
+	actual, err := normalizeGolden(body, ignored)
+	if err != nil {
+		return err
+	}
+	if g.update {
+		if err := os.MkdirAll(filepath.Dir(g.path), 0755); err != nil {
+			return fmt.Errorf("can't create dir of golden file: %v", err)
+		}
+		if err := ioutil.WriteFile(g.path, actual, 0644); err != nil {
+			return fmt.Errorf("can't write golden file %v: %v", g.path, err)
+		}
+		return nil
+	}
+	expected, err := normalizeGolden(g.expected, ignored)
+	if err != nil {
+		return fmt.Errorf("can't read golden file %v: %v", g.path, err)
+	}
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(expected),
+		B:        splitLines(actual),
+		FromFile: g.path,
+		ToFile:   "response",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("can't diff body with golden file %v: %v", g.path, err)
+	}
+	return fmt.Errorf("body is not matched with golden file %v:\n%v", g.path, diff)
+}
+
+// splitLines splits content into lines which end with newline
+func splitLines(content []byte) []string {
+	return difflib.SplitLines(strings.TrimSuffix(string(content), "\n"))
+}
+
+// normalizeGolden returns body which is compared with golden file
+// Json body is indented with sorted keys after ignored fields are
+// removed, and other body is returned as it is
+func normalizeGolden(body []byte, ignored []string) ([]byte, error) {
+	if !json.Valid(body) {
+		return body, nil
+	}
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	for _, p := range ignored {
+		var err error
+		if v, err = jsonutil.DeleteByJSONPath(v, p); err != nil {
+			return nil, err
+		}
+	}
+	buf := bytes.Buffer{}
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package roundtrip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeGolden(t *testing.T) {
+	cases := []struct {
+		body     string
+		ignored  []string
+		expected string
+	}{
+		{`{"b": 1, "a": "<x>"}`, nil, "{\n  \"a\": \"<x>\",\n  \"b\": 1\n}\n"},
+		{`{"id": 12345678901234567890, "items": [{"id": 1, "name": "a"}]}`, []string{"$.items[*].id"},
+			"{\n  \"id\": 12345678901234567890,\n  \"items\": [\n    {\n      \"name\": \"a\"\n    }\n  ]\n}\n"},
+		{`hello`, []string{"$.id"}, `hello`},
+	}
+	for _, c := range cases {
+		actual, err := normalizeGolden([]byte(c.body), c.ignored)
+		assert.NoError(t, err, c.body)
+		assert.Equal(t, c.expected, string(actual), c.body)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	g, err := newGolden(&types.Context{Dir: dir, UpdateGolden: true}, "golden/product.json")
+	assert.NoError(t, err)
+	assert.NoError(t, g.match([]byte(`{"name": "a", "id": 1}`), []string{"$.id"}))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "golden", "product.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"a\"\n}\n", string(content))
+
+	g, err = newGolden(&types.Context{Dir: dir}, "golden/product.json")
+	assert.NoError(t, err)
+	assert.NoError(t, g.match([]byte(`{"id": 2, "name": "a"}`), []string{"$.id"}))
+	err = g.match([]byte(`{"name": "b"}`), nil)
+	if assert.Error(t, err) {
+		p := filepath.Join(dir, "golden", "product.json")
+		assert.Equal(t, "body is not matched with golden file "+p+":\n"+
+			"--- "+p+"\n"+
+			"+++ response\n"+
+			"@@ -1,3 +1,3 @@\n"+
+			" {\n"+
+			"-  \"name\": \"a\"\n"+
+			"+  \"name\": \"b\"\n"+
+			" }\n", err.Error())
+	}
+
+	_, err = newGolden(&types.Context{Dir: dir}, "golden/missing.json")
+	assert.Error(t, err)
+}
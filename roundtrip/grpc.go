@@ -0,0 +1,337 @@
+package roundtrip
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// GRPCStatusTrailer is trailer of grpc status code of response
+	GRPCStatusTrailer = "Grpc-Status"
+
+	// GRPCMessageTrailer is trailer of grpc status message of response
+	GRPCMessageTrailer = "Grpc-Message"
+)
+
+// grpcMethod is a unary method found in descriptor set
+type grpcMethod struct {
+	desc  protoreflect.MethodDescriptor
+	types *dynamicpb.Types
+}
+
+// fullName returns full name of method in grpc requests
+// e.g. /products.v1.Products/GetProduct
+func (m *grpcMethod) fullName() string {
+	return fmt.Sprintf("/%v/%v", m.desc.Parent().FullName(), m.desc.Name())
+}
+
+// loadGRPCMethod finds method like products.v1.Products/GetProduct
+// in descriptor set of request
+func loadGRPCMethod(ctx *types.Context, conf *types.GRPC, name string) (*grpcMethod, error) {
+	if conf == nil || conf.DescriptorSet == nil {
+		return nil, fmt.Errorf("descriptorSet of grpc should be set")
+	}
+	p, err := conf.DescriptorSet.Render(ctx.Variables)
+	if err != nil {
+		return nil, err
+	}
+	content, p, err := readFile(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("can't read descriptor set: %v", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(content, set); err != nil {
+		return nil, fmt.Errorf("can't parse descriptor set %v: %v", p, err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("can't load descriptor set %v: %v", p, err)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("api %v of grpc should be service and method, e.g. products.v1.Products/GetProduct", name)
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("can't find service %v in descriptor set %v", parts[0], p)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a service", parts[0])
+	}
+	md := sd.Methods().ByName(protoreflect.Name(parts[1]))
+	if md == nil {
+		return nil, fmt.Errorf("can't find method %v of service %v", parts[1], parts[0])
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("streaming method %v is not supported", name)
+	}
+	return &grpcMethod{
+		desc:  md,
+		types: dynamicpb.NewTypes(files),
+	}, nil
+}
+
+// newGRPCRequest returns request message of method and a http request
+// which presents the grpc request, e.g. for hooks, dumps and recorders
+// Hooks are applied after message is decoded, so headers set by them are
+// sent as metadata but changes of body are ignored
+func (c *Client) newGRPCRequest(ctx *types.Context, reqConf *types.Request) (*http.Request, *grpcMethod, proto.Message, error) {
+	if reqConf.API == nil {
+		return nil, nil, nil, fmt.Errorf("api can not be empty")
+	}
+	api, err := reqConf.API.Render(ctx.Variables)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	method, err := loadGRPCMethod(ctx, reqConf.GRPC, strings.TrimSpace(api))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	msg := dynamicpb.NewMessage(method.desc.Input())
+	body := []byte{}
+	if reqConf.Body != nil {
+		rendered, err := reqConf.Body.RenderJSON(ctx.Variables)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if strings.TrimSpace(rendered) != "" {
+			opts := protojson.UnmarshalOptions{Resolver: method.types}
+			if err := opts.Unmarshal([]byte(rendered), msg); err != nil {
+				return nil, nil, nil, fmt.Errorf("can't unmarshal body to %v: %v", method.desc.Input().FullName(), err)
+			}
+			body = []byte(rendered)
+		}
+	}
+
+	// path prefix doesn't apply to grpc methods
+	conf := *reqConf
+	conf.PathPrefix = nil
+	raw, err := c.urlOf(ctx, &conf, method.fullName())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, raw, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for k, v := range reqConf.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	if err := applyHooks(req, reqConf.Hooks); err != nil {
+		return nil, nil, nil, err
+	}
+	return req, method, msg, nil
+}
+
+// doGRPC calls a unary grpc method found in descriptor set of request
+// Response message is returned as json body of a http response, header
+// metadata are its headers, and trailer metadata with grpc status are
+// its trailers, so that it can be matched like a http response
+func (c *Client) doGRPC(ctx *types.Context, reqConf *types.Request) (*http.Response, error) {
+	req, method, msg, err := c.newGRPCRequest(ctx, reqConf)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.dialGRPC(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	md := metadata.MD{}
+	for k, vs := range req.Header {
+		if k == "Content-Type" {
+			continue
+		}
+		md.Append(k, vs...)
+	}
+	callCtx := metadata.NewOutgoingContext(context.Background(), md)
+	if reqConf.Timeout != nil {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, reqConf.Timeout.Duration)
+		defer cancel()
+	}
+
+	c.limiter.wait(req.URL.Host)
+	record(ctx, req)
+	start := time.Now()
+	reply := dynamicpb.NewMessage(method.desc.Output())
+	var header, trailer metadata.MD
+	callErr := conn.Invoke(callCtx, method.fullName(), msg, reply, grpc.Header(&header), grpc.Trailer(&trailer))
+	duration := time.Since(start)
+
+	st := status.Convert(callErr)
+	body := []byte{}
+	if st.Code() == codes.OK {
+		opts := protojson.MarshalOptions{Resolver: method.types}
+		if body, err = opts.Marshal(reply); err != nil {
+			return nil, fmt.Errorf("can't marshal response of %v: %v", method.fullName(), err)
+		}
+	}
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     metadataHeader(header),
+		Trailer:    metadataHeader(trailer),
+		Body: &timedBody{
+			ReadCloser: ioutil.NopCloser(bytes.NewReader(body)),
+			duration:   duration,
+		},
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Trailer.Set(GRPCStatusTrailer, strconv.Itoa(int(st.Code())))
+	if st.Message() != "" {
+		resp.Trailer.Set(GRPCMessageTrailer, st.Message())
+	}
+	return resp, nil
+}
+
+// dialGRPC connects to host of u, tls is used for https
+// Connections are counted like connections of http requests
+func (c *Client) dialGRPC(u *url.URL) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if u.Scheme == "https" {
+		config := &tls.Config{}
+		if c.tlsConfig != nil {
+			config = c.tlsConfig.Clone()
+		}
+		creds = credentials.NewTLS(config)
+	}
+	conn, err := grpc.NewClient("passthrough:///"+u.Host,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return c.dialContext(ctx, "tcp", addr)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to grpc server %v: %v", u.Host, err)
+	}
+	return conn, nil
+}
+
+// metadataHeader converts metadata to http header
+func metadataHeader(md metadata.MD) http.Header {
+	h := http.Header{}
+	for k, vs := range md {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	return h
+}
+
+// parseGRPCCode parses grpc code like NotFound, NOT_FOUND or 5
+func parseGRPCCode(s string) (codes.Code, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= int(codes.Unauthenticated) {
+		return codes.Code(n), nil
+	}
+	name := strings.Replace(s, "_", "", -1)
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.EqualFold(c.String(), name) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown grpc code %v", s)
+}
+
+// grpcMatcher matches grpc code and trailers of response
+type grpcMatcher struct {
+	// code is nil if round trip is not grpc
+	code *codes.Code
+
+	// trailers used to match trailers of response like headers
+	trailers []headerMatcher
+}
+
+// newGRPCMatcher returns nil if neither grpc code nor trailers
+// should be matched
+func newGRPCMatcher(ctx *types.Context, rt *types.RoundTrip) (*grpcMatcher, error) {
+	respConf := rt.Response
+	gm := &grpcMatcher{}
+	if rt.Request.Protocol == types.GRPCProtocol {
+		code := codes.OK
+		if respConf.GRPCCode != "" {
+			c, err := parseGRPCCode(respConf.GRPCCode)
+			if err != nil {
+				return nil, err
+			}
+			code = c
+		}
+		gm.code = &code
+	} else if respConf.GRPCCode != "" {
+		return nil, fmt.Errorf("grpcCode can only be used with grpc protocol")
+	}
+	if len(respConf.Trailers) != 0 {
+		trailers, err := newTrailerMatchers(ctx, respConf.Trailers)
+		if err != nil {
+			return nil, err
+		}
+		gm.trailers = trailers
+	}
+	if gm.code == nil && gm.trailers == nil {
+		return nil, nil
+	}
+	return gm, nil
+}
+
+// match returns failures of grpc code and trailers
+func (gm *grpcMatcher) match(resp *http.Response) []error {
+	failures := []error{}
+	if gm.code != nil {
+		if err := matchGRPCCode(*gm.code, resp); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	for i := range gm.trailers {
+		if err := gm.trailers[i].match(resp); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	return failures
+}
+
+// matchGRPCCode returns error if grpc status of response is not expected
+func matchGRPCCode(expected codes.Code, resp *http.Response) error {
+	actual := codes.Unknown
+	if n, err := strconv.Atoi(resp.Trailer.Get(GRPCStatusTrailer)); err == nil {
+		actual = codes.Code(n)
+	}
+	if actual == expected {
+		return nil
+	}
+	if msg := resp.Trailer.Get(GRPCMessageTrailer); msg != "" {
+		return fmt.Errorf("grpc code is not matched, expected: %v, actual: %v (%v)", expected, actual, msg)
+	}
+	return fmt.Errorf("grpc code is not matched, expected: %v, actual: %v", expected, actual)
+}
@@ -0,0 +1,219 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// productsDescriptor returns descriptor of products.v1.Products
+func productsDescriptor() *descriptorpb.FileDescriptorProto {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("products.proto"),
+		Package: proto.String("products.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("GetProductRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+			},
+			{
+				Name: proto.String("Product"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("stock", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Products"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("GetProduct"),
+					InputType:  proto.String(".products.v1.GetProductRequest"),
+					OutputType: proto.String(".products.v1.Product"),
+				},
+				{
+					Name:            proto.String("Watch"),
+					InputType:       proto.String(".products.v1.GetProductRequest"),
+					OutputType:      proto.String(".products.v1.Product"),
+					ServerStreaming: proto.Bool(true),
+				},
+			},
+		}},
+	}
+}
+
+// newProductsServer returns address of a grpc server of products
+func newProductsServer(t *testing.T) (string, func()) {
+	fd, err := protodesc.NewFile(productsDescriptor(), nil)
+	if err != nil {
+		t.Fatalf("can't build descriptor: %v", err)
+	}
+	request := fd.Messages().ByName("GetProductRequest")
+	product := fd.Messages().ByName("Product")
+	s := grpc.NewServer(grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		in := dynamicpb.NewMessage(request)
+		if err := stream.RecvMsg(in); err != nil {
+			return err
+		}
+		id := in.Get(request.Fields().ByName("id")).String()
+		if id == "missing" {
+			return status.Errorf(codes.NotFound, "product %v is not found", id)
+		}
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		stream.SetHeader(metadata.MD{"x-tenant": md.Get("x-tenant")})
+		stream.SetTrailer(metadata.Pairs("x-request-id", "r1"))
+		out := dynamicpb.NewMessage(product)
+		out.Set(product.Fields().ByName("id"), protoreflect.ValueOfString(id))
+		out.Set(product.Fields().ByName("name"), protoreflect.ValueOfString("apple"))
+		return stream.SendMsg(out)
+	}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("can't listen: %v", err)
+	}
+	go s.Serve(l)
+	return l.Addr().String(), s.Stop
+}
+
+func TestDoGRPC(t *testing.T) {
+	addr, stop := newProductsServer(t)
+	defer stop()
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{productsDescriptor()},
+	})
+	assert.NoError(t, err)
+	ctx := &types.Context{
+		Dir: "cases",
+		FS: fstest.MapFS{
+			"cases/products.pb": {Data: set},
+		},
+	}
+	client := NewClient(addr)
+	cases := []struct {
+		rt       string
+		failure  string
+		hasError bool
+	}{
+		{`{
+			"request": {"protocol": "grpc", "api": "products.v1.Products/GetProduct", "grpc": {"descriptorSet": "products.pb"},
+				"headers": {"X-Tenant": "acme"}, "body": {"id": "p1"}},
+			"response": {"body": {"id": "p1", "name": "apple"}, "headers": {"X-Tenant": "acme"}, "trailers": {"X-Request-Id": "r1"}}
+		}`, "", false},
+		{`{
+			"request": {"protocol": "grpc", "api": "/products.v1.Products/GetProduct", "grpc": {"descriptorSet": "products.pb"}, "body": {"id": "missing"}},
+			"response": {"grpcCode": "NOT_FOUND", "trailers": {"Grpc-Message": {"$regexp": "not found"}}}
+		}`, "", false},
+		{`{
+			"request": {"protocol": "grpc", "api": "products.v1.Products/GetProduct", "grpc": {"descriptorSet": "products.pb"}, "body": {"id": "missing"}},
+			"response": {"body": {"id": "missing"}}
+		}`, "grpc code is not matched, expected: OK, actual: NotFound (product missing is not found)", false},
+		{`{
+			"request": {"protocol": "grpc", "api": "products.v1.Products/GetProduct", "grpc": {"descriptorSet": "products.pb"}, "body": {"id": "p1"}},
+			"response": {"grpcCode": "5", "trailers": {"X-Request-Id": "r2"}}
+		}`, "grpc code is not matched, expected: NotFound, actual: OK", false},
+		{`{"request": {"protocol": "grpc", "api": "products.v1.Products/Watch", "grpc": {"descriptorSet": "products.pb"}}}`, "", true},
+		{`{"request": {"protocol": "grpc", "api": "products.v1.Products/Delete", "grpc": {"descriptorSet": "products.pb"}}}`, "", true},
+		{`{"request": {"protocol": "grpc", "api": "products.v1.Orders/GetOrder", "grpc": {"descriptorSet": "products.pb"}}}`, "", true},
+		{`{"request": {"protocol": "grpc", "api": "GetProduct", "grpc": {"descriptorSet": "products.pb"}}}`, "", true},
+		{`{"request": {"protocol": "grpc", "api": "products.v1.Products/GetProduct"}}`, "", true},
+		{`{"request": {"protocol": "grpc", "api": "products.v1.Products/GetProduct", "grpc": {"descriptorSet": "products.pb"}, "body": {"sku": "p1"}}}`, "", true},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.rt), rt), c.rt) {
+			continue
+		}
+		resp, err := client.DoRequest(ctx, rt)
+		if c.hasError {
+			assert.Error(t, err, c.rt)
+			continue
+		}
+		if !assert.NoError(t, err, c.rt) {
+			continue
+		}
+		assert.Equal(t, "POST", resp.Request.Method, c.rt)
+		m, err := MatchResponse(ctx, rt)
+		if !assert.NoError(t, err, c.rt) {
+			continue
+		}
+		matched, err := m.Match(resp)
+		assert.NoError(t, err, c.rt)
+		if c.failure == "" {
+			assert.True(t, matched, "%v: %v", c.rt, m.FailureMessage(resp))
+			continue
+		}
+		assert.False(t, matched, c.rt)
+		assert.Contains(t, m.FailureMessage(resp), c.failure, c.rt)
+		if c.failure != "" && len(rt.Response.Trailers) != 0 {
+			assert.Contains(t, m.FailureMessage(resp), "trailer X-Request-Id is not matched", c.rt)
+		}
+	}
+	assert.Equal(t, int64(4), client.ConnectionsOpened())
+
+	// headers set by hooks are sent as metadata
+	rt := &types.RoundTrip{}
+	raw := `{"request": {"protocol": "grpc", "api": "products.v1.Products/GetProduct", "grpc": {"descriptorSet": "products.pb"}, "body": {"id": "p1"}}}`
+	assert.NoError(t, json.Unmarshal([]byte(raw), rt))
+	rt.Request.Hooks = []types.RequestHook{func(req *http.Request) error {
+		req.Header.Set("X-Tenant", "hooked")
+		return nil
+	}}
+	resp, err := client.DoRequest(ctx, rt)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hooked", resp.Header.Get("X-Tenant"))
+	}
+
+	_, err = MatchResponse(ctx, &types.RoundTrip{Response: types.Response{GRPCCode: "NotFound"}})
+	assert.Error(t, err)
+}
+
+func TestParseGRPCCode(t *testing.T) {
+	cases := []struct {
+		raw      string
+		code     codes.Code
+		hasError bool
+	}{
+		{"OK", codes.OK, false},
+		{"NotFound", codes.NotFound, false},
+		{"NOT_FOUND", codes.NotFound, false},
+		{"deadline_exceeded", codes.DeadlineExceeded, false},
+		{"16", codes.Unauthenticated, false},
+		{"17", 0, true},
+		{"Missing", 0, true},
+	}
+	for _, c := range cases {
+		code, err := parseGRPCCode(c.raw)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		assert.NoError(t, err, c.raw)
+		assert.Equal(t, c.code, code, c.raw)
+	}
+}
@@ -0,0 +1,104 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/caicloud/aloe/matcher"
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/gomega"
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+// headerMatcher matches a header or trailer of response
+type headerMatcher struct {
+	name string
+
+	// trailer means trailer of response is matched
+	trailer bool
+
+	// absent means header should not exist in response
+	absent bool
+
+	m gomegatypes.GomegaMatcher
+}
+
+// newTrailerMatchers returns matchers of trailers sorted by name
+func newTrailerMatchers(ctx *types.Context, trailers map[string]types.Template) ([]headerMatcher, error) {
+	ms, err := newHeaderMatchers(ctx, trailers)
+	for i := range ms {
+		ms[i].trailer = true
+	}
+	return ms, err
+}
+
+// newHeaderMatchers returns matchers of headers sorted by name
+func newHeaderMatchers(ctx *types.Context, headers map[string]types.Template) ([]headerMatcher, error) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ms := []headerMatcher{}
+	for _, name := range names {
+		t := headers[name]
+		rendered, err := t.Render(ctx.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("can't render matcher of header %v: %v", name, err)
+		}
+		hm := headerMatcher{name: http.CanonicalHeaderKey(name)}
+		obj := map[string]interface{}{}
+		switch {
+		case json.Unmarshal([]byte(rendered), &obj) != nil || !isSpecial(obj):
+			// other values are matched with value of header literally
+			hm.m = gomega.Equal(rendered)
+		case len(obj) == 1 && obj[matcher.ExistsMatcher] != nil:
+			exists, ok := obj[matcher.ExistsMatcher].(bool)
+			if !ok {
+				return nil, fmt.Errorf("value of %v of header %v MUST be bool", matcher.ExistsMatcher, name)
+			}
+			hm.absent = !exists
+		default:
+			if hm.m, err = matcher.ParseValue(rendered); err != nil {
+				return nil, fmt.Errorf("can't parse matcher of header %v: %v", name, err)
+			}
+		}
+		ms = append(ms, hm)
+	}
+	return ms, nil
+}
+
+// match returns error if header is not matched
+// Values of a header with more than one value are joined by comma
+func (hm *headerMatcher) match(resp *http.Response) error {
+	kind, header := "header", resp.Header
+	if hm.trailer {
+		kind, header = "trailer", resp.Trailer
+	}
+	values, ok := header[hm.name]
+	if hm.absent {
+		if ok {
+			return fmt.Errorf("%v %v should not exist, actual: %v", kind, hm.name, strings.Join(values, ", "))
+		}
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("%v %v is not found in response", kind, hm.name)
+	}
+	if hm.m == nil {
+		// header only needs to exist
+		return nil
+	}
+	actual := strings.Join(values, ", ")
+	matched, err := hm.m.Match(actual)
+	if err != nil {
+		return fmt.Errorf("can't match %v %v: %v", kind, hm.name, err)
+	}
+	if !matched {
+		return fmt.Errorf("%v %v is not matched: \n%v", kind, hm.name, hm.m.FailureMessage(actual))
+	}
+	return nil
+}
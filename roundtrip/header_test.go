@@ -0,0 +1,49 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderMatchers(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{
+			"Content-Length": []string{"42"},
+			"Etag":           []string{`"v1"`},
+			"Vary":           []string{"Accept", "Origin"},
+		},
+	}
+	cases := []struct {
+		headers string
+		matched bool
+	}{
+		{`{"content-length": "42", "ETag": "\"v1\""}`, true},
+		{`{"Content-Length": "0"}`, false},
+		{`{"Vary": "Accept, Origin"}`, true},
+		{`{"ETag": {"$regexp": "^\"v[0-9]+\"$"}}`, true},
+		{`{"ETag": {"$exists": true}, "X-Missing": {"$exists": false}}`, true},
+		{`{"ETag": {"$exists": false}}`, false},
+		{`{"X-Missing": ""}`, false},
+	}
+	for _, c := range cases {
+		conf := map[string]types.Template{}
+		if err := json.Unmarshal([]byte(c.headers), &conf); err != nil {
+			t.Fatalf("can't parse %v: %v", c.headers, err)
+		}
+		ms, err := newHeaderMatchers(&types.Context{}, conf)
+		if !assert.NoError(t, err, c.headers) {
+			continue
+		}
+		var matchErr error
+		for i := range ms {
+			if err := ms[i].match(resp); err != nil {
+				matchErr = err
+			}
+		}
+		assert.Equal(t, c.matched, matchErr == nil, "%v: %v", c.headers, matchErr)
+	}
+}
@@ -1,31 +1,114 @@
 package roundtrip
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/caicloud/aloe/types"
+	"github.com/gorilla/websocket"
 )
 
 // Client defines client which can run round-trip of API test
 type Client struct {
-	c    *http.Client
-	host string
+	// conns is number of opened connections, it is first for
+	// atomic operations
+	conns int64
+
+	c         *http.Client
+	transport *http.Transport
+	netDialer *net.Dialer
+	dialer    *websocket.Dialer
+	host      string
+	limiter   *rateLimiter
+
+	// tlsConfig is set by WithTLSConfig, config of transport can't
+	// be used because it is also set when http2 is configured
+	tlsConfig *tls.Config
 }
 
 // NewClient returns a client for roundtrip
-func NewClient(host string) *Client {
-	return &Client{
-		c:    http.DefaultClient,
-		host: host,
+func NewClient(host string, opts ...Option) *Client {
+	netDialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	// transport is cloned from http.DefaultTransport, e.g. http2 is
+	// attempted, but connections are dialed by client
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c := &Client{
+		c: &http.Client{
+			Transport: transport,
+		},
+		transport: transport,
+		netDialer: netDialer,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 45 * time.Second,
+		},
+		host:    host,
+		limiter: newRateLimiter(),
+	}
+	// connections of both http and websocket are counted
+	transport.DialContext = c.dialContext
+	c.dialer.NetDialContext = c.dialContext
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// dialContext dials server and counts opened connections
+func (c *Client) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := c.netDialer.DialContext(ctx, network, addr)
+	if err == nil {
+		atomic.AddInt64(&c.conns, 1)
+	}
+	return conn, err
+}
+
+// ConnectionsOpened returns number of connections opened by client
+// e.g. to check that connections are reused or not
+func (c *Client) ConnectionsOpened() int64 {
+	return atomic.LoadInt64(&c.conns)
+}
+
+// HTTPClient returns a http client which shares transport of client
+// e.g. tls config, proxy and counting of connections, but cookies
+// of contexts are not used
+func (c *Client) HTTPClient() *http.Client {
+	hc := *c.c
+	return &hc
+}
+
+// baseURL returns scheme and host of target
+// If host has no scheme, https will be used when tls is configured
+func (c *Client) baseURL() string {
+	return c.baseURLOf(c.host)
+}
+
+// baseURLOf returns base url of host like baseURL
+func (c *Client) baseURLOf(host string) string {
+	if strings.Contains(host, "://") {
+		return strings.TrimSuffix(host, "/")
+	}
+	if c.tlsConfig != nil {
+		return "https://" + host
 	}
+	return "http://" + host
 }
 
+// splitMethodAndPath splits api into method and path, e.g. PATCH /products/1
+// Method is passed through as it is, so custom methods can be used
 func splitMethodAndPath(api string) (string, string) {
-	s := strings.Split(api, " ")
+	s := strings.Fields(api)
 	if len(s) != 2 {
 		return "", ""
 	}
@@ -34,10 +117,146 @@ func splitMethodAndPath(api string) (string, string) {
 
 // DoRequest runs a round-trip of http
 func (c *Client) DoRequest(ctx *types.Context, rt *types.RoundTrip) (*http.Response, error) {
-	return c.doRequest(ctx, &rt.Request)
+	switch rt.Request.Protocol {
+	case types.GRPCProtocol:
+		return c.doGRPC(ctx, &rt.Request)
+	case "", types.HTTPProtocol:
+		return c.doRequest(ctx, &rt.Request)
+	case types.WebSocketProtocol:
+		return c.doWebSocket(ctx, rt)
+	case types.SSEProtocol:
+		return c.doSSE(ctx, rt)
+	}
+	return nil, fmt.Errorf("protocol %v is not supported", rt.Request.Protocol)
+}
+
+// NewRequest builds http request of a round-trip without sending it
+// A grpc request is presented as a http request of its method
+func (c *Client) NewRequest(ctx *types.Context, rt *types.RoundTrip) (*http.Request, error) {
+	if rt.Request.Protocol == types.GRPCProtocol {
+		req, _, _, err := c.newGRPCRequest(ctx, &rt.Request)
+		return req, err
+	}
+	return c.newRequest(ctx, &rt.Request)
 }
 
 func (c *Client) doRequest(ctx *types.Context, reqConf *types.Request) (*http.Response, error) {
+	if reqConf.Retry != nil {
+		return c.doRequestWithRetry(ctx, reqConf)
+	}
+	req, err := c.newRequest(ctx, reqConf)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, req, reqConf)
+}
+
+// record records request if recorder of context is set
+func record(ctx *types.Context, req *http.Request) {
+	if ctx.Recorder != nil {
+		ctx.Recorder.Record(req)
+	}
+}
+
+// httpClient returns http client for the request
+// Cookie jar of context is used unless it is disabled by request
+// and redirect policy is decided by request
+func (c *Client) httpClient(ctx *types.Context, reqConf *types.Request) *http.Client {
+	hc := *c.c
+	if !reqConf.DisableCookies {
+		hc.Jar = ctx.CookieJar
+	}
+	if follow := reqConf.FollowRedirects; follow != nil && !*follow {
+		hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else if max := reqConf.MaxRedirects; max > 0 {
+		hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= max {
+				return fmt.Errorf("stopped after %v redirects", max)
+			}
+			return nil
+		}
+	}
+	return &hc
+}
+
+// send sends request and records its duration
+func (c *Client) send(ctx *types.Context, req *http.Request, reqConf *types.Request) (*http.Response, error) {
+	c.limiter.wait(req.URL.Host)
+	record(ctx, req)
+	start := time.Now()
+	resp, err := c.sendWithTimeout(ctx, req, reqConf)
+	if err != nil {
+		return nil, err
+	}
+	// body is decoded if it is not decoded by transport
+	if err := decompress(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = &timedBody{
+		ReadCloser: resp.Body,
+		duration:   time.Since(start),
+	}
+	return resp, nil
+}
+
+// sendWithTimeout sends request and cancels it if timeout of request is reached
+func (c *Client) sendWithTimeout(ctx *types.Context, req *http.Request, reqConf *types.Request) (*http.Response, error) {
+	hc := c.httpClient(ctx, reqConf)
+	if reqConf.Timeout == nil {
+		return hc.Do(req)
+	}
+	timeout := reqConf.Timeout.Duration
+	reqCtx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := hc.Do(req.WithContext(reqCtx))
+	if err != nil {
+		cancel()
+		if reqCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("request timed out after %v", timeout)
+		}
+		return nil, err
+	}
+	// timeout also applies to reading body
+	resp.Body = &cancelableBody{
+		ReadCloser: resp.Body,
+		cancel:     cancel,
+	}
+	return resp, nil
+}
+
+// timedBody records duration of the request
+type timedBody struct {
+	io.ReadCloser
+	duration time.Duration
+}
+
+// Duration returns duration of the request which returns the response
+// It is measured until response headers are received
+// It returns false if response is not returned by a http request of client
+func Duration(resp *http.Response) (time.Duration, bool) {
+	b, ok := resp.Body.(*timedBody)
+	if !ok {
+		return 0, false
+	}
+	return b.duration, true
+}
+
+// cancelableBody cancels context of request when body is closed
+type cancelableBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelableBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (c *Client) newRequest(ctx *types.Context, reqConf *types.Request) (*http.Request, error) {
 	if reqConf.API == nil {
 		return nil, fmt.Errorf("api can not be empty")
 	}
@@ -48,23 +267,78 @@ func (c *Client) doRequest(ctx *types.Context, reqConf *types.Request) (*http.Re
 	}
 
 	method, path := splitMethodAndPath(api)
+	if method == "" {
+		return nil, fmt.Errorf("api %v should be method and path, e.g. GET /products", api)
+	}
+	path, err = expandPath(ctx, path, reqConf.PathParams)
+	if err != nil {
+		return nil, err
+	}
 
-	var body io.Reader
-	if reqConf.Body != nil {
-		rendered, err := reqConf.Body.Render(ctx.Variables)
+	body, contentType, err := newBody(ctx, reqConf)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && reqConf.ContentEncoding != "" {
+		body, err = compress(body, reqConf.ContentEncoding)
 		if err != nil {
 			return nil, err
 		}
-		body = bytes.NewBufferString(rendered)
 	}
 
-	req, err := http.NewRequest(method, "http://"+c.host+path, body)
+	raw, err := c.urlOf(ctx, reqConf, path)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(reqConf.Query) != 0 {
+		q := u.Query()
+		for _, k := range reqConf.ReplaceQuery {
+			if _, ok := reqConf.Query[k]; ok {
+				q.Del(k)
+			}
+		}
+		for k, ts := range reqConf.Query {
+			for i := range ts {
+				v, err := ts[i].Render(ctx.Variables)
+				if err != nil {
+					return nil, fmt.Errorf("can't render query %v: %v", k, err)
+				}
+				q.Add(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
 	for k, v := range reqConf.Headers {
 		req.Header.Set(k, v)
 	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if body != nil && reqConf.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", string(reqConf.ContentEncoding))
+	}
+	if err := applyHooks(req, reqConf.Hooks); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
 
-	return c.c.Do(req)
+// applyHooks modifies built request by hooks in order
+func applyHooks(req *http.Request, hooks []types.RequestHook) error {
+	for _, hook := range hooks {
+		if err := hook(req); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,220 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoRequestMethods(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"method": r.Method,
+			"body":   string(body),
+		})
+	}))
+	defer s.Close()
+	client := NewClient(s.URL)
+	cases := []struct {
+		api      string
+		body     string
+		expected string
+	}{
+		{"PATCH /products/1", `{"name": "a"}`, `{"method":"PATCH","body":"{\"name\": \"a\"}"}`},
+		{"DELETE /products/1", `{"force": true}`, `{"method":"DELETE","body":"{\"force\": true}"}`},
+		{"PURGE  /cache", "", `{"method":"PURGE","body":""}`},
+		{"HEAD /products/1", "", ""},
+	}
+	for _, c := range cases {
+		api, err := types.NewTemplate(c.api)
+		assert.NoError(t, err)
+		rt := &types.RoundTrip{
+			Request: types.Request{
+				API: api,
+			},
+		}
+		if c.body != "" {
+			if rt.Request.Body, err = types.NewTemplate(c.body); !assert.NoError(t, err) {
+				continue
+			}
+		}
+		resp, err := client.DoRequest(&types.Context{}, rt)
+		if !assert.NoError(t, err, c.api) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, c.api)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, c.api)
+		if c.expected == "" {
+			// body of HEAD response is always empty
+			assert.Empty(t, body, c.api)
+			continue
+		}
+		assert.JSONEq(t, c.expected, string(body), c.api)
+	}
+
+	for _, api := range []string{"/products", "GET", "GET /products extra"} {
+		a, err := types.NewTemplate(api)
+		assert.NoError(t, err)
+		_, err = client.DoRequest(&types.Context{}, &types.RoundTrip{Request: types.Request{API: a}})
+		assert.Error(t, err, api)
+	}
+}
+
+func TestDoRequestContentType(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Content-Type")))
+	}))
+	defer s.Close()
+	client := NewClient(s.URL)
+	tmpl := types.RoundTrip{}
+	tmpl.Request.Headers = map[string]string{"Content-Type": "text/plain"}
+	cases := []struct {
+		request     string
+		tmpl        *types.RoundTrip
+		contentType string
+	}{
+		{`{"api": "POST /products", "body": {"name": "a"}}`, nil, "application/json"},
+		{`{"api": "POST /products", "body": {"name": "a"}, "bodyType": "form"}`, nil, "application/x-www-form-urlencoded"},
+		{`{"api": "POST /products", "body": {"name": "a"}, "headers": {"content-type": "application/merge-patch+json"}}`, nil, "application/merge-patch+json"},
+		{`{"api": "GET /products"}`, nil, ""},
+		{`{"api": "POST /products", "body": "a"}`, &tmpl, "text/plain"},
+		{`{"api": "POST /products", "body": "a", "headers": {"content-type": "text/csv"}}`, &tmpl, "text/csv"},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.request), &rt.Request), c.request) {
+			continue
+		}
+		if c.tmpl != nil {
+			merged := types.MergeRoundTrip(*rt, *c.tmpl)
+			rt = &merged
+		}
+		resp, err := client.DoRequest(&types.Context{}, rt)
+		if !assert.NoError(t, err, c.request) {
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, c.contentType, string(body), c.request)
+	}
+}
+
+func TestDoRequestQuery(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer s.Close()
+	client := NewClient(s.URL)
+	ctx := &types.Context{
+		Variables: map[string]template.Variable{
+			"name": {Raw: []byte("a&b c"), Name: "name", Type: template.StringType},
+		},
+	}
+	cases := []struct {
+		request  string
+		expected string
+	}{
+		{`{"api": "GET /products", "query": {"name": "%{name}"}}`, "name=a%26b+c"},
+		{`{"api": "GET /products", "query": {"tag": ["a", "b"], "page": 1}}`, "page=1&tag=a&tag=b"},
+		{`{"api": "GET /products?page=1&sort=id", "query": {"page": "2"}}`, "page=1&page=2&sort=id"},
+		{`{"api": "GET /products?tag=a&tag=b&sort=id", "query": {"tag": ["c"]}}`, "sort=id&tag=a&tag=b&tag=c"},
+		{`{"api": "GET /products?tag=a", "query": {"tag": []}}`, "tag=a"},
+		{`{"api": "GET /products?page=1&sort=id", "query": {"page": "2"}, "replaceQuery": ["page"]}`, "page=2&sort=id"},
+		{`{"api": "GET /products?tag=a&tag=b&sort=id", "query": {"tag": ["c"]}, "replaceQuery": ["tag", "sort"]}`, "sort=id&tag=c"},
+		{`{"api": "GET /products?tag=a", "query": {"tag": []}, "replaceQuery": ["tag"]}`, ""},
+		{`{"api": "GET /products?sort=id"}`, "sort=id"},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.request), &rt.Request), c.request) {
+			continue
+		}
+		resp, err := client.DoRequest(ctx, rt)
+		if !assert.NoError(t, err, c.request) {
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, c.expected, string(body), c.request)
+	}
+}
+
+func TestDoRequestPathParams(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	}))
+	defer s.Close()
+	client := NewClient(s.URL)
+	rt := &types.RoundTrip{}
+	raw := `{"api": "GET /users/{id}/posts/{postId}", "pathPrefix": "/api", "pathParams": {"id": "a/b", "postId": "1"}}`
+	if !assert.NoError(t, json.Unmarshal([]byte(raw), &rt.Request)) {
+		return
+	}
+	resp, err := client.DoRequest(&types.Context{}, rt)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "/api/users/a%2Fb/posts/1", string(body))
+}
+
+func TestDoRequestKeepAlives(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	api, err := types.NewTemplate("GET /products")
+	assert.NoError(t, err)
+	cases := []struct {
+		opts     []Option
+		expected int64
+	}{
+		{nil, 1},
+		{[]Option{WithDisableKeepAlives()}, 3},
+	}
+	for _, c := range cases {
+		client := NewClient(s.URL, c.opts...)
+		for i := 0; i < 3; i++ {
+			resp, err := client.DoRequest(&types.Context{}, &types.RoundTrip{Request: types.Request{API: api}})
+			if !assert.NoError(t, err) {
+				continue
+			}
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		assert.Equal(t, c.expected, client.ConnectionsOpened())
+	}
+}
+
+func TestDoWebSocketConnections(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer s.Close()
+	api, err := types.NewTemplate("GET /ws")
+	assert.NoError(t, err)
+	client := NewClient(s.URL)
+	for i := 0; i < 2; i++ {
+		_, err := client.DoRequest(&types.Context{}, &types.RoundTrip{Request: types.Request{Protocol: types.WebSocketProtocol, API: api}})
+		assert.NoError(t, err)
+	}
+	// connections of websocket are counted without dial timeout
+	assert.Equal(t, int64(2), client.ConnectionsOpened())
+}
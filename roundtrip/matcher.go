@@ -2,20 +2,23 @@ package roundtrip
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/caicloud/aloe/matcher"
 	"github.com/caicloud/aloe/template"
 	"github.com/caicloud/aloe/types"
 	"github.com/caicloud/aloe/utils/indent"
 	"github.com/caicloud/aloe/utils/jsonutil"
+	"github.com/caicloud/aloe/utils/xmlutil"
+	"github.com/onsi/gomega"
 	"github.com/onsi/gomega/format"
 	gomegatypes "github.com/onsi/gomega/types"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 // ResponseHandler defines handler for response
@@ -27,14 +30,78 @@ type ResponseHandler interface {
 	Variables() (map[string]template.Variable, error)
 }
 
+// Validator validates body of response decoded as json
+// e.g. by schema of operation in OpenAPI spec
+type Validator interface {
+	Validate(method, path string, status int, contentType string, body []byte) []error
+}
+
 // ResponseMatcher defines a matcher to match http response
 type ResponseMatcher struct {
 	bodyMatcher gomegatypes.GomegaMatcher
 
+	// messagesMatcher used to match received websocket messages
+	messagesMatcher gomegatypes.GomegaMatcher
+
+	// xmlBody used to match xml response body
+	xmlBody *xmlutil.Node
+
+	// binaryBody used to match raw bytes of response body
+	binaryBody []byte
+
+	// checksum used to match checksum of response body
+	checksum *checksum
+
+	// bodySize used to match size of response body
+	bodySize *int64
+
+	// stream means body is only hashed and counted while it is read
+	stream bool
+
+	// schema used to validate response body
+	schema *gojsonschema.Schema
+
+	// isJSON used to validate that body is valid json
+	isJSON bool
+
+	// validators used to validate response body of http
+	validators []Validator
+
 	// emptyBody used to validate that body is empty
 	emptyBody bool
 
-	code int
+	// grpc used to match grpc code and trailers of response
+	grpc *grpcMatcher
+
+	// maxDuration used to validate duration of request
+	maxDuration *time.Duration
+
+	// ignoreFields are jsonpaths of fields removed from actual body
+	ignoreFields []string
+
+	// transforms modify decoded body before it is matched
+	transforms []types.Transform
+
+	// absentFields are jsonpaths of fields which should not exist
+	absentFields []string
+
+	// golden used to match normalized body with golden file
+	golden *golden
+
+	// verbosity defines how mismatches are rendered
+	verbosity types.FailureVerbosity
+
+	// expectedBody is decoded expected body, it is used to render
+	// expected values of mismatched fields
+	expectedBody interface{}
+
+	// cookies used to match cookies set by response
+	cookies []cookieMatcher
+
+	// headers used to match headers of response
+	headers []headerMatcher
+
+	code types.StatusCode
 
 	defs []types.Definition
 
@@ -47,16 +114,124 @@ type ResponseMatcher struct {
 
 // MatchResponse returns a response matcher
 func MatchResponse(ctx *types.Context, rt *types.RoundTrip) (ResponseHandler, error) {
+	return MatchResponseWithValidators(ctx, rt)
+}
+
+// MatchResponseWithValidators returns a response matcher which also
+// validates body of http response by validators
+func MatchResponseWithValidators(ctx *types.Context, rt *types.RoundTrip, validators ...Validator) (ResponseHandler, error) {
 	respConf := rt.Response
+	gm, err := newGRPCMatcher(ctx, rt)
+	if err != nil {
+		return nil, err
+	}
+	if gm != nil && gm.code != nil && len(respConf.StatusCode) == 0 {
+		respConf.StatusCode = types.NewStatusCode(http.StatusOK)
+	}
 	rm := &ResponseMatcher{
-		code: respConf.StatusCode,
-		defs: rt.Definitions,
+		code:       respConf.StatusCode,
+		defs:       rt.Definitions,
+		grpc:       gm,
+		validators: validators,
+		verbosity:  ctx.FailureVerbosity,
+		isJSON:     respConf.IsJSON,
+		bodySize:   respConf.BodySize,
+		stream:     respConf.Stream,
+	}
+	if gm != nil && gm.code != nil {
+		// operations of http spec don't apply to grpc methods
+		rm.validators = nil
+	}
+	if rm.stream {
+		if err := validateStream(rt); err != nil {
+			return nil, err
+		}
+	}
+	for _, def := range rt.Definitions {
+		switch def.Type {
+		case "", types.StringVariable, types.IntVariable, types.NumberVariable, types.BoolVariable, types.JSONVariable:
+		default:
+			return nil, fmt.Errorf("unknown type %v of variable %v", def.Type, def.Name)
+		}
+	}
+	if respConf.MaxDuration != nil {
+		rm.maxDuration = &respConf.MaxDuration.Duration
+	}
+	if err := validateTransforms(respConf.Transforms); err != nil {
+		return nil, err
+	}
+	rm.transforms = respConf.Transforms
+	for _, p := range respConf.AbsentFields {
+		if _, err := jsonutil.LocateByJSONPath(nil, p); err != nil {
+			return nil, fmt.Errorf("invalid absent field: %v", err)
+		}
+	}
+	rm.absentFields = respConf.AbsentFields
+	if p := rt.Request.Protocol; p == types.WebSocketProtocol || p == types.SSEProtocol {
+		if len(rm.code) == 0 && p == types.WebSocketProtocol {
+			rm.code = types.NewStatusCode(http.StatusSwitchingProtocols)
+		}
+		m, err := matchMessages(ctx, respConf.Messages)
+		if err != nil {
+			return nil, err
+		}
+		rm.messagesMatcher = m
+		rm.validators = nil
+	}
+	if len(respConf.Headers) != 0 {
+		headers, err := newHeaderMatchers(ctx, respConf.Headers)
+		if err != nil {
+			return nil, err
+		}
+		rm.headers = headers
+	}
+	if len(respConf.Cookies) != 0 {
+		cookies, err := newCookieMatchers(ctx, respConf.Cookies)
+		if err != nil {
+			return nil, err
+		}
+		rm.cookies = cookies
+	}
+	if respConf.JSONSchema != nil {
+		rendered, err := respConf.JSONSchema.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(rendered))
+		if err != nil {
+			return nil, fmt.Errorf("parse json schema error: %v", err)
+		}
+		rm.schema = schema
+	}
+	if respConf.BodyChecksum != nil {
+		rendered, err := respConf.BodyChecksum.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		if rm.checksum, err = parseChecksum(rendered); err != nil {
+			return nil, err
+		}
+	}
+	if respConf.Golden != nil {
+		rendered, err := respConf.Golden.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		if rm.golden, err = newGolden(ctx, rendered); err != nil {
+			return nil, err
+		}
+		for _, p := range respConf.IgnoreFields {
+			if _, err := jsonutil.DeleteByJSONPath(nil, p); err != nil {
+				return nil, fmt.Errorf("invalid ignore field: %v", err)
+			}
+		}
+		rm.ignoreFields = respConf.IgnoreFields
 	}
 	if respConf.Body == nil {
 		return rm, nil
 
 	}
-	matcherConf, err := respConf.Body.Render(ctx.Variables)
+	matcherConf, err := respConf.Body.RenderJSON(ctx.Variables)
 	if err != nil {
 		return nil, err
 	}
@@ -66,14 +241,130 @@ func MatchResponse(ctx *types.Context, rt *types.RoundTrip) (ResponseHandler, er
 		return rm, nil
 	}
 
-	m, err := matcher.Parse(matcherConf)
+	switch respConf.BodyType {
+	case "", types.JSONBody, types.GraphQLBody:
+	case types.XMLBody:
+		n, err := xmlutil.Parse([]byte(matcherConf))
+		if err != nil {
+			return nil, fmt.Errorf("parse xml error: %v", err)
+		}
+		rm.xmlBody = n
+		return rm, nil
+	case types.BinaryBody:
+		data, err := decodeBase64(matcherConf)
+		if err != nil {
+			return nil, fmt.Errorf("binary body should be base64 encoded: %v", err)
+		}
+		rm.binaryBody = data
+		return rm, nil
+	default:
+		return nil, fmt.Errorf("unknown body type %v of response", respConf.BodyType)
+	}
+
+	opts := matcher.Options{}
+	switch respConf.MatchMode {
+	case types.DefaultMatchMode:
+	case types.SubsetMatchMode:
+		opts.Subset = true
+	default:
+		return nil, fmt.Errorf("unknown match mode %v", respConf.MatchMode)
+	}
+	if len(respConf.IgnoreFields) != 0 {
+		matcherConf, err = ignoreFields(matcherConf, respConf.IgnoreFields)
+		if err != nil {
+			return nil, fmt.Errorf("can't ignore fields of expected body: %v", err)
+		}
+		rm.ignoreFields = respConf.IgnoreFields
+	}
+	m, err := matcher.ParseWithOptions(matcherConf, opts)
 	if err != nil {
 		return nil, fmt.Errorf("parse json error: %v", err)
 	}
 	rm.bodyMatcher = m
+	if rm.verbosity == types.PathFailures {
+		// expected body has been parsed as json
+		json.Unmarshal([]byte(matcherConf), &rm.expectedBody)
+	}
 	return rm, nil
 }
 
+// ignoreFields removes fields selected by paths from a json object
+func ignoreFields(body string, paths []string) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return "", err
+	}
+	for _, p := range paths {
+		var err error
+		if v, err = jsonutil.DeleteByJSONPath(v, p); err != nil {
+			return "", err
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func matchMessages(ctx *types.Context, msgs []*types.Template) (gomegatypes.GomegaMatcher, error) {
+	elems := matcher.Elements{}
+	for i, msg := range msgs {
+		rendered, err := msg.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		// message which is not json is matched literally
+		if !json.Valid([]byte(rendered)) {
+			elems = append(elems, gomega.Equal(rendered))
+			continue
+		}
+		m, err := matcher.ParseValue(rendered)
+		if err != nil {
+			return nil, fmt.Errorf("parse message %v error: %v", i, err)
+		}
+		elems = append(elems, m)
+	}
+	return matcher.MatchSlice(elems), nil
+}
+
+// matchAbsentFields returns errors with locations of fields which
+// should be absent. Values of fields are not printed because they
+// are usually sensitive
+func matchAbsentFields(body []byte, paths []string) []error {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []error{fmt.Errorf("can't check absent fields: can't unmarshal body to json: %v", err)}
+	}
+	errs := []error{}
+	for _, p := range paths {
+		// paths have been validated
+		located, _ := jsonutil.LocateByJSONPath(v, p)
+		for _, l := range located {
+			errs = append(errs, fmt.Errorf("field %v should be absent, but it is found at %v", p, l))
+		}
+	}
+	return errs
+}
+
+// validateSchema validates body by json schema
+// Failures are reported with json pointer of invalid field
+func validateSchema(schema *gojsonschema.Schema, body []byte) []error {
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+	if err != nil {
+		return []error{fmt.Errorf("can't validate body by json schema: %v", err)}
+	}
+	errs := []error{}
+	for _, re := range result.Errors() {
+		pointer := strings.TrimPrefix(re.Context().String("/"), "(root)")
+		if pointer == "" {
+			pointer = "/"
+		}
+		errs = append(errs, fmt.Errorf("json schema is not matched at %v: %v", pointer, re.Description()))
+	}
+	return errs
+}
+
 // Variables returns variable of matcher
 func (m *ResponseMatcher) Variables() (map[string]template.Variable, error) {
 	if !m.parsed {
@@ -90,35 +381,188 @@ func (m *ResponseMatcher) Match(actual interface{}) (bool, error) {
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	// matcher may be used to match more than one response
+	// e.g. in eventually and consistently
+	m.failures = nil
+	m.parsed = false
+
+	var body, sum []byte
+	var size int64
+	var err error
+	if m.stream {
+		size, sum, err = streamBody(resp.Body, m.checksum)
+	} else {
+		body, err = ioutil.ReadAll(resp.Body)
+		size = int64(len(body))
+	}
 	if err != nil {
 		m.failures = append(m.failures, fmt.Errorf("can't read body from response"))
 		return false, nil
 	}
-	if resp.StatusCode != m.code {
+	if !m.code.Match(resp.StatusCode) {
 		m.failures = append(m.failures, fmt.Errorf("status code is not matched, expected: %v, actual: %v", m.code, resp.StatusCode))
-		m.failures = append(m.failures, fmt.Errorf("api status: %v", string(body)))
+		if !m.stream {
+			m.failures = append(m.failures, fmt.Errorf("api status: %v", m.truncate(string(body))))
+		}
+	}
+
+	if m.grpc != nil {
+		m.failures = append(m.failures, m.grpc.match(resp)...)
+	}
+
+	if m.maxDuration != nil {
+		if d, ok := Duration(resp); !ok {
+			m.failures = append(m.failures, fmt.Errorf("duration of request is unknown"))
+		} else if d > *m.maxDuration {
+			m.failures = append(m.failures, fmt.Errorf("request is too slow, expected: at most %v, actual: %v", *m.maxDuration, d))
+		}
+	}
+
+	for i := range m.headers {
+		if err := m.headers[i].match(resp); err != nil {
+			m.failures = append(m.failures, err)
+		}
+	}
+
+	for i := range m.cookies {
+		if err := m.cookies[i].match(resp); err != nil {
+			m.failures = append(m.failures, err)
+		}
+	}
+
+	if m.bodySize != nil && size != *m.bodySize {
+		m.failures = append(m.failures, fmt.Errorf("size of body is not matched, expected: %v bytes, actual: %v bytes", *m.bodySize, size))
+	}
+
+	if m.stream {
+		if m.checksum != nil {
+			if err := m.checksum.matchSum(sum); err != nil {
+				m.failures = append(m.failures, err)
+			}
+		}
+		return m.matchWithoutBody(resp)
 	}
 
 	if m.emptyBody && len(body) != 0 {
-		m.failures = append(m.failures, fmt.Errorf("body should be empty, actual: %v", string(body)))
+		m.failures = append(m.failures, fmt.Errorf("body should be empty, actual: %v", m.truncate(string(body))))
+	}
+
+	if m.checksum != nil {
+		if err := m.checksum.match(body); err != nil {
+			m.failures = append(m.failures, err)
+		}
+	}
+
+	if m.binaryBody != nil {
+		if err := matchBytes(m.binaryBody, body); err != nil {
+			m.failures = append(m.failures, err)
+		}
+	}
+
+	if m.isJSON {
+		// body is only parsed, and values are not decoded
+		if err := json.Unmarshal(body, &json.RawMessage{}); err != nil {
+			m.failures = append(m.failures, fmt.Errorf("body is not valid json: %v", err))
+		}
+	}
+
+	// e.g. response of HEAD request or 204, body is neither decoded nor
+	// validated, and only status, headers and cookies are matched
+	if len(body) == 0 && m.messagesMatcher == nil {
+		if m.bodyMatcher != nil || m.xmlBody != nil || m.schema != nil {
+			m.failures = append(m.failures, fmt.Errorf("body is expected, but response has no body"))
+		}
+		// only responses which can't have a body are allowed to skip
+		// absent fields and transforms
+		if !hasNoBody(resp) && (len(m.absentFields) != 0 || len(m.transforms) != 0) {
+			m.failures = append(m.failures, fmt.Errorf("body is expected by absent fields or transforms, but response with status %v has no body", resp.StatusCode))
+		}
+		if m.golden != nil {
+			if err := m.golden.match(body, m.ignoreFields); err != nil {
+				m.failures = append(m.failures, err)
+			}
+		}
+		return m.matchWithoutBody(resp)
+	}
+
+	if m.xmlBody == nil && m.messagesMatcher == nil && m.binaryBody == nil {
+		// body is decoded by content type before it is matched as json
+		decoded, err := decode(resp.Header.Get("Content-Type"), body)
+		if err != nil {
+			m.failures = append(m.failures, err)
+			return false, nil
+		}
+		body = decoded
+		if req := resp.Request; req != nil {
+			for _, v := range m.validators {
+				m.failures = append(m.failures, v.Validate(req.Method, req.URL.Path, resp.StatusCode, resp.Header.Get("Content-Type"), body)...)
+			}
+		}
+		if len(m.transforms) != 0 {
+			transformed, err := transformBody(body, m.transforms)
+			if err != nil {
+				m.failures = append(m.failures, err)
+				return false, nil
+			}
+			body = transformed
+		}
+	}
+
+	if len(m.absentFields) != 0 {
+		m.failures = append(m.failures, matchAbsentFields(body, m.absentFields)...)
+	}
+
+	if m.schema != nil {
+		m.failures = append(m.failures, validateSchema(m.schema, body)...)
+	}
+
+	if m.golden != nil {
+		if err := m.golden.match(body, m.ignoreFields); err != nil {
+			m.failures = append(m.failures, err)
+		}
+	}
+
+	if m.messagesMatcher != nil {
+		msgs := []interface{}{}
+		if err := json.Unmarshal(body, &msgs); err != nil {
+			m.failures = append(m.failures, fmt.Errorf("can't unmarshal received messages: %v", err))
+			return false, nil
+		}
+		matched, err := m.messagesMatcher.Match(msgs)
+		if err != nil {
+			m.failures = append(m.failures, fmt.Errorf("can't match messages: %v", err))
+		} else if !matched {
+			m.failures = append(m.failures, fmt.Errorf("can't match messages: \n%v",
+				indent.Indent(m.messagesMatcher.FailureMessage(msgs), "\t")))
+		}
+	}
+
+	if m.xmlBody != nil {
+		if n, err := xmlutil.Parse(body); err != nil {
+			m.failures = append(m.failures, fmt.Errorf("can't parse body as xml: %v", err))
+		} else {
+			m.failures = append(m.failures, xmlutil.Diff(m.xmlBody, n)...)
+		}
 	}
 
 	if m.bodyMatcher != nil {
 		b := map[string]interface{}{}
 		if err := json.Unmarshal(body, &b); err != nil {
-			m.failures = append(m.failures, fmt.Errorf("can't unmarshal body to json, NOW only json Content-Type is supported"))
+			m.failures = append(m.failures, fmt.Errorf("can't unmarshal body to json, decoder of content type %v may not be registered", resp.Header.Get("Content-Type")))
 			return false, nil
 
 		}
+		for _, p := range m.ignoreFields {
+			// paths have been validated by expected body
+			v, _ := jsonutil.DeleteByJSONPath(b, p)
+			b, _ = v.(map[string]interface{})
+		}
 		if err := func() error {
 			matched, err := m.bodyMatcher.Match(b)
 			if err != nil {
 				return err
 			} else if !matched {
-				return errors.New(
-					indent.Indent(m.bodyMatcher.FailureMessage(b), "\t"),
-				)
+				return m.bodyFailure(b)
 			}
 			return nil
 		}(); err != nil {
@@ -129,14 +573,76 @@ func (m *ResponseMatcher) Match(actual interface{}) (bool, error) {
 	if len(m.failures) > 0 {
 		return false, nil
 	}
+	return m.define(resp, body)
+}
+
+// matchWithoutBody returns result of response whose body is empty
+// Variables can only be defined by headers and cookies
+func (m *ResponseMatcher) matchWithoutBody(resp *http.Response) (bool, error) {
+	if len(m.failures) > 0 {
+		return false, nil
+	}
+	for _, def := range m.defs {
+		if def.Header == "" && def.Cookie == "" {
+			m.failures = append(m.failures, fmt.Errorf("can't define variable %v: response has no body", def.Name))
+		}
+	}
+	if len(m.failures) > 0 {
+		return false, nil
+	}
+	return m.define(resp, nil)
+}
 
+// define defines variables of round trip from response
+func (m *ResponseMatcher) define(resp *http.Response, body []byte) (bool, error) {
 	m.vars = map[string]template.Variable{}
 	isErr := false
 	for _, def := range m.defs {
-		v, err := jsonutil.GetVariable(body, &def)
+		var v *template.Variable
+		var err error
+		if def.Header != "" {
+			values, ok := resp.Header[http.CanonicalHeaderKey(def.Header)]
+			if !ok && def.Optional {
+				continue
+			}
+			if !ok {
+				m.failures = append(m.failures, fmt.Errorf("can't define variable %v: header %v is not found in response", def.Name, def.Header))
+				isErr = true
+				continue
+			}
+			v = &template.Variable{
+				Raw:  []byte(values[0]),
+				Name: def.Name,
+				Type: template.StringType,
+			}
+		} else if def.Cookie != "" {
+			c := findCookie(resp, def.Cookie)
+			if c == nil && def.Optional {
+				continue
+			}
+			if c == nil {
+				m.failures = append(m.failures, fmt.Errorf("can't define variable %v: cookie %v is not set by response", def.Name, def.Cookie))
+				isErr = true
+				continue
+			}
+			v = &template.Variable{
+				Raw:  []byte(c.Value),
+				Name: def.Name,
+				Type: template.StringType,
+			}
+		} else if def.XPath != "" {
+			v, err = xmlutil.GetVariable(body, &def)
+		} else {
+			v, err = jsonutil.GetVariable(body, &def)
+		}
+		if err == nil {
+			v.Secret = def.Secret
+			err = jsonutil.Cast(v, def.Type)
+		}
 		if err != nil {
 			m.failures = append(m.failures, err)
 			isErr = true
+			continue
 		}
 		m.vars[def.Name] = *v
 	}
@@ -147,6 +653,16 @@ func (m *ResponseMatcher) Match(actual interface{}) (bool, error) {
 	return true, nil
 }
 
+// hasNoBody returns true if response can't have a body, i.e. response
+// of HEAD request or response with status 1xx, 204 or 304
+func hasNoBody(resp *http.Response) bool {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return true
+	}
+	code := resp.StatusCode
+	return code/100 == 1 || code == http.StatusNoContent || code == http.StatusNotModified
+}
+
 // FailureMessage implements gomegatypes.GomegaMatcher
 func (m *ResponseMatcher) FailureMessage(actual interface{}) (message string) {
 	failures := make([]string, len(m.failures))
@@ -0,0 +1,115 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchWithoutBody(t *testing.T) {
+	cases := []struct {
+		method   string
+		code     int
+		response string
+		failure  string
+	}{
+		{http.MethodHead, http.StatusOK, `{"statusCode": 200, "headers": {"Content-Length": "42"}}`, ""},
+		{http.MethodHead, http.StatusOK, `{"statusCode": 200, "headers": {"ETag": {"$exists": true}}}`, "header Etag is not found in response"},
+		{http.MethodHead, http.StatusOK, `{"statusCode": 200, "absentFields": ["$.password"]}`, ""},
+		{http.MethodHead, http.StatusOK, `{"statusCode": 200, "body": {"id": 1}}`, "body is expected, but response has no body"},
+		{http.MethodDelete, http.StatusNoContent, `{"statusCode": 204, "transforms": [{"path": "$.data", "type": "json"}]}`, ""},
+		{http.MethodGet, http.StatusNotModified, `{"statusCode": 304}`, ""},
+		{http.MethodGet, http.StatusAccepted, `{"statusCode": 202, "absentFields": ["$.password"]}`, "body is expected by absent fields or transforms, but response with status 202 has no body"},
+		{http.MethodGet, http.StatusOK, `{"statusCode": 200, "transforms": [{"path": "$.data", "type": "json"}]}`, "body is expected by absent fields or transforms"},
+		{http.MethodDelete, http.StatusNoContent, `{"statusCode": 204, "body": ""}`, ""},
+		{http.MethodDelete, http.StatusNoContent, `{"statusCode": 204, "bodyChecksum": "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}`, ""},
+		{http.MethodDelete, http.StatusNoContent, `{"statusCode": 200}`, "status code is not matched"},
+		{http.MethodGet, http.StatusOK, `{"statusCode": 200, "jsonSchema": {"type": "object"}}`, "body is expected, but response has no body"},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.response), &rt.Response), c.response) {
+			continue
+		}
+		rt.Definitions = []types.Definition{{Name: "length", Header: "Content-Length", Optional: true}}
+		m, err := MatchResponse(&types.Context{}, rt)
+		if !assert.NoError(t, err, c.response) {
+			continue
+		}
+		req, _ := http.NewRequest(c.method, "http://localhost/products/1", nil)
+		resp := &http.Response{
+			StatusCode: c.code,
+			Header: http.Header{
+				"Content-Type":   []string{"application/json"},
+				"Content-Length": []string{"42"},
+			},
+			Body:    ioutil.NopCloser(strings.NewReader("")),
+			Request: req,
+		}
+		matched, err := m.Match(resp)
+		assert.NoError(t, err, c.response)
+		if c.failure == "" {
+			assert.True(t, matched, "%v: %v", c.response, m.FailureMessage(resp))
+			continue
+		}
+		assert.False(t, matched, c.response)
+		assert.Contains(t, m.FailureMessage(resp), c.failure, c.response)
+	}
+
+	rt := &types.RoundTrip{
+		Response:    types.Response{StatusCode: types.NewStatusCode(http.StatusOK)},
+		Definitions: []types.Definition{{Name: "id", Selector: []string{"id"}}},
+	}
+	m, err := MatchResponse(&types.Context{}, rt)
+	assert.NoError(t, err)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	matched, err := m.Match(resp)
+	assert.NoError(t, err)
+	assert.False(t, matched)
+	assert.Contains(t, m.FailureMessage(resp), "can't define variable id: response has no body")
+}
+
+func TestMatchIsJSON(t *testing.T) {
+	cases := []struct {
+		body    string
+		failure string
+	}{
+		{`{"items": [1, 2, {"id": "a"}]}`, ""},
+		{`[]`, ""},
+		{`"text"`, ""},
+		{`{"items": [1, 2`, "body is not valid json: unexpected end of JSON input"},
+		{`{"id": 1} {"id": 2}`, "body is not valid json: invalid character '{' after top-level value"},
+		{`<html></html>`, "body is not valid json: invalid character '<' looking for beginning of value"},
+		{``, "body is not valid json: unexpected end of JSON input"},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{
+			Response: types.Response{StatusCode: types.NewStatusCode(http.StatusOK), IsJSON: true},
+		}
+		m, err := MatchResponse(&types.Context{}, rt)
+		if !assert.NoError(t, err, c.body) {
+			continue
+		}
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(c.body)),
+		}
+		matched, err := m.Match(resp)
+		assert.NoError(t, err, c.body)
+		if c.failure == "" {
+			assert.True(t, matched, "%v: %v", c.body, m.FailureMessage(resp))
+			continue
+		}
+		assert.False(t, matched, c.body)
+		assert.Contains(t, m.FailureMessage(resp), c.failure, c.body)
+	}
+}
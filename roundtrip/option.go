@@ -0,0 +1,177 @@
+package roundtrip
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Option defines option of client
+type Option func(c *Client)
+
+// WithTLSConfig sets tls config of client
+// It is used for both http and websocket
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config
+		// transport adds http2 to config, so it is cloned
+		c.transport.TLSClientConfig = config.Clone()
+		c.dialer.TLSClientConfig = config
+	}
+}
+
+// WithTransport replaces default transport of http requests
+// e.g. transport for tracing or record/replay
+// NOTE: tls, proxy, timeout and keep alive options will not affect the
+// transport, and its connections are not counted
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.c.Transport = rt
+	}
+}
+
+// WithDialTimeout sets timeout of connecting to server, 30s by default
+// It is also used by websocket, and zero means no timeout
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.netDialer.Timeout = timeout
+	}
+}
+
+// WithDisableKeepAlives opens a new connection for each http request
+// e.g. to exercise connection setup of server, connections are reused
+// by default
+func WithDisableKeepAlives() Option {
+	return func(c *Client) {
+		c.transport.DisableKeepAlives = true
+	}
+}
+
+// WithTLSHandshakeTimeout sets timeout of tls handshake of http
+// requests, 10s by default, and zero means no timeout
+func WithTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.transport.TLSHandshakeTimeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout sets timeout of waiting for response headers
+// after request is written, body of response is not limited by it
+// There is no timeout by default
+func WithResponseHeaderTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.transport.ResponseHeaderTimeout = timeout
+	}
+}
+
+var insecureOnce sync.Once
+
+// WithInsecureSkipVerify skips verifying certificate of server
+// It should only be used for self-signed staging environments
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) {
+		insecureOnce.Do(func() {
+			log.Println("WARNING: InsecureSkipVerify is enabled, certificate of server will not be verified")
+		})
+		// config is cloned so that config passed by WithTLSConfig
+		// is not modified
+		config := &tls.Config{}
+		if c.tlsConfig != nil {
+			config = c.tlsConfig.Clone()
+		}
+		config.InsecureSkipVerify = true
+		WithTLSConfig(config)(c)
+	}
+}
+
+// LoadTLSConfig returns tls config from files
+// certFile and keyFile are used for client certificate
+// caFile is a CA bundle to verify server
+// Empty path will be ignored
+func LoadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read CA bundle: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("can't parse CA bundle %v", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// WithProxy sets proxy of client
+// Hosts listed in NO_PROXY environment variable will not be proxied
+// By default, proxy is from HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+func WithProxy(proxy *url.URL) Option {
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	fn := func(req *http.Request) (*url.URL, error) {
+		if isNoProxy(noProxy, req.URL.Host) {
+			return nil, nil
+		}
+		return proxy, nil
+	}
+	return func(c *Client) {
+		c.transport.Proxy = fn
+		c.dialer.Proxy = fn
+	}
+}
+
+// isNoProxy returns true if host matches any one in noProxy
+// noProxy is a comma separated list of host, domain suffix or CIDR
+// e.g. "localhost,.example.com,10.0.0.0/8"
+func isNoProxy(noProxy, host string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.ToLower(hostname)
+	ip := net.ParseIP(hostname)
+	for _, p := range strings.Split(noProxy, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "":
+			continue
+		case p == "*":
+			return true
+		case p == host || p == hostname:
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(p); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if !strings.HasPrefix(p, ".") {
+			p = "." + p
+		}
+		if strings.HasSuffix(hostname, p) || hostname == p[1:] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package roundtrip
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNoProxy(t *testing.T) {
+	cases := []struct {
+		noProxy string
+		host    string
+		matched bool
+	}{
+		{"", "example.com", false},
+		{"*", "example.com", true},
+		{"example.com", "example.com", true},
+		{"example.com", "api.example.com:8080", true},
+		{".example.com", "example.com", true},
+		{".example.com", "api.example.com", true},
+		{"example.com", "badexample.com", false},
+		{"localhost:8080", "localhost:8080", true},
+		{"localhost:8080", "localhost:9090", false},
+		{"foo.com, 10.0.0.0/8", "10.1.2.3:80", true},
+		{"10.0.0.0/8", "192.168.1.1", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.matched, isNoProxy(c.noProxy, c.host), "noProxy: %v, host: %v", c.noProxy, c.host)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	c := NewClient("localhost")
+	assert.Equal(t, 30*time.Second, c.netDialer.Timeout)
+	assert.Equal(t, 10*time.Second, c.transport.TLSHandshakeTimeout)
+	assert.Equal(t, time.Duration(0), c.transport.ResponseHeaderTimeout)
+	assert.NotNil(t, c.dialer.NetDialContext)
+
+	c = NewClient("localhost", WithDialTimeout(time.Second), WithTLSHandshakeTimeout(2*time.Second), WithResponseHeaderTimeout(3*time.Second))
+	assert.Equal(t, time.Second, c.netDialer.Timeout)
+	assert.NotNil(t, c.dialer.NetDialContext)
+	assert.Equal(t, 2*time.Second, c.transport.TLSHandshakeTimeout)
+	assert.Equal(t, 3*time.Second, c.transport.ResponseHeaderTimeout)
+
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer s.Close()
+	defer close(done)
+	api, err := types.NewTemplate("GET /slow")
+	assert.NoError(t, err)
+	c = NewClient(s.URL, WithResponseHeaderTimeout(50*time.Millisecond))
+	_, err = c.DoRequest(&types.Context{}, &types.RoundTrip{Request: types.Request{API: api}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timeout awaiting response headers")
+	}
+}
+
+func TestTLSOptions(t *testing.T) {
+	c := NewClient("localhost")
+	assert.True(t, c.transport.ForceAttemptHTTP2)
+	assert.Nil(t, c.tlsConfig)
+
+	config := &tls.Config{ServerName: "api.example.com"}
+	c = NewClient("localhost", WithTLSConfig(config), WithInsecureSkipVerify())
+	// config of caller is not modified
+	assert.False(t, config.InsecureSkipVerify)
+	assert.True(t, c.transport.TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, "api.example.com", c.transport.TLSClientConfig.ServerName)
+	assert.Equal(t, c.tlsConfig, c.dialer.TLSClientConfig)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+	config = &tls.Config{RootCAs: s.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+	c = NewClient(s.Listener.Addr().String(), WithTLSConfig(config))
+	api, err := types.NewTemplate("GET /")
+	assert.NoError(t, err)
+	resp, err := c.DoRequest(&types.Context{}, &types.RoundTrip{Request: types.Request{API: api}})
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+	}
+	assert.Empty(t, config.NextProtos)
+}
@@ -0,0 +1,45 @@
+package roundtrip
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// expandPath replaces path params like {id} in path by rendered and
+// escaped values of params, query of path is not expanded
+// All params in path should be defined even if no params are defined,
+// and params which are not used are ignored, e.g. params for all cases
+// in preset
+func expandPath(ctx *types.Context, path string, params map[string]types.Template) (string, error) {
+	query := ""
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, query = path[:i], path[i:]
+	}
+	expanded := ""
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("path param of %v is not closed", path)
+		}
+		end += start
+		name := path[start+1 : end]
+		t, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("path param %v is not defined", name)
+		}
+		v, err := t.Render(ctx.Variables)
+		if err != nil {
+			return "", fmt.Errorf("can't render path param %v: %v", name, err)
+		}
+		expanded += path[:start] + url.PathEscape(v)
+		path = path[end+1:]
+	}
+	return expanded + path + query, nil
+}
@@ -0,0 +1,51 @@
+package roundtrip
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPath(t *testing.T) {
+	ctx := &types.Context{
+		Variables: map[string]template.Variable{
+			"id": {Raw: []byte("a/b c"), Name: "id", Type: template.StringType},
+		},
+	}
+	params := map[string]types.Template{}
+	for k, v := range map[string]string{
+		"id":     "%{id}",
+		"postId": "1",
+		"tenant": "t",
+	} {
+		tmpl, err := types.NewTemplate(v)
+		assert.NoError(t, err)
+		params[k] = *tmpl
+	}
+	cases := []struct {
+		path     string
+		params   map[string]types.Template
+		expected string
+		hasError bool
+	}{
+		{"/users/{id}/posts/{postId}", params, "/users/a%2Fb%20c/posts/1", false},
+		{"/posts/{postId}?sort={id}", params, "/posts/1?sort={id}", false},
+		{"/users", params, "/users", false},
+		{"/users", nil, "/users", false},
+		{"/users?filter={id}", nil, "/users?filter={id}", false},
+		{"/users/{id}", nil, "", true},
+		{"/users/{name}", params, "", true},
+		{"/users/{id", params, "", true},
+	}
+	for _, c := range cases {
+		expanded, err := expandPath(ctx, c.path, c.params)
+		if c.hasError {
+			assert.Error(t, err, c.path)
+			continue
+		}
+		assert.NoError(t, err, c.path)
+		assert.Equal(t, c.expected, expanded, c.path)
+	}
+}
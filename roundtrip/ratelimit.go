@@ -0,0 +1,112 @@
+package roundtrip
+
+import (
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits rate of all requests of client to rps requests
+// per second, at most burst requests can be sent at once
+// Limit is disabled if rps is not positive
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter.global = newTokenBucket(rps, burst)
+	}
+}
+
+// WithHostRateLimit limits rate of requests to host like WithRateLimit
+// Host is matched with host of url with or without port, and requests
+// are limited by both limits of host and client
+func WithHostRateLimit(host string, rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter.hosts[strings.ToLower(host)] = newTokenBucket(rps, burst)
+	}
+}
+
+// rateLimiter limits rate of requests of client
+type rateLimiter struct {
+	global *tokenBucket
+	hosts  map[string]*tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		hosts: map[string]*tokenBucket{},
+	}
+}
+
+// wait blocks until a request to host is allowed
+func (l *rateLimiter) wait(host string) {
+	time.Sleep(l.reserve(host, time.Now()))
+}
+
+// reserve takes tokens of a request to host and returns
+// duration to wait before the request is allowed
+func (l *rateLimiter) reserve(host string, now time.Time) time.Duration {
+	var d time.Duration
+	for _, b := range []*tokenBucket{l.global, l.hostBucket(host)} {
+		if b == nil {
+			continue
+		}
+		if wait := b.reserve(now); wait > d {
+			d = wait
+		}
+	}
+	return d
+}
+
+func (l *rateLimiter) hostBucket(host string) *tokenBucket {
+	host = strings.ToLower(host)
+	if b, ok := l.hosts[host]; ok {
+		return b
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return l.hosts[hostname]
+	}
+	return nil
+}
+
+// tokenBucket is a token bucket which is refilled at rate per second
+// Tokens can be borrowed, and borrowed tokens are waited for
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket, or nil if rate is not positive
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes a token at now and returns duration to wait for it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,50 @@
+package roundtrip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(2, 2)
+	cases := []struct {
+		after    time.Duration
+		expected time.Duration
+	}{
+		{0, 0},
+		{0, 0},
+		{0, 500 * time.Millisecond},
+		{0, time.Second},
+		{time.Second, 500 * time.Millisecond},
+		{10 * time.Second, 0},
+		{0, 0},
+		{0, 500 * time.Millisecond},
+	}
+	for i, c := range cases {
+		now = now.Add(c.after)
+		assert.Equal(t, c.expected, b.reserve(now), "reservation %v", i)
+	}
+	assert.Nil(t, newTokenBucket(0, 1))
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter()
+	l.global = newTokenBucket(10, 1)
+	l.hosts["example.com"] = newTokenBucket(1, 1)
+	cases := []struct {
+		host     string
+		expected time.Duration
+	}{
+		{"example.com:8080", 0},
+		{"localhost:8080", 100 * time.Millisecond},
+		{"EXAMPLE.com", time.Second},
+		{"localhost", 300 * time.Millisecond},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, l.reserve(c.host, now), c.host)
+	}
+}
@@ -0,0 +1,70 @@
+package roundtrip
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/onsi/ginkgo"
+)
+
+var (
+	// defaultRetryInterval defines default initial interval of retry
+	defaultRetryInterval = 100 * time.Millisecond
+)
+
+// doRequestWithRetry sends request until it is not retryable
+// or max attempts is reached. Response of the last attempt is returned
+func (c *Client) doRequestWithRetry(ctx *types.Context, reqConf *types.Request) (*http.Response, error) {
+	policy := reqConf.Retry
+	interval := defaultRetryInterval
+	if policy.Interval != nil {
+		interval = policy.Interval.Duration
+	}
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, reqConf)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.send(ctx, req, reqConf)
+		reason := retryReason(policy, resp, err)
+		if reason == "" || attempt >= policy.MaxAttempts {
+			if attempt > 1 {
+				ginkgo.By(fmt.Sprintf("Request is finished after %v attempts", attempt))
+			}
+			return resp, err
+		}
+		if resp != nil {
+			// drain body so that connection can be reused
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		ginkgo.By(fmt.Sprintf("Retry request after %v (attempt %v/%v): %v",
+			interval, attempt+1, policy.MaxAttempts, template.Mask(reason, ctx.Variables)))
+		time.Sleep(interval)
+		interval = time.Duration(float64(interval) * multiplier)
+	}
+}
+
+// retryReason returns why the request should be retried
+// Empty string means request should not be retried
+func retryReason(policy *types.Retry, resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	for _, code := range policy.StatusCodes {
+		if resp.StatusCode == code {
+			return fmt.Sprintf("status code %v is retryable", code)
+		}
+	}
+	return ""
+}
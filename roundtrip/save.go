@@ -0,0 +1,59 @@
+package roundtrip
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// SaveResponse writes response into file defined by conf
+// Relative path is joined with dir, and body of response
+// can still be read after it is saved
+func SaveResponse(ctx *types.Context, conf *types.SaveResponse, dir string, resp *http.Response) error {
+	if conf.Path == nil {
+		return fmt.Errorf("path of saved response can not be empty")
+	}
+	path, err := conf.Path.Render(ctx.Variables)
+	if err != nil {
+		return fmt.Errorf("can't render path of saved response: %v", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("can't read body from response: %v", err)
+	}
+	restored := ioutil.NopCloser(bytes.NewReader(body))
+	if b, ok := resp.Body.(*timedBody); ok {
+		// keep duration of request
+		b.ReadCloser = restored
+	} else {
+		resp.Body = restored
+	}
+
+	buf := bytes.Buffer{}
+	if conf.Headers {
+		fmt.Fprintf(&buf, "%v %v\r\n", resp.Proto, resp.Status)
+		if err := resp.Header.Write(&buf); err != nil {
+			return err
+		}
+		buf.WriteString("\r\n")
+	}
+	buf.Write(body)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("can't create dir of saved response: %v", err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("can't save response to %v: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package roundtrip
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// event is a parsed server-sent event
+type event struct {
+	Event string          `json:"event,omitempty"`
+	ID    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// doSSE connects to an event stream and reads events until all
+// expected events are received. Received events are returned as
+// a json array in response body like messages of websocket
+func (c *Client) doSSE(ctx *types.Context, rt *types.RoundTrip) (*http.Response, error) {
+	reqConf := &rt.Request
+	req, err := c.newRequest(ctx, reqConf)
+	if err != nil {
+		return nil, err
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	timeout := defaultMessageTimeout
+	if ev := rt.Response.Eventually; ev != nil && ev.Timeout != nil {
+		timeout = ev.Timeout.Duration
+	}
+	reqCtx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+	c.limiter.wait(req.URL.Host)
+	record(ctx, req)
+	resp, err := c.httpClient(ctx, reqConf).Do(req.WithContext(reqCtx))
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// body of error response is matched as it is
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+
+	expected := len(rt.Response.Messages)
+	events := make([]event, 0, expected)
+	r := bufio.NewReader(resp.Body)
+	for i := 0; i < expected; i++ {
+		e, err := readEvent(r)
+		if err != nil {
+			return nil, fmt.Errorf("expected %v events in %v, but only received %v: %v", expected, timeout, i, err)
+		}
+		events = append(events, *e)
+	}
+
+	body, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// readEvent reads the next event which has data from stream
+// Data which is valid json is kept, others are converted to json string
+func readEvent(r *bufio.Reader) (*event, error) {
+	e := event{}
+	data := []string{}
+	hasData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !hasData {
+				// events without data are not dispatched
+				e = event{}
+				continue
+			}
+			raw := strings.Join(data, "\n")
+			if json.Valid([]byte(raw)) {
+				e.Data = json.RawMessage(raw)
+			} else {
+				e.Data, _ = json.Marshal(raw)
+			}
+			return &e, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment, e.g. heartbeat
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i != -1 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			e.Event = value
+		case "id":
+			e.ID = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		}
+	}
+}
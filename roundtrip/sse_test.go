@@ -0,0 +1,35 @@
+package roundtrip
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEvent(t *testing.T) {
+	stream := ": heartbeat\n\n" +
+		"event: created\nid: 1\ndata: {\"id\": \"p1\"}\n\n" +
+		"event: ping\n\n" +
+		"data: hello\ndata:world\r\n\r\n" +
+		"retry: 1000\ndata: [1, 2]\n\n" +
+		"data: incomplete"
+	r := bufio.NewReader(strings.NewReader(stream))
+	expected := []string{
+		`{"event":"created","id":"1","data":{"id": "p1"}}`,
+		`{"data":"hello\nworld"}`,
+		`{"data":[1, 2]}`,
+	}
+	for _, e := range expected {
+		actual, err := readEvent(r)
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, _ := json.Marshal(actual)
+		assert.JSONEq(t, e, string(b))
+	}
+	_, err := readEvent(r)
+	assert.Error(t, err)
+}
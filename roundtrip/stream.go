@@ -0,0 +1,50 @@
+package roundtrip
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// validateStream returns error if fields which need buffered body
+// are used with stream mode
+func validateStream(rt *types.RoundTrip) error {
+	respConf := &rt.Response
+	for _, f := range []struct {
+		name string
+		used bool
+	}{
+		{"body", respConf.Body != nil},
+		{"jsonSchema", respConf.JSONSchema != nil},
+		{"golden", respConf.Golden != nil},
+		{"isJSON", respConf.IsJSON},
+		{"absentFields", len(respConf.AbsentFields) != 0},
+		{"transforms", len(respConf.Transforms) != 0},
+		{"saveResponse", rt.SaveResponse != nil},
+	} {
+		if f.used {
+			return fmt.Errorf("%v can't be used with stream", f.name)
+		}
+	}
+	if p := rt.Request.Protocol; p == types.WebSocketProtocol || p == types.SSEProtocol {
+		return fmt.Errorf("stream can't be used with %v", p)
+	}
+	return nil
+}
+
+// streamBody reads body without buffering it and returns its size,
+// and its sum if checksum is not nil
+func streamBody(body io.Reader, c *checksum) (int64, []byte, error) {
+	if c == nil {
+		n, err := io.Copy(ioutil.Discard, body)
+		return n, nil, err
+	}
+	h := c.hash()
+	n, err := io.Copy(h, body)
+	if err != nil {
+		return n, nil, err
+	}
+	return n, h.Sum(nil), nil
+}
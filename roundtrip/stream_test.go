@@ -0,0 +1,84 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReader records read bytes and max size of reads
+type countingReader struct {
+	r    *strings.Reader
+	read int
+	max  int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if len(p) > r.max {
+		r.max = len(p)
+	}
+	n, err := r.r.Read(p)
+	r.read += n
+	return n, err
+}
+
+func TestMatchStream(t *testing.T) {
+	body := strings.Repeat("a", 1<<20)
+	cases := []struct {
+		response string
+		failure  string
+	}{
+		{`{"statusCode": 200, "stream": true, "bodySize": 1048576}`, ""},
+		{`{"statusCode": 200, "stream": true, "bodyChecksum": "sha256:9bc1b2a288b26af7257a36277ae3816a7d4f16e89c1e7e77d0a5c48bad62b360"}`, ""},
+		{`{"statusCode": 200, "stream": true, "bodySize": 1, "headers": {"Content-Type": "application/zip"}}`, "size of body is not matched, expected: 1 bytes, actual: 1048576 bytes"},
+		{`{"statusCode": 200, "stream": true, "bodyChecksum": "md5:00"}`, "checksum of body is not matched"},
+		{`{"statusCode": 201, "stream": true}`, "status code is not matched"},
+	}
+	for _, c := range cases {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.response), &rt.Response), c.response) {
+			continue
+		}
+		rt.Definitions = []types.Definition{{Name: "type", Header: "Content-Type"}}
+		m, err := MatchResponse(&types.Context{}, rt)
+		if !assert.NoError(t, err, c.response) {
+			continue
+		}
+		r := &countingReader{r: strings.NewReader(body)}
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/zip"}},
+			Body:       ioutil.NopCloser(r),
+		}
+		matched, err := m.Match(resp)
+		assert.NoError(t, err, c.response)
+		assert.Equal(t, len(body), r.read, c.response)
+		assert.True(t, r.max < len(body), "body should not be buffered: %v", c.response)
+		if c.failure == "" {
+			assert.True(t, matched, "%v: %v", c.response, m.FailureMessage(resp))
+			continue
+		}
+		assert.False(t, matched, c.response)
+		assert.Contains(t, m.FailureMessage(resp), c.failure, c.response)
+	}
+
+	for _, raw := range []string{
+		`{"response": {"stream": true, "body": {"id": 1}}}`,
+		`{"response": {"stream": true, "jsonSchema": {"type": "object"}}}`,
+		`{"response": {"stream": true, "isJSON": true}}`,
+		`{"response": {"stream": true}, "saveResponse": {"path": "a.zip"}}`,
+		`{"request": {"protocol": "websocket"}, "response": {"stream": true}}`,
+	} {
+		rt := &types.RoundTrip{}
+		if !assert.NoError(t, json.Unmarshal([]byte(raw), rt), raw) {
+			continue
+		}
+		_, err := MatchResponse(&types.Context{}, rt)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,69 @@
+package roundtrip
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/jsonutil"
+)
+
+// validateTransforms validates types of transforms
+func validateTransforms(ts []types.Transform) error {
+	for _, t := range ts {
+		switch t.Type {
+		case types.JSONTransform, types.Base64Transform:
+		default:
+			return fmt.Errorf("unknown type %v of transform %v", t.Type, t.Path)
+		}
+	}
+	return nil
+}
+
+// transformBody transforms fields of json body in order
+func transformBody(body []byte, ts []types.Transform) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("can't transform body: can't unmarshal body to json: %v", err)
+	}
+	for _, t := range ts {
+		var err error
+		v, err = jsonutil.UpdateByJSONPath(v, t.Path, func(field interface{}) (interface{}, error) {
+			return transformField(field, t.Type)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't transform %v by %v: %v", t.Path, t.Type, err)
+		}
+	}
+	return json.Marshal(v)
+}
+
+func transformField(field interface{}, typ types.TransformType) (interface{}, error) {
+	s, ok := field.(string)
+	if !ok {
+		return nil, fmt.Errorf("field should be a string, actual: %T", field)
+	}
+	switch typ {
+	case types.JSONTransform:
+		decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return nil, fmt.Errorf("field is not json: %v", err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("field is not json: unexpected data after value")
+		}
+		return v, nil
+	case types.Base64Transform:
+		data, err := decodeBase64(s)
+		if err != nil {
+			return nil, fmt.Errorf("field is not base64: %v", err)
+		}
+		return string(data), nil
+	}
+	return nil, fmt.Errorf("unknown type %v of transform", typ)
+}
@@ -0,0 +1,48 @@
+package roundtrip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caicloud/aloe/types"
+)
+
+func TestTransformBody(t *testing.T) {
+	cases := []struct {
+		body       string
+		transforms []types.Transform
+		expected   string
+		hasError   bool
+	}{
+		{
+			`{"data": "{\"id\": 12345678901234567890}"}`,
+			[]types.Transform{{Path: "$.data", Type: types.JSONTransform}},
+			`{"data": {"id": 12345678901234567890}}`, false,
+		},
+		{
+			`{"items": [{"payload": "eyJhIjogMX0="}, {"payload": "eyJhIjogMn0="}]}`,
+			[]types.Transform{
+				{Path: "$.items[*].payload", Type: types.Base64Transform},
+				{Path: "$.items[*].payload", Type: types.JSONTransform},
+			},
+			`{"items": [{"payload": {"a": 1}}, {"payload": {"a": 2}}]}`, false,
+		},
+		{`{"data": "{"}`, []types.Transform{{Path: "$.data", Type: types.JSONTransform}}, "", true},
+		{`{"data": "{} x"}`, []types.Transform{{Path: "$.data", Type: types.JSONTransform}}, "", true},
+		{`{"data": {}}`, []types.Transform{{Path: "$.data", Type: types.JSONTransform}}, "", true},
+		{`{"data": "!"}`, []types.Transform{{Path: "$.data", Type: types.Base64Transform}}, "", true},
+		{`{"data": "{}"}`, []types.Transform{{Path: "$.missing", Type: types.JSONTransform}}, "", true},
+		{`not json`, []types.Transform{{Path: "$.data", Type: types.JSONTransform}}, "", true},
+	}
+	for _, c := range cases {
+		body, err := transformBody([]byte(c.body), c.transforms)
+		if c.hasError {
+			assert.Error(t, err, c.body)
+			continue
+		}
+		if assert.NoError(t, err, c.body) {
+			assert.JSONEq(t, c.expected, string(body), c.body)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package roundtrip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caicloud/aloe/types"
+)
+
+// urlOf returns url of path in api, base url and path prefix
+// of request are used if they are set
+func (c *Client) urlOf(ctx *types.Context, reqConf *types.Request, path string) (string, error) {
+	base := c.baseURL()
+	if reqConf.BaseURL != nil {
+		rendered, err := reqConf.BaseURL.Render(ctx.Variables)
+		if err != nil {
+			return "", fmt.Errorf("can't render base url: %v", err)
+		}
+		base = c.baseURLOf(rendered)
+	}
+	prefix := ""
+	if reqConf.PathPrefix != nil {
+		rendered, err := reqConf.PathPrefix.Render(ctx.Variables)
+		if err != nil {
+			return "", fmt.Errorf("can't render path prefix: %v", err)
+		}
+		prefix = rendered
+	}
+	return joinURL(base, prefix, path), nil
+}
+
+// joinURL joins base url, path prefix and path with exactly one
+// slash between them, trailing slash and query of path are kept
+func joinURL(base, prefix, path string) string {
+	query := ""
+	if i := strings.IndexByte(path, '?'); i != -1 {
+		path, query = path[:i], path[i:]
+	}
+	u := strings.TrimSuffix(base, "/")
+	if p := strings.Trim(prefix, "/"); p != "" {
+		u += "/" + p
+	}
+	return u + "/" + strings.TrimLeft(path, "/") + query
+}
@@ -0,0 +1,27 @@
+package roundtrip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinURL(t *testing.T) {
+	cases := []struct {
+		base     string
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"http://localhost", "", "/products", "http://localhost/products"},
+		{"http://localhost/", "", "products", "http://localhost/products"},
+		{"http://localhost", "/api/v1", "/products", "http://localhost/api/v1/products"},
+		{"http://localhost/", "api/v1/", "products/", "http://localhost/api/v1/products/"},
+		{"http://localhost", "/api/v1/", "//products?page=1", "http://localhost/api/v1/products?page=1"},
+		{"http://localhost/api", "v2", "/", "http://localhost/api/v2/"},
+		{"http://localhost", "/", "/products/a/b", "http://localhost/products/a/b"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, joinURL(c.base, c.prefix, c.path), "%#v", c)
+	}
+}
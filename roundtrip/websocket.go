@@ -0,0 +1,161 @@
+package roundtrip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// defaultMessageTimeout defines default deadline of receiving
+	// all expected messages
+	defaultMessageTimeout = 1 * time.Second
+)
+
+// doWebSocket connects to a websocket endpoint, sends all messages
+// in request and waits for expected messages.
+// Received messages are returned as a json array in response body
+// so that they can be matched like a normal http response
+func (c *Client) doWebSocket(ctx *types.Context, rt *types.RoundTrip) (*http.Response, error) {
+	reqConf := &rt.Request
+	if reqConf.API == nil {
+		return nil, fmt.Errorf("api can not be empty")
+	}
+
+	api, err := reqConf.API.Render(ctx.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	method, path := splitMethodAndPath(api)
+	if method != http.MethodGet {
+		return nil, fmt.Errorf("websocket only supports GET, actual: %v", method)
+	}
+	path, err = expandPath(ctx, path, reqConf.PathParams)
+	if err != nil {
+		return nil, err
+	}
+	u := path
+	if !strings.HasPrefix(path, "ws://") && !strings.HasPrefix(path, "wss://") {
+		raw, err := c.urlOf(ctx, reqConf, path)
+		if err != nil {
+			return nil, err
+		}
+		// http => ws, https => wss
+		u = "ws" + strings.TrimPrefix(raw, "http")
+	}
+
+	header := http.Header{}
+	for k, v := range reqConf.Headers {
+		header.Set(k, v)
+	}
+	if len(reqConf.Hooks) != 0 {
+		// hooks modify handshake request like a http request
+		req, err := http.NewRequest(method, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header
+		if err := applyHooks(req, reqConf.Hooks); err != nil {
+			return nil, err
+		}
+		u, header = req.URL.String(), req.Header
+	}
+
+	dialer := *c.dialer
+	if !reqConf.DisableCookies {
+		dialer.Jar = ctx.CookieJar
+	}
+	if req, err := http.NewRequest(method, u, nil); err == nil {
+		c.limiter.wait(req.URL.Host)
+		req.Header = header
+		record(ctx, req)
+	}
+	conn, resp, err := dialer.Dial(u, header)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to websocket %v: %v", u, err)
+	}
+	defer conn.Close()
+
+	for i := range reqConf.Messages {
+		typ, data, err := renderMessage(ctx, &reqConf.Messages[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.WriteMessage(typ, data); err != nil {
+			return nil, fmt.Errorf("can't send message %v: %v", i, err)
+		}
+	}
+
+	timeout := defaultMessageTimeout
+	if ev := rt.Response.Eventually; ev != nil && ev.Timeout != nil {
+		timeout = ev.Timeout.Duration
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	expected := len(rt.Response.Messages)
+	msgs := make([]json.RawMessage, 0, expected)
+	for i := 0; i < expected; i++ {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("expected %v messages in %v, but only received %v: %v", expected, timeout, i, err)
+		}
+		msg, err := encodeMessage(typ, data)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	body, err := json.Marshal(msgs)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func renderMessage(ctx *types.Context, msg *types.Message) (int, []byte, error) {
+	rendered := ""
+	if msg.Body != nil {
+		s, err := msg.Body.Render(ctx.Variables)
+		if err != nil {
+			return 0, nil, err
+		}
+		rendered = s
+	}
+	switch msg.Type {
+	case "", types.TextMessage:
+		return websocket.TextMessage, []byte(rendered), nil
+	case types.BinaryMessage:
+		data, err := base64.StdEncoding.DecodeString(rendered)
+		if err != nil {
+			return 0, nil, fmt.Errorf("body of binary message should be base64 encoded: %v", err)
+		}
+		return websocket.BinaryMessage, data, nil
+	}
+	return 0, nil, fmt.Errorf("unknown message type %v", msg.Type)
+}
+
+// encodeMessage converts received message to json
+// text message which is valid json is kept, others are
+// converted to json string and binary message is base64 encoded
+func encodeMessage(typ int, data []byte) (json.RawMessage, error) {
+	if typ == websocket.BinaryMessage {
+		return json.Marshal(base64.StdEncoding.EncodeToString(data))
+	}
+	if json.Valid(data) {
+		return json.RawMessage(data), nil
+	}
+	return json.Marshal(string(data))
+}
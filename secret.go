@@ -0,0 +1,78 @@
+package framework
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/caicloud/aloe/roundtrip"
+	"github.com/caicloud/aloe/template"
+	"github.com/onsi/gomega"
+)
+
+// secretSet collects secret variables of all contexts
+// so that they can be masked in all outputs, e.g. reports
+type secretSet struct {
+	lock sync.RWMutex
+	vars map[string]template.Variable
+}
+
+func newSecretSet() *secretSet {
+	return &secretSet{
+		vars: map[string]template.Variable{},
+	}
+}
+
+// add adds secret variables of vs
+func (s *secretSet) add(vs map[string]template.Variable) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, v := range vs {
+		if v.Secret {
+			s.vars[v.String()] = v
+		}
+	}
+}
+
+// addValues adds secret values which are not variables
+// e.g. tokens got by presetters
+func (s *secretSet) addValues(values ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, v := range values {
+		if v != "" {
+			s.vars[v] = template.Variable{
+				Raw:    []byte(v),
+				Type:   template.StringType,
+				Secret: true,
+			}
+		}
+	}
+}
+
+// mask replaces values of secret variables in str
+func (s *secretSet) mask(str string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return template.Mask(str, s.vars)
+}
+
+// maskHandler masks secret variables in failure message of response handler
+type maskHandler struct {
+	roundtrip.ResponseHandler
+
+	secrets *secretSet
+}
+
+// FailureMessage implements gomegatypes.GomegaMatcher
+func (h *maskHandler) FailureMessage(actual interface{}) string {
+	return h.secrets.mask(h.ResponseHandler.FailureMessage(actual))
+}
+
+// expectNoError fails current case if err is not nil
+// Secret variables are masked in its message
+func (gf *genericFramework) expectNoError(err error) {
+	if err != nil {
+		err = errors.New(gf.secrets.mask(err.Error()))
+	}
+	gomega.ExpectWithOffset(1, err).NotTo(gomega.HaveOccurred())
+}
@@ -0,0 +1,65 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/preset"
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresetterSecrets(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, secret, _ := r.BasicAuth()
+		if secret != "s3cret" {
+			// some servers echo credentials in errors
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "invalid secret %v", secret)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok-123"})
+	}))
+	defer s.Close()
+
+	presetters := func(clientID, secret string) []types.PresetConfig {
+		args := map[string]types.Template{}
+		for k, v := range map[string]string{
+			preset.TokenURLArg:     s.URL,
+			preset.ClientIDArg:     clientID,
+			preset.ClientSecretArg: secret,
+		} {
+			tmpl, err := types.NewTemplate(v)
+			assert.NoError(t, err)
+			args[k] = *tmpl
+		}
+		return []types.PresetConfig{{Name: preset.OAuth2PresetterName, Args: args}}
+	}
+
+	gf := NewFrameworkWithOptions(s.URL, func() {}, nil).(*genericFramework)
+	ctx := &types.Context{
+		Variables:  map[string]template.Variable{},
+		Presetters: presetters("aloe", "s3cret"),
+	}
+	rt, err := gf.preset(ctx, &types.RoundTrip{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// token got by presetter is masked like secret variables
+	assert.Equal(t, "Bearer tok-123", rt.Request.Headers["Authorization"])
+	assert.Equal(t, "Bearer *** ***", gf.secrets.mask("Bearer tok-123 s3cret"))
+
+	// errors of presetters are masked in failures
+	ctx.Presetters = presetters("other", "wrong-secret")
+	failure := interceptFailure(func() {
+		_, err := gf.preset(ctx, &types.RoundTrip{})
+		gf.expectNoError(err)
+	})
+	assert.Contains(t, failure, "invalid secret ***")
+	assert.False(t, strings.Contains(failure, "wrong-secret"), failure)
+}
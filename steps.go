@@ -0,0 +1,69 @@
+package framework
+
+import (
+	"fmt"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/types"
+)
+
+// stepText returns text of a round trip in steps, reports and logs
+// Name of round trip is prefixed so that it can be correlated across runs
+func stepText(rt *types.RoundTrip) string {
+	if rt.Name == "" {
+		return rt.Description
+	}
+	return fmt.Sprintf("[%v] %v", rt.Name, rt.Description)
+}
+
+// skippedDependency returns a dependency of round trip which is not run
+// ran contains names of round trips which have been run in the flow
+func skippedDependency(rt *types.RoundTrip, ran map[string]bool) (string, bool) {
+	for _, dep := range rt.DependsOn {
+		if !ran[dep] {
+			return dep, true
+		}
+	}
+	return "", false
+}
+
+// validateFlows validates names and dependencies of round trips
+// in flows of contexts and cases in dir
+func validateFlows(dir *data.Dir) error {
+	if err := validateFlow(dir.Context.Flow); err != nil {
+		return fmt.Errorf("invalid flow of context %v: %v", dir.Path, err)
+	}
+	for name, f := range dir.Files {
+		if err := validateFlow(f.Case.Flow); err != nil {
+			return fmt.Errorf("invalid flow of case %v in %v: %v", name, dir.Path, err)
+		}
+	}
+	for _, d := range dir.Dirs {
+		if err := validateFlows(&d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFlow checks that names of round trips are unique and they
+// only depend on previous round trips in the same flow
+func validateFlow(flow []types.RoundTrip) error {
+	names := map[string]bool{}
+	for i := range flow {
+		rt := &flow[i]
+		for _, dep := range rt.DependsOn {
+			if !names[dep] {
+				return fmt.Errorf("step %v depends on %v which is not a previous step", i+1, dep)
+			}
+		}
+		if rt.Name == "" {
+			continue
+		}
+		if names[rt.Name] {
+			return fmt.Errorf("name %v of step %v is duplicated", rt.Name, i+1)
+		}
+		names[rt.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package framework
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlow returns a flow whose steps are named by names and depend
+// on deps of the same index
+func newFlow(names []string, deps [][]string) []types.RoundTrip {
+	flow := []types.RoundTrip{}
+	for i, name := range names {
+		rt := types.RoundTrip{Name: name}
+		if i < len(deps) {
+			rt.DependsOn = deps[i]
+		}
+		flow = append(flow, rt)
+	}
+	return flow
+}
+
+func TestValidateFlow(t *testing.T) {
+	cases := []struct {
+		description string
+		names       []string
+		deps        [][]string
+		err         string
+	}{
+		{
+			description: "dependencies on previous steps",
+			names:       []string{"login", "create", "", "get"},
+			deps:        [][]string{nil, {"login"}, {"create"}, {"login", "create"}},
+		},
+		{
+			description: "missing dependency",
+			names:       []string{"login", "create"},
+			deps:        [][]string{nil, {"signup"}},
+			err:         "step 2 depends on signup which is not a previous step",
+		},
+		{
+			description: "dependency on itself",
+			names:       []string{"login"},
+			deps:        [][]string{{"login"}},
+			err:         "step 1 depends on login which is not a previous step",
+		},
+		{
+			description: "cyclic dependencies",
+			names:       []string{"create", "get"},
+			deps:        [][]string{{"get"}, {"create"}},
+			err:         "step 1 depends on get which is not a previous step",
+		},
+		{
+			description: "duplicated names",
+			names:       []string{"login", "create", "login"},
+			err:         "name login of step 3 is duplicated",
+		},
+	}
+	for _, c := range cases {
+		err := validateFlow(newFlow(c.names, c.deps))
+		if c.err == "" {
+			assert.NoError(t, err, c.description)
+			continue
+		}
+		assert.EqualError(t, err, c.err, c.description)
+	}
+}
+
+func TestValidateFlows(t *testing.T) {
+	invalid := newFlow([]string{"create"}, [][]string{{"login"}})
+	dir := &data.Dir{
+		Path: "cases",
+		Dirs: map[string]data.Dir{
+			"products": {
+				Path:    "cases/products",
+				Context: types.ContextConfig{Flow: invalid},
+			},
+		},
+	}
+	assert.EqualError(t, validateFlows(dir), "invalid flow of context cases/products: step 1 depends on login which is not a previous step")
+
+	dir = &data.Dir{
+		Path: "cases",
+		Files: map[string]data.File{
+			"create.yaml": {Case: types.Case{Flow: invalid}},
+		},
+	}
+	assert.EqualError(t, validateFlows(dir), "invalid flow of case create.yaml in cases: step 1 depends on login which is not a previous step")
+}
+
+func TestSkippedDependency(t *testing.T) {
+	cases := []struct {
+		description string
+		deps        []string
+		ran         map[string]bool
+		dep         string
+		skipped     bool
+	}{
+		{"no dependency", nil, map[string]bool{}, "", false},
+		{"all dependencies are run", []string{"login", "create"}, map[string]bool{"login": true, "create": true}, "", false},
+		{"a dependency is skipped", []string{"login", "create"}, map[string]bool{"login": true}, "create", true},
+		{"the first skipped dependency is returned", []string{"login", "create"}, map[string]bool{}, "login", true},
+	}
+	for _, c := range cases {
+		dep, skipped := skippedDependency(&types.RoundTrip{DependsOn: c.deps}, c.ran)
+		assert.Equal(t, c.skipped, skipped, c.description)
+		assert.Equal(t, c.dep, dep, c.description)
+	}
+}
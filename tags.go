@@ -0,0 +1,73 @@
+package framework
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	// IncludeTagsEnv defines env of comma separated tags
+	// Only cases with any of the tags are run
+	IncludeTagsEnv = "ALOE_INCLUDE_TAGS"
+
+	// ExcludeTagsEnv defines env of comma separated tags
+	// Cases with any of the tags are not run
+	ExcludeTagsEnv = "ALOE_EXCLUDE_TAGS"
+)
+
+// WithIncludeTags only runs cases with any of tags
+// Tags of a case include tags of all its contexts
+func WithIncludeTags(tags ...string) Option {
+	return func(gf *genericFramework) {
+		gf.tags.include = append(gf.tags.include, tags...)
+	}
+}
+
+// WithExcludeTags doesn't run cases with any of tags
+// It takes precedence over included tags
+func WithExcludeTags(tags ...string) Option {
+	return func(gf *genericFramework) {
+		gf.tags.exclude = append(gf.tags.exclude, tags...)
+	}
+}
+
+// tagFilter filters cases by tags
+type tagFilter struct {
+	include []string
+	exclude []string
+}
+
+// loadEnv adds tags from env
+func (f *tagFilter) loadEnv() {
+	f.include = append(f.include, splitTags(os.Getenv(IncludeTagsEnv))...)
+	f.exclude = append(f.exclude, splitTags(os.Getenv(ExcludeTagsEnv))...)
+}
+
+// match returns true if case with tags should be run
+func (f *tagFilter) match(tags []string) bool {
+	if hasAnyTag(tags, f.exclude) {
+		return false
+	}
+	return len(f.include) == 0 || hasAnyTag(tags, f.include)
+}
+
+func hasAnyTag(tags, expected []string) bool {
+	for _, t := range tags {
+		for _, e := range expected {
+			if t == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitTags(s string) []string {
+	tags := []string{}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,64 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var layouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+}
+
+func init() {
+	registerFunc("now", now)
+	registerFunc("base64", base64Encode)
+	registerFunc("sha256", sha256Sum)
+}
+
+// now returns current time in layout
+// Layout can be a quoted name like 'RFC3339', 'Unix', 'UnixMilli' or a layout of golang
+// Default layout is RFC3339
+func now(args ...string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("only layout is expected")
+	}
+	layout := "RFC3339"
+	if len(args) == 1 {
+		layout = args[0]
+	}
+	t := time.Now()
+	switch layout {
+	case "Unix":
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "UnixMilli":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10), nil
+	}
+	if l, ok := layouts[layout]; ok {
+		layout = l
+	}
+	return t.Format(layout), nil
+}
+
+// base64Encode returns std base64 encoding of arg
+func base64Encode(args ...string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("only one arg is expected")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(args[0])), nil
+}
+
+// sha256Sum returns hex encoded sha256 of arg
+func sha256Sum(args ...string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("only one arg is expected")
+	}
+	sum := sha256.Sum256([]byte(args[0]))
+	return hex.EncodeToString(sum[:]), nil
+}
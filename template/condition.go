@@ -0,0 +1,223 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Evaluate evaluates a condition by variables
+// Conditions support:
+//
+//	exists(name)            => variable name is defined
+//	name                    => variable name is true
+//	name == 'value'         => value of variable name is value
+//	name != 1               => value of variable name is not 1
+//	!cond, cond && cond, cond || cond, (cond)
+//
+// Operands can be names of variables, quoted strings, numbers,
+// true, false and null. Values are compared as strings
+func Evaluate(cond string, vs map[string]Variable) (bool, error) {
+	tokens, err := tokenize(cond)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %v: %v", cond, err)
+	}
+	p := &condParser{tokens: tokens, vs: vs}
+	ok, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %v", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %v: %v", cond, err)
+	}
+	return ok, nil
+}
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	literalToken
+	opToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits condition into names, literals and operators
+func tokenize(s string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(s[i:], "==") || strings.HasPrefix(s[i:], "!=") ||
+			strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||"):
+			tokens = append(tokens, token{opToken, s[i : i+2]})
+			i += 2
+		case c == '!' || c == '(' || c == ')':
+			tokens = append(tokens, token{opToken, string(c)})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed quote")
+			}
+			tokens = append(tokens, token{literalToken, s[i+1 : i+1+end]})
+			i += end + 2
+		case isNameChar(c):
+			j := i
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			kind := identToken
+			switch {
+			case word == "true" || word == "false" || word == "null":
+				kind = literalToken
+			case c == '-' || (c >= '0' && c <= '9'):
+				kind = literalToken
+			}
+			tokens = append(tokens, token{kind, word})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// condParser is a recursive descent parser of conditions
+type condParser struct {
+	tokens []token
+	pos    int
+	vs     map[string]Variable
+
+	// skipped means result of current operand is not used, e.g.
+	// right operand of false && cond, so variables are not checked
+	skipped int
+}
+
+func (p *condParser) peek(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == opToken && p.tokens[p.pos].text == op
+}
+
+func (p *condParser) or() (bool, error) {
+	ok, err := p.and()
+	for err == nil && p.peek("||") {
+		p.pos++
+		var right bool
+		right, err = p.skip(ok, p.and)
+		ok = ok || right
+	}
+	return ok, err
+}
+
+func (p *condParser) and() (bool, error) {
+	ok, err := p.unary()
+	for err == nil && p.peek("&&") {
+		p.pos++
+		var right bool
+		right, err = p.skip(!ok, p.unary)
+		ok = ok && right
+	}
+	return ok, err
+}
+
+// skip parses next operand by f, and its result is not used if skipped
+func (p *condParser) skip(skipped bool, f func() (bool, error)) (bool, error) {
+	if skipped {
+		p.skipped++
+		defer func() { p.skipped-- }()
+	}
+	return f()
+}
+
+func (p *condParser) unary() (bool, error) {
+	if p.peek("!") {
+		p.pos++
+		ok, err := p.unary()
+		return !ok, err
+	}
+	return p.primary()
+}
+
+func (p *condParser) primary() (bool, error) {
+	if p.peek("(") {
+		p.pos++
+		ok, err := p.or()
+		if err != nil {
+			return false, err
+		}
+		if !p.peek(")") {
+			return false, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return ok, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return false, fmt.Errorf("unexpected end")
+	}
+	t := p.tokens[p.pos]
+	if t.kind == opToken {
+		return false, fmt.Errorf("unexpected %v", t.text)
+	}
+	p.pos++
+	if t.kind == identToken && t.text == "exists" && p.peek("(") {
+		if p.pos+2 >= len(p.tokens) || p.tokens[p.pos+1].kind != identToken || !(p.tokens[p.pos+2].kind == opToken && p.tokens[p.pos+2].text == ")") {
+			return false, fmt.Errorf("exists should have name of variable")
+		}
+		_, ok := p.vs[p.tokens[p.pos+1].text]
+		p.pos += 3
+		return ok, nil
+	}
+	left, err := p.value(t)
+	if err != nil {
+		return false, err
+	}
+	if p.peek("==") || p.peek("!=") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind == opToken {
+			return false, fmt.Errorf("missing operand of %v", op)
+		}
+		right, err := p.value(p.tokens[p.pos])
+		if err != nil {
+			return false, err
+		}
+		p.pos++
+		return (left == right) == (op == "=="), nil
+	}
+	switch left {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if p.skipped > 0 {
+		return false, nil
+	}
+	return false, fmt.Errorf("%v is not a boolean", t.text)
+}
+
+// value returns value of operand
+func (p *condParser) value(t token) (string, error) {
+	if t.kind == literalToken {
+		return t.text, nil
+	}
+	v, ok := p.vs[t.text]
+	if !ok && p.skipped > 0 {
+		return "", nil
+	}
+	if !ok {
+		return "", fmt.Errorf("can't find variable %v", t.text)
+	}
+	return v.String(), nil
+}
@@ -0,0 +1,65 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	vs := map[string]Variable{
+		"id": {
+			Raw:  []byte("p1"),
+			Name: "id",
+			Type: StringType,
+		},
+		"count": {
+			Raw:  []byte("3"),
+			Name: "count",
+			Type: NumberType,
+		},
+		"exists": {
+			Raw:  []byte("true"),
+			Name: "exists",
+			Type: BooleanType,
+		},
+	}
+	cases := []struct {
+		cond     string
+		ok       bool
+		hasError bool
+	}{
+		{"exists", true, false},
+		{"!exists", false, false},
+		{"exists(id)", true, false},
+		{"exists(missing)", false, false},
+		{"!exists(missing)", true, false},
+		{"id == 'p1'", true, false},
+		{`id != "p1"`, false, false},
+		{"count == 3", true, false},
+		{"count == 3 && !exists", false, false},
+		{"count == 4 || exists", true, false},
+		{"!(count == 4 || id == 'p2')", true, false},
+		{"exists(missing) && missing == 'a'", false, false},
+		{"exists(id) || missing == 'a'", true, false},
+		{"exists(missing) && missing", false, false},
+		{"missing == 'a'", false, true},
+		{"id", false, true},
+		{"id ==", false, true},
+		{"(exists", false, true},
+		{"exists exists", false, true},
+		{"id == 'p1", false, true},
+		{"id = 'p1'", false, true},
+		{"", false, true},
+	}
+	for _, c := range cases {
+		ok, err := Evaluate(c.cond, vs)
+		if c.hasError {
+			assert.Error(t, err, c.cond)
+			continue
+		}
+		if assert.NoError(t, err, c.cond) {
+			assert.Equal(t, c.ok, ok, c.cond)
+		}
+	}
+}
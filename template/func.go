@@ -0,0 +1,121 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Func defines a function which can be called in template
+// e.g. %{random.int(1, 100)}
+// Args which are names of variables are replaced by values of variables
+// and other names are rejected, so literal strings should be quoted
+type Func func(args ...string) (string, error)
+
+var (
+	funcsLock sync.RWMutex
+	funcs     = map[string]Func{}
+
+	callRegexp     = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.]*)(?:\((.*)\))?$`)
+	funcNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// RegisterFunc registers a function which can be called in templates
+// Name can contain letters, digits, underscores and dots
+// Variables take precedence over functions with the same name
+func RegisterFunc(name string, f Func) error {
+	if !funcNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid function name %v", name)
+	}
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	if _, ok := funcs[name]; ok {
+		return fmt.Errorf("function %v has been registered", name)
+	}
+	funcs[name] = f
+	return nil
+}
+
+func registerFunc(name string, f Func) {
+	if err := RegisterFunc(name, f); err != nil {
+		panic(err)
+	}
+}
+
+func getFunc(name string) (Func, bool) {
+	funcsLock.RLock()
+	defer funcsLock.RUnlock()
+	f, ok := funcs[name]
+	return f, ok
+}
+
+// call calls function in template
+// It returns false if expr is not a call of registered function
+func call(expr string, vs map[string]Variable) (string, bool, error) {
+	m := callRegexp.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", false, nil
+	}
+	f, ok := getFunc(m[1])
+	if !ok {
+		return "", false, nil
+	}
+	args, err := parseArgs(m[2], vs)
+	if err != nil {
+		return "", true, fmt.Errorf("can't call %v: %v", m[1], err)
+	}
+	out, err := f(args...)
+	if err != nil {
+		return "", true, fmt.Errorf("can't call %v: %v", m[1], err)
+	}
+	return out, true, nil
+}
+
+// parseArgs splits args by comma
+// Quoted arg is unquoted and arg which is name of variable is
+// replaced by value of the variable, other unquoted args should be
+// literals which aren't names, e.g. numbers
+func parseArgs(s string, vs map[string]Variable) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	raws := []string{}
+	quote, start := rune(0), 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ',':
+			raws = append(raws, s[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote in args %v", s)
+	}
+	raws = append(raws, s[start:])
+
+	args := []string{}
+	for _, raw := range raws {
+		raw = strings.TrimSpace(raw)
+		if len(raw) >= 2 && (raw[0] == '\'' || raw[0] == '"') && raw[len(raw)-1] == raw[0] {
+			args = append(args, raw[1:len(raw)-1])
+			continue
+		}
+		if v, ok := vs[raw]; ok {
+			args = append(args, v.String())
+			continue
+		}
+		// a typo of variable name shouldn't be passed as literal
+		if funcNameRegexp.MatchString(raw) {
+			return nil, fmt.Errorf("variable %v is not found, quote it to use as literal", raw)
+		}
+		args = append(args, raw)
+	}
+	return args, nil
+}
@@ -0,0 +1,107 @@
+package template
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandom(t *testing.T) {
+	cases := []struct {
+		raw      string
+		pattern  string
+		hasError bool
+	}{
+		{"%{random.uuid}", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, false},
+		{"%{random.int(1, 3)}", `^[1-3]$`, false},
+		{"%{random.int(n, 3)}", `^3$`, false},
+		{"%{random.string}", `^[a-z0-9]{8}$`, false},
+		{"name-%{random.string(4)}", `^name-[a-z0-9]{4}$`, false},
+		{"%{faker.email}", `^[a-z]+\.[a-z]+[0-9]*@example\.(com|org|net)$`, false},
+		{"%{faker.name}", `^[a-z]+ [a-z]+$`, false},
+		{"%{random.int(3, 1)}", "", true},
+		{"%{random.int(1)}", "", true},
+		{"%{random.uuid(1)}", "", true},
+		{"%{random.unknown}", "", true},
+	}
+	vs := map[string]Variable{
+		"n": {Raw: []byte("3"), Name: "n", Type: NumberType},
+	}
+	for _, c := range cases {
+		temp, err := New(c.raw)
+		assert.NoError(t, err, c.raw)
+		out, err := temp.Render(vs)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		assert.NoError(t, err, c.raw)
+		assert.Regexp(t, regexp.MustCompile(c.pattern), out, c.raw)
+	}
+}
+
+func TestSetSeed(t *testing.T) {
+	temp, err := New("%{random.uuid}-%{random.int(0, 1000000)}")
+	assert.NoError(t, err)
+	outs := []string{}
+	for i := 0; i < 2; i++ {
+		SetSeed(42)
+		out, err := temp.Render(nil)
+		assert.NoError(t, err)
+		outs = append(outs, out)
+	}
+	assert.Equal(t, outs[0], outs[1])
+
+	other, err := temp.Render(nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, outs[0], other, "values should be fresh per use")
+}
+
+func TestBuiltinFuncs(t *testing.T) {
+	vs := map[string]Variable{
+		"user": {Raw: []byte("admin:secret"), Name: "user", Type: StringType},
+	}
+	cases := []struct {
+		raw      string
+		pattern  string
+		hasError bool
+	}{
+		{"%{base64(user)}", `^YWRtaW46c2VjcmV0$`, false},
+		{"%{base64('user')}", `^dXNlcg==$`, false},
+		{"%{sha256(user)}", `^901b281c4e0c4007e8526ef27153b79330811e733976d5e65c8343a39e54ec81$`, false},
+		{"%{now}", `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}`, false},
+		{"%{now('RFC3339')}", `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}`, false},
+		{"%{now('Unix')}", `^\d{10}$`, false},
+		{"%{now('2006-01-02')}", `^\d{4}-\d{2}-\d{2}$`, false},
+		{"%{base64(a, b)}", "", true},
+		// unquoted names which are not variables are rejected
+		{"%{now(Unix)}", "", true},
+		{"%{base64(usr)}", "", true},
+	}
+	for _, c := range cases {
+		temp, err := New(c.raw)
+		assert.NoError(t, err, c.raw)
+		out, err := temp.Render(vs)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		assert.NoError(t, err, c.raw)
+		assert.Regexp(t, regexp.MustCompile(c.pattern), out, c.raw)
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	assert.NoError(t, RegisterFunc("test.upper", func(args ...string) (string, error) {
+		return "UPPER", nil
+	}))
+	assert.Error(t, RegisterFunc("test.upper", nil), "duplicated function")
+	assert.Error(t, RegisterFunc("bad name", nil), "invalid name")
+
+	temp, err := New("%{test.upper}")
+	assert.NoError(t, err)
+	out, err := temp.Render(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "UPPER", out)
+}
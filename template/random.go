@@ -0,0 +1,125 @@
+package template
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	randLock sync.Mutex
+	random   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetSeed sets seed of random functions so that
+// random values can be reproduced
+func SetSeed(seed int64) {
+	randLock.Lock()
+	defer randLock.Unlock()
+	random = rand.New(rand.NewSource(seed))
+}
+
+func withRand(f func(r *rand.Rand) string) string {
+	randLock.Lock()
+	defer randLock.Unlock()
+	return f(random)
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+var (
+	firstNames = []string{"alice", "bob", "carol", "dave", "eve", "frank", "grace", "heidi", "ivan", "judy"}
+	lastNames  = []string{"smith", "johnson", "brown", "lee", "wang", "garcia", "miller", "davis", "lopez", "chen"}
+	domains    = []string{"example.com", "example.org", "example.net"}
+)
+
+func init() {
+	registerFunc("random.uuid", randomUUID)
+	registerFunc("random.int", randomInt)
+	registerFunc("random.string", randomString)
+	registerFunc("faker.name", fakerName)
+	registerFunc("faker.email", fakerEmail)
+}
+
+// randomUUID returns a version 4 uuid
+func randomUUID(args ...string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("no arg is expected")
+	}
+	return withRand(func(r *rand.Rand) string {
+		b := make([]byte, 16)
+		r.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	}), nil
+}
+
+// randomInt returns an int in [min, max]
+func randomInt(args ...string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("min and max are expected")
+	}
+	min, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("min should be an int: %v", err)
+	}
+	max, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", fmt.Errorf("max should be an int: %v", err)
+	}
+	if min > max {
+		return "", fmt.Errorf("min %v should not be greater than max %v", min, max)
+	}
+	return withRand(func(r *rand.Rand) string {
+		return strconv.Itoa(min + r.Intn(max-min+1))
+	}), nil
+}
+
+// randomString returns a string of lowercase letters and digits
+// Default length is 8
+func randomString(args ...string) (string, error) {
+	n := 8
+	switch len(args) {
+	case 0:
+	case 1:
+		l, err := strconv.Atoi(args[0])
+		if err != nil || l < 1 {
+			return "", fmt.Errorf("length should be a positive int")
+		}
+		n = l
+	default:
+		return "", fmt.Errorf("only length is expected")
+	}
+	return withRand(func(r *rand.Rand) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = letters[r.Intn(len(letters))]
+		}
+		return string(b)
+	}), nil
+}
+
+func fakerName(args ...string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("no arg is expected")
+	}
+	return withRand(func(r *rand.Rand) string {
+		return firstNames[r.Intn(len(firstNames))] + " " + lastNames[r.Intn(len(lastNames))]
+	}), nil
+}
+
+func fakerEmail(args ...string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("no arg is expected")
+	}
+	return withRand(func(r *rand.Rand) string {
+		return fmt.Sprintf("%v.%v%v@%v",
+			firstNames[r.Intn(len(firstNames))],
+			lastNames[r.Intn(len(lastNames))],
+			r.Intn(10000),
+			domains[r.Intn(len(domains))])
+	}), nil
+}
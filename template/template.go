@@ -3,6 +3,9 @@ package template
 import (
 	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 )
 
 // JSONType defines type of JSON
@@ -39,6 +42,14 @@ type Variable struct {
 	Raw  []byte
 	Name string
 	Type JSONType
+
+	// Secret means value of variable is masked in outputs
+	Secret bool
+
+	// Typed means type of variable is declared, a typed variable
+	// which is not a string is rendered without quotes if it is
+	// the whole json string, e.g. "%{count}" => 3
+	Typed bool
 }
 
 // String returns variable value
@@ -50,10 +61,39 @@ func (v *Variable) String() string {
 	return string(v.Raw)
 }
 
+const (
+	// Masked replaces values of secret variables
+	Masked = "***"
+)
+
+// Mask replaces values of secret variables in s by Masked
+// Longer values are replaced first, so a value which contains
+// another secret is masked entirely
+func Mask(s string, vs map[string]Variable) string {
+	secrets := []string{}
+	for _, v := range vs {
+		if v.Secret && len(v.Raw) != 0 {
+			secrets = append(secrets, v.String())
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, Masked, -1)
+	}
+	return s
+}
+
 // Template is a simple template support variable
 // Golang template is too complex to use in this case
 type Template interface {
 	Render(vs map[string]Variable) (string, error)
+
+	// RenderJSON renders template of json, typed variables which
+	// are not strings are rendered without quotes if they are whole
+	// json strings, e.g. "%{count}" => 3
+	RenderJSON(vs map[string]Variable) (string, error)
 }
 
 // Template defines template of request
@@ -113,6 +153,11 @@ func (t *template) fromRaw(raw string) error {
 	return nil
 }
 
+// Escape escapes string so that it will be rendered as it is
+func Escape(s string) string {
+	return strings.Replace(s, "%", "%%", -1)
+}
+
 // New returns raw string to template
 func New(raw string) (Template, error) {
 	t := template{}
@@ -141,16 +186,103 @@ func New(raw string) (Template, error) {
 // "%{number}" => "1.5"
 // %% => %
 // %%{string} => %{string}
+// %{env:HOST} => value of env HOST
+// %{env:HOST:localhost} => value of env HOST or localhost if it is not set
+// %{random.int(1, 10)} => result of function, e.g. 3
 func (t *template) Render(vs map[string]Variable) (string, error) {
+	return t.render(vs, false)
+}
+
+// RenderJSON renders template like Render, but typed variables which are
+// not strings are unquoted if they are whole json strings
+// "%{typedNumber}" => 1.5
+func (t *template) RenderJSON(vs map[string]Variable) (string, error) {
+	return t.render(vs, true)
+}
+
+func (t *template) render(vs map[string]Variable, isJSON bool) (string, error) {
 	out := ""
+	// unquoted means quote at the beginning of current snippt is removed
+	unquoted := false
 	for i, varName := range t.varNames {
-		out += t.snippts[i]
-		v, ok := vs[varName]
-		if !ok {
-			return "", fmt.Errorf("can't find varibale %v", varName)
+		snippt := t.snippts[i]
+		if unquoted {
+			snippt = snippt[1:]
+		}
+		unquoted = isJSON && isUnquoted(vs[varName]) && isQuoted(snippt, t.snippts[i+1])
+		if unquoted {
+			snippt = snippt[:len(snippt)-1]
+		}
+		out += snippt
+		v, err := lookup(varName, vs)
+		if err != nil {
+			return "", err
 		}
-		out += v.String()
+		out += v
 	}
-	out += t.snippts[len(t.snippts)-1]
+	last := t.snippts[len(t.snippts)-1]
+	if unquoted {
+		last = last[1:]
+	}
+	out += last
 	return out, nil
 }
+
+// isUnquoted returns whether variable is rendered without quotes
+// if it is the whole json string
+func isUnquoted(v Variable) bool {
+	return v.Typed && v.Type != StringType
+}
+
+// isQuoted returns whether variable between before and after
+// is the whole json string
+func isQuoted(before, after string) bool {
+	if !strings.HasSuffix(before, `"`) || !strings.HasPrefix(after, `"`) {
+		return false
+	}
+	// escaped quote is in a json string
+	return !strings.HasSuffix(before, `\"`)
+}
+
+const (
+	// envPrefix is prefix of variable which is read from env
+	envPrefix = "env:"
+)
+
+// lookup returns value of variable
+func lookup(varName string, vs map[string]Variable) (string, error) {
+	if strings.HasPrefix(varName, envPrefix) {
+		return lookupEnv(strings.TrimPrefix(varName, envPrefix))
+	}
+	v, ok := vs[varName]
+	if ok {
+		return v.String(), nil
+	}
+	out, ok, err := call(varName, vs)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("can't find varibale %v", varName)
+	}
+	return out, nil
+}
+
+// lookupEnv returns value of env
+// Default value is after the first colon, e.g. HOST:localhost:8080
+func lookupEnv(s string) (string, error) {
+	name, def, hasDefault := s, "", false
+	if i := strings.IndexByte(s, ':'); i != -1 {
+		name, def, hasDefault = s[:i], s[i+1:], true
+	}
+	if name == "" {
+		return "", fmt.Errorf("name of env should not be empty")
+	}
+	if v, ok := os.LookupEnv(name); ok {
+		return v, nil
+	}
+	if hasDefault {
+		return def, nil
+	}
+	return "", fmt.Errorf("env %v is not set and has no default value", name)
+}
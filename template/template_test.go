@@ -1,6 +1,7 @@
 package template
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,8 +10,8 @@ import (
 func TestNew(t *testing.T) {
 	cases := []struct {
 		raw      string
-		varNames []string
 		snippts  []string
+		varNames []string
 		hasError bool
 	}{
 		{
@@ -112,12 +113,12 @@ func TestRender(t *testing.T) {
 			},
 			map[string]Variable{
 				"cluster": {
-					raw:  []byte("cid"),
+					Raw:  []byte("cid"),
 					Name: "cluster",
 					Type: StringType,
 				},
 				"partition": {
-					raw:  []byte("1.5"),
+					Raw:  []byte("1.5"),
 					Name: "partition",
 					Type: NumberType,
 				},
@@ -130,18 +131,18 @@ func TestRender(t *testing.T) {
 				[]string{"cluster", "partition"},
 				[]string{
 					`{"cluster": "`,
-					`", "partitions: ",
-					"}`,
+					`", "partition": "`,
+					`"}`,
 				},
 			},
 			map[string]Variable{
 				"cluster": {
-					raw:  []byte("cid"),
+					Raw:  []byte("cid"),
 					Name: "cluster",
 					Type: StringType,
 				},
 				"partition": {
-					raw:  []byte("1.5"),
+					Raw:  []byte("1.5"),
 					Name: "partition",
 					Type: NumberType,
 				},
@@ -149,10 +150,44 @@ func TestRender(t *testing.T) {
 			`{"cluster": "cid", "partition": "1.5"}`,
 			false,
 		},
+		{
+			&template{
+				[]string{"cluster", "partition", "enabled", "enabled"},
+				[]string{
+					`{"cluster": "`,
+					`", "partition": "`,
+					`", "enabled": "`,
+					`", "s": "\"`,
+					`\""}`,
+				},
+			},
+			map[string]Variable{
+				"cluster": {
+					Raw:   []byte("cid"),
+					Name:  "cluster",
+					Type:  StringType,
+					Typed: true,
+				},
+				"partition": {
+					Raw:   []byte("1.5"),
+					Name:  "partition",
+					Type:  NumberType,
+					Typed: true,
+				},
+				"enabled": {
+					Raw:   []byte("true"),
+					Name:  "enabled",
+					Type:  BooleanType,
+					Typed: true,
+				},
+			},
+			`{"cluster": "cid", "partition": 1.5, "enabled": true, "s": "\"true\""}`,
+			false,
+		},
 	}
 
 	for _, c := range cases {
-		out, err := c.t.Render(c.vs)
+		out, err := c.t.RenderJSON(c.vs)
 		if c.hasError {
 			continue
 		}
@@ -160,3 +195,67 @@ func TestRender(t *testing.T) {
 		assert.Equal(t, c.out, out, "render result should be same")
 	}
 }
+
+func TestRenderTyped(t *testing.T) {
+	vs := map[string]Variable{
+		"count": {Raw: []byte("3"), Name: "count", Type: NumberType, Typed: true},
+	}
+	temp, err := New(`count="%{count}"`)
+	assert.NoError(t, err)
+	// typed variables are only unquoted in json
+	out, err := temp.Render(vs)
+	assert.NoError(t, err)
+	assert.Equal(t, `count="3"`, out)
+	out, err = temp.RenderJSON(vs)
+	assert.NoError(t, err)
+	assert.Equal(t, `count=3`, out)
+}
+
+func TestRenderEnv(t *testing.T) {
+	os.Setenv("ALOE_TEST_HOST", "example.com")
+	defer os.Unsetenv("ALOE_TEST_HOST")
+	cases := []struct {
+		raw      string
+		out      string
+		hasError bool
+	}{
+		{"http://%{env:ALOE_TEST_HOST}/api", "http://example.com/api", false},
+		{"%{env:ALOE_TEST_HOST:localhost}", "example.com", false},
+		{"%{env:ALOE_TEST_MISSING:localhost:8080}", "localhost:8080", false},
+		{"%{env:ALOE_TEST_MISSING:}", "", false},
+		{"%{env:ALOE_TEST_MISSING}", "", true},
+		{"%{env:}", "", true},
+	}
+	for _, c := range cases {
+		temp, err := New(c.raw)
+		assert.NoError(t, err, c.raw)
+		out, err := temp.Render(nil)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		assert.NoError(t, err, c.raw)
+		assert.Equal(t, c.out, out, c.raw)
+	}
+}
+
+func TestMask(t *testing.T) {
+	vs := map[string]Variable{
+		"token":    {Raw: []byte("abc"), Name: "token", Type: StringType, Secret: true},
+		"password": {Raw: []byte("abc123"), Name: "password", Type: StringType, Secret: true},
+		"empty":    {Raw: []byte(""), Name: "empty", Type: StringType, Secret: true},
+		"user":     {Raw: []byte("admin"), Name: "user", Type: StringType},
+	}
+	cases := []struct {
+		s        string
+		expected string
+	}{
+		{"Bearer abc", "Bearer ***"},
+		{`{"user": "admin", "password": "abc123"}`, `{"user": "admin", "password": "***"}`},
+		{"abc abc", "*** ***"},
+		{"nothing", "nothing"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, Mask(c.s, vs), c.s)
+	}
+}
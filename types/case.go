@@ -5,6 +5,42 @@ type Case struct {
 	// Description describe
 	Description string `json:"description,omitempty"`
 
+	// Tags are used to filter cases
+	Tags []string `json:"tags,omitempty"`
+
+	// Focus means only focused cases and contexts are run
+	Focus bool `json:"focus,omitempty"`
+
+	// Skip means the case is not run
+	Skip bool `json:"skip,omitempty"`
+
+	// SkipReason is displayed with summary of skipped case
+	SkipReason string `json:"skipReason,omitempty"`
+
+	// DependsOn defines cases in the same dir which should be run before it
+	// Extension of file can be omitted. The case is skipped if any of
+	// them is not passed
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// FlakeAttempts defines max attempts of running flow
+	// Variables of context are restored before each attempt
+	FlakeAttempts int `json:"flakeAttempts,omitempty"`
+
 	// Flow defines test flow of a test case
 	Flow []RoundTrip `json:"flow,omitempty"`
+
+	// Requests defines expected requests which are sent by flow
+	// They are checked after flow is passed
+	Requests *RequestsExpectation `json:"requests,omitempty"`
+}
+
+// RequestsExpectation defines expected requests sent by flow of a case
+// Each attempt of retries and polls is a request, but redirects are not
+type RequestsExpectation struct {
+	// Count defines expected number of requests
+	Count *int `json:"count,omitempty"`
+
+	// Sequence defines expected method and path of requests in order
+	// e.g. GET /products/%{productID}, query of path is ignored
+	Sequence []Template `json:"sequence,omitempty"`
 }
@@ -1,10 +1,27 @@
 package types
 
-import "github.com/caicloud/aloe/template"
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"github.com/caicloud/aloe/template"
+)
 
 const (
 	// ContextFile defines default filename of spec
 	ContextFile = "_context.yaml"
+
+	// ContextJSONFile defines filename of spec in json
+	// It can be used instead of ContextFile
+	ContextJSONFile = "_context.json"
+
+	// VariablesFile defines filename of variables of a directory
+	VariablesFile = "variables.yaml"
+
+	// VariablesJSONFile defines filename of variables in json
+	// It can be used instead of VariablesFile
+	VariablesJSONFile = "variables.json"
 )
 
 // ContextConfig defines some configs for ginkgo.Describe
@@ -17,19 +34,133 @@ type ContextConfig struct {
 	// Description used to describe context of all cases
 	Description string `json:"description,omitempty"`
 
+	// Include defines paths of shared context fragments
+	// They are relative to the context file and merged
+	// into this context in order
+	Include []string `json:"include,omitempty"`
+
+	// Tags are inherited by all cases and sub contexts
+	// in this context
+	Tags []string `json:"tags,omitempty"`
+
+	// Focus means only focused cases and contexts are run
+	Focus bool `json:"focus,omitempty"`
+
+	// Skip means all cases in this context are not run
+	Skip bool `json:"skip,omitempty"`
+
+	// SkipReason is displayed with summary of skipped context
+	SkipReason string `json:"skipReason,omitempty"`
+
 	// Definitions defines variable in this context
 	// Definitions map[string]string `json:"definitions,omitempty"`
 
-	// Preset defines some common fields for each round-trip in context
+	// Once means flow of context is run once before all cases in it,
+	// and for top level context, clearFn is called once after all cases.
+	// It only works if parent contexts are also once
+	Once bool `json:"once,omitempty"`
+
+	// Cleaners defines names of registered cleaners which are run
+	// after each case in this context, or once after all cases if
+	// context is once. All cleaners are run for top level context if
+	// it is empty
+	Cleaners []string `json:"cleaners,omitempty"`
+
+	// InheritVariables means variables of parent contexts are visible in
+	// this context, it is true by default. Flow of context is started with
+	// variables of suite and variables.yaml in the same directory if it is false
+	InheritVariables *bool `json:"inheritVariables,omitempty"`
+
+	// Preset defines some common fields for each round-trip of cases and
+	// sub contexts in this context. It is merged with presets of parent
+	// contexts, see MergeRoundTrip
 	Preset RoundTrip `json:"preset,omitempty"`
 
+	// Presetters defines presetters applied to round trips
+	// of all cases and sub contexts in this context
+	// They are applied before presetters of parent contexts
+	Presetters []PresetConfig `json:"presetters,omitempty"`
+
 	// Flow will be called to construct context
 	Flow []RoundTrip `json:"flow,omitempty"`
 }
 
+// VariablesConfig defines variables which are added into context
+// before flow of context in the same directory is run
+type VariablesConfig struct {
+	// Variables defines values of variables, they can be any json value
+	Variables map[string]json.RawMessage `json:"variables,omitempty"`
+
+	// Environments defines variables of each environment, e.g. staging
+	// Variables of selected environment override Variables
+	Environments map[string]map[string]json.RawMessage `json:"environments,omitempty"`
+}
+
+// PresetConfig defines a registered presetter and its args
+type PresetConfig struct {
+	// Name is name of presetter
+	Name string `json:"name"`
+
+	// Args defines args of presetter
+	// They are rendered by variables of context
+	Args map[string]Template `json:"args,omitempty"`
+}
+
 // Context defines context of test cases
 type Context struct {
 	Variables map[string]template.Variable
 
+	// Dir is directory of test data
+	// Relative paths in round trip are relative to it
+	Dir string
+
+	// FS is file system of test data, e.g. embed.FS
+	// Files of round trips are read from disk if it is nil
+	FS fs.FS
+
+	// CookieJar stores cookies across round trips of a case
+	// including round trips of context
+	CookieJar http.CookieJar
+
+	// Presetters defines presetters of all parent contexts
+	// Presetters of inner context are in front
+	Presetters []PresetConfig
+
+	// RoundTripTemplate is merged from presets of all parent contexts
+	// Preset of inner context overrides outer ones
+	RoundTripTemplate RoundTrip
+
+	// UpdateGolden means golden files of responses are written
+	// by received responses instead of matched
+	UpdateGolden bool
+
+	// FailureVerbosity defines how mismatches of responses are rendered
+	FailureVerbosity FailureVerbosity
+
+	// Recorder records requests which are sent if it is not nil
+	Recorder RequestRecorder
+
 	Error error
 }
+
+// FailureVerbosity defines how mismatches of responses are rendered
+// in failure messages
+type FailureVerbosity int
+
+const (
+	// FullFailures renders mismatches with full bodies
+	FullFailures FailureVerbosity = iota
+
+	// TruncatedFailures renders mismatches of each field without
+	// full body, and long messages are truncated
+	TruncatedFailures
+
+	// PathFailures only renders jsonpaths of mismatched fields
+	// with expected and actual values
+	PathFailures
+)
+
+// RequestRecorder records requests which are sent by client
+type RequestRecorder interface {
+	Record(req *http.Request)
+}
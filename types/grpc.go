@@ -0,0 +1,10 @@
+package types
+
+// GRPC defines a grpc request
+type GRPC struct {
+	// DescriptorSet defines path of a file descriptor set which contains
+	// the service and its messages, e.g. generated by protoc with
+	// --include_imports --descriptor_set_out. It is relative to
+	// directory of test data
+	DescriptorSet *Template `json:"descriptorSet"`
+}
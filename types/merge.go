@@ -0,0 +1,119 @@
+package types
+
+import (
+	"net/http"
+)
+
+// MergeRoundTrip returns a round trip whose unset request fields are set
+// by request of tmpl, fields of rt always win:
+//   - headers, query and path params are merged by keys, and names of
+//     headers are case insensitive
+//   - body, body file, multipart and graphql are a single field, none of
+//     them are set by tmpl if one of them is set in rt
+//   - protocol, base url, path prefix, replaced query keys, body type,
+//     content encoding, grpc, redirects, timeout and retry are set if
+//     they are unset in rt
+//
+// Other fields are fields of a step, e.g. name, api, response and
+// definitions, and they are never set by tmpl
+func MergeRoundTrip(rt, tmpl RoundTrip) RoundTrip {
+	merged := rt
+	req, t := &merged.Request, tmpl.Request
+	if req.Protocol == "" {
+		req.Protocol = t.Protocol
+	}
+	if req.BaseURL == nil {
+		req.BaseURL = t.BaseURL
+	}
+	if req.PathPrefix == nil {
+		req.PathPrefix = t.PathPrefix
+	}
+	req.PathParams = mergeTemplates(req.PathParams, t.PathParams)
+	req.Query = mergeQuery(req.Query, t.Query)
+	if req.ReplaceQuery == nil {
+		req.ReplaceQuery = t.ReplaceQuery
+	}
+	req.Headers = mergeHeaders(req.Headers, t.Headers)
+	if req.Body == nil && req.BodyFile == nil && req.Multipart == nil && req.GraphQL == nil {
+		req.Body = t.Body
+		req.BodyFile = t.BodyFile
+		req.Multipart = t.Multipart
+		req.GraphQL = t.GraphQL
+	}
+	if req.BodyType == "" {
+		req.BodyType = t.BodyType
+	}
+	if req.ContentEncoding == "" {
+		req.ContentEncoding = t.ContentEncoding
+	}
+	if req.GRPC == nil {
+		req.GRPC = t.GRPC
+	}
+	if req.FollowRedirects == nil {
+		req.FollowRedirects = t.FollowRedirects
+	}
+	if req.MaxRedirects == 0 {
+		req.MaxRedirects = t.MaxRedirects
+	}
+	if req.Timeout == nil {
+		req.Timeout = t.Timeout
+	}
+	if req.Retry == nil {
+		req.Retry = t.Retry
+	}
+	return merged
+}
+
+// mergeHeaders returns headers of rt merged with headers of tmpl
+// e.g. content-type of rt overrides Content-Type of tmpl
+func mergeHeaders(headers, tmpl map[string]string) map[string]string {
+	if len(tmpl) == 0 {
+		return headers
+	}
+	names := map[string]bool{}
+	for k := range headers {
+		names[http.CanonicalHeaderKey(k)] = true
+	}
+	merged := make(map[string]string, len(headers)+len(tmpl))
+	for k, v := range tmpl {
+		if !names[http.CanonicalHeaderKey(k)] {
+			merged[k] = v
+		}
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return merged
+}
+
+// mergeQuery returns query of rt merged with query of tmpl
+// Values of a key in rt replace all values of the key in tmpl
+func mergeQuery(query, tmpl map[string]Templates) map[string]Templates {
+	if len(tmpl) == 0 {
+		return query
+	}
+	// map of rt is copied so that round trip in test data is not modified
+	merged := make(map[string]Templates, len(query)+len(tmpl))
+	for k, v := range tmpl {
+		merged[k] = v
+	}
+	for k, v := range query {
+		merged[k] = v
+	}
+	return merged
+}
+
+// mergeTemplates returns templates of rt merged with templates of tmpl
+func mergeTemplates(ts, tmpl map[string]Template) map[string]Template {
+	if len(tmpl) == 0 {
+		return ts
+	}
+	merged := make(map[string]Template, len(ts)+len(tmpl))
+	for k, v := range tmpl {
+		merged[k] = v
+	}
+	for k, v := range ts {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeRoundTrip(t *testing.T) {
+	parse := func(raw string) RoundTrip {
+		rt := RoundTrip{}
+		if err := json.Unmarshal([]byte(raw), &rt); err != nil {
+			t.Fatalf("can't parse %v: %v", raw, err)
+		}
+		return rt
+	}
+	parent := parse(`{
+		"request": {
+			"headers": {"X-Tenant": "parent", "Accept": "application/json"},
+			"query": {"version": "v1"},
+			"body": {"from": "parent"}
+		},
+		"response": {"statusCode": 200}
+	}`)
+	child := parse(`{
+		"request": {
+			"headers": {"X-Tenant": "child"},
+			"query": {"page": "1"}
+		}
+	}`)
+	c := parse(`{
+		"request": {
+			"api": "POST /products",
+			"headers": {"Accept": "text/plain"},
+			"body": {"from": "case"}
+		}
+	}`)
+	tmpl := MergeRoundTrip(child, parent)
+	merged := MergeRoundTrip(c, tmpl)
+
+	assert.Equal(t, "POST /products", merged.Request.API.Raw())
+	assert.Equal(t, map[string]string{
+		"X-Tenant": "child",
+		"Accept":   "text/plain",
+	}, merged.Request.Headers)
+	assert.Equal(t, []string{"page", "version"}, sortedKeys(merged.Request.Query))
+	assert.Equal(t, `{"from": "case"}`, merged.Request.Body.Raw())
+	assert.Nil(t, merged.Response.StatusCode)
+
+	// templates are not modified
+	assert.Equal(t, map[string]string{"X-Tenant": "child"}, child.Request.Headers)
+	assert.Equal(t, map[string]string{"Accept": "text/plain"}, c.Request.Headers)
+	assert.Equal(t, `{"from": "parent"}`, MergeRoundTrip(RoundTrip{}, tmpl).Request.Body.Raw())
+
+	// names of headers are case insensitive
+	lower := parse(`{"request": {"headers": {"accept": "text/csv"}}}`)
+	assert.Equal(t, map[string]string{
+		"X-Tenant": "child",
+		"accept":   "text/csv",
+	}, MergeRoundTrip(lower, tmpl).Request.Headers)
+}
+
+func TestMergeRoundTripStepFields(t *testing.T) {
+	parse := func(raw string) RoundTrip {
+		rt := RoundTrip{}
+		if err := json.Unmarshal([]byte(raw), &rt); err != nil {
+			t.Fatalf("can't parse %v: %v", raw, err)
+		}
+		return rt
+	}
+	tmpl := parse(`{
+		"name": "preset",
+		"dependsOn": ["login"],
+		"when": "exists(token)",
+		"forEach": {"items": ["a"], "as": "item"},
+		"definitions": [{"name": "id", "selector": ["id"]}],
+		"request": {
+			"api": "GET /preset",
+			"disableCookies": true,
+			"body": {"from": "preset"},
+			"bodyType": "xml",
+			"pathParams": {"tenant": "t1"},
+			"followRedirects": false,
+			"timeout": "1s"
+		},
+		"response": {"statusCode": 200, "body": {"ok": true}}
+	}`)
+	c := parse(`{
+		"name": "create",
+		"request": {
+			"api": "POST /tenants/{tenant}/products/{id}",
+			"bodyFile": "_fixtures/product.json",
+			"pathParams": {"id": "p1"},
+			"followRedirects": true
+		}
+	}`)
+	merged := MergeRoundTrip(c, tmpl)
+
+	// fields of steps are never set by template
+	assert.Equal(t, "create", merged.Name)
+	assert.Empty(t, merged.DependsOn)
+	assert.Empty(t, merged.When)
+	assert.Nil(t, merged.ForEach)
+	assert.Empty(t, merged.Definitions)
+	assert.Equal(t, Response{}, merged.Response)
+	assert.Equal(t, "POST /tenants/{tenant}/products/{id}", merged.Request.API.Raw())
+	assert.False(t, merged.Request.DisableCookies)
+
+	// body of template is not used with body file of round trip
+	assert.Nil(t, merged.Request.Body)
+	assert.Equal(t, "_fixtures/product.json", merged.Request.BodyFile.Raw())
+	assert.Equal(t, XMLBody, merged.Request.BodyType)
+	assert.Equal(t, []string{"id", "tenant"}, sortedTemplateKeys(merged.Request.PathParams))
+	// explicit false and true of round trip are kept
+	assert.True(t, *merged.Request.FollowRedirects)
+	merged = MergeRoundTrip(parse(`{"request": {"followRedirects": false}}`), parse(`{"request": {"followRedirects": true}}`))
+	assert.False(t, *merged.Request.FollowRedirects)
+	assert.Equal(t, `{"from": "preset"}`, MergeRoundTrip(RoundTrip{}, tmpl).Request.Body.Raw())
+	assert.Equal(t, "1s", MergeRoundTrip(RoundTrip{}, tmpl).Request.Timeout.String())
+}
+
+func sortedKeys(m map[string]Templates) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedTemplateKeys(m map[string]Template) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
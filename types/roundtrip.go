@@ -1,7 +1,12 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caicloud/aloe/template"
@@ -10,9 +15,24 @@ import (
 // RoundTrip defines a test case
 // It usually means one http request and response
 type RoundTrip struct {
+	// Name is a stable name of round trip in a flow, e.g. create-product
+	// It is shown in steps, reports and logs, and referenced by dependsOn
+	Name string `json:"name,omitempty"`
+
 	// Description describe the round trip
 	Description string `json:"description,omitempty"`
 
+	// DependsOn defines names of previous round trips in the same flow
+	// Round trip is skipped if any of them is skipped
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// When is a condition of variables, round trip is skipped if it
+	// is false, e.g. !exists(productID), see template.Evaluate
+	When string `json:"when,omitempty"`
+
+	// ForEach runs round trip for each item of a list
+	ForEach *ForEach `json:"forEach,omitempty"`
+
 	// Request defines a http request template
 	Request Request `json:"request,omitempty"`
 
@@ -21,35 +41,491 @@ type RoundTrip struct {
 
 	// Definitions defines new variables from response
 	Definitions []Definition `json:"definitions,omitempty"`
+
+	// SaveResponse writes received response into a file
+	SaveResponse *SaveResponse `json:"saveResponse,omitempty"`
+}
+
+// ForEach defines a loop of round trip over items of a list
+// Variables defined by round trip are collected into arrays, or
+// objects if key is set
+type ForEach struct {
+	// Items is a template of json array
+	// e.g. "%{names}" or ["apple", "banana"]
+	Items *Template `json:"items"`
+
+	// As is name of variable of current item, default is item
+	As string `json:"as,omitempty"`
+
+	// Index is name of variable of zero-indexed position of current item
+	Index string `json:"index,omitempty"`
+
+	// Key is rendered by variables of each item, e.g. "%{item}"
+	// Collected variables are objects keyed by it if it is set
+	Key *Template `json:"key,omitempty"`
+}
+
+const (
+	// DefaultForEachItem is default name of variable of current item
+	DefaultForEachItem = "item"
+)
+
+// SaveResponse defines where response is written
+type SaveResponse struct {
+	// Path is a template of file path
+	// Relative path is relative to output dir of framework
+	// or dir of test data if output dir is not set
+	Path *Template `json:"path"`
+
+	// Headers means status line and headers are also written
+	Headers bool `json:"headers,omitempty"`
 }
 
+// Protocol defines protocol of a round trip
+type Protocol string
+
+const (
+	// HTTPProtocol is the default protocol of round trip
+	HTTPProtocol Protocol = "http"
+
+	// GRPCProtocol defines grpc round trip of an unary method
+	// Api is full name of method, e.g. products.v1.Products/GetProduct,
+	// and body is json of request message
+	GRPCProtocol Protocol = "grpc"
+
+	// WebSocketProtocol defines websocket round trip
+	// Messages in request will be sent after connection is
+	// upgraded and received messages will be matched in order
+	WebSocketProtocol Protocol = "websocket"
+
+	// SSEProtocol defines server-sent events round trip
+	// Events are read from stream and matched by messages of
+	// response in order
+	SSEProtocol Protocol = "sse"
+)
+
 // Request defines a part template of http request
 type Request struct {
+	// Protocol defines protocol of request
+	// Default protocol is http
+	Protocol Protocol `json:"protocol,omitempty"`
+
+	// GRPC defines descriptors of grpc request
+	GRPC *GRPC `json:"grpc,omitempty"`
+
 	// API is a http verb + http path
 	// e.g GET /api/v1/users
 	API *Template `json:"api"`
 
+	// BaseURL defines scheme and host of request, e.g. https://staging:8443
+	// Host of framework is used if it is nil
+	BaseURL *Template `json:"baseURL,omitempty"`
+
+	// PathPrefix is joined with path in api, e.g. /api/v1
+	// It is usually set by preset of context
+	PathPrefix *Template `json:"pathPrefix,omitempty"`
+
+	// PathParams defines values of path params in api, e.g. {id} of
+	// GET /products/{id}. Values are escaped as path segments
+	PathParams map[string]Template `json:"pathParams,omitempty"`
+
+	// Query defines query parameters of request
+	// Values are appended to parameters with the same key in api, e.g.
+	// {tag: [b]} turns /products?tag=a into /products?tag=a&tag=b
+	Query map[string]Templates `json:"query,omitempty"`
+
+	// ReplaceQuery defines keys of query whose values replace parameters
+	// with the same keys in api instead of being appended, e.g. [tag]
+	// turns /products?tag=a into /products?tag=b for {tag: [b]}
+	ReplaceQuery []string `json:"replaceQuery,omitempty"`
+
 	// Headers defines http header of request
 	// NOTE(liubog2008): whether to use map[string][]string
 	Headers map[string]string `json:"headers,omitempty"`
 
 	// Body defines a template with variable
 	Body *Template `json:"body,omitempty"`
+
+	// BodyFile defines path of a file which is sent as body, it is
+	// relative to directory of test data. Content of file is rendered
+	// by variables unless body type is binary. It can't be used with body
+	BodyFile *Template `json:"bodyFile,omitempty"`
+
+	// BodyType defines how body is encoded
+	// Default body type is json
+	BodyType BodyType `json:"bodyType,omitempty"`
+
+	// ContentEncoding compresses body of request and sets
+	// Content-Encoding header. Body is not compressed by default
+	ContentEncoding ContentEncoding `json:"contentEncoding,omitempty"`
+
+	// Multipart defines a multipart/form-data body
+	// It can't be used with body
+	Multipart *Multipart `json:"multipart,omitempty"`
+
+	// GraphQL defines graphql request which is sent as body
+	// It is used if body type is graphql
+	GraphQL *GraphQL `json:"graphql,omitempty"`
+
+	// Messages defines messages sent by websocket
+	Messages []Message `json:"messages,omitempty"`
+
+	// DisableCookies disables cookie jar of context for the request
+	// Cookies are neither sent nor saved
+	DisableCookies bool `json:"disableCookies,omitempty"`
+
+	// FollowRedirects defines whether to follow redirects
+	// Default is true. If it is false, 3xx response will be matched
+	FollowRedirects *bool `json:"followRedirects,omitempty"`
+
+	// MaxRedirects defines max number of followed redirects
+	// Default is 10
+	MaxRedirects int `json:"maxRedirects,omitempty"`
+
+	// Timeout defines deadline of request
+	// Request has no timeout if it is nil
+	Timeout *Duration `json:"timeout,omitempty"`
+
+	// Retry defines retry policy of request
+	// Request will not be retried if it is nil
+	Retry *Retry `json:"retry,omitempty"`
+
+	// Hooks modify http request after it is built, e.g. sign request
+	// They are added by presetters and can't be defined in test data
+	Hooks []RequestHook `json:"-"`
+}
+
+// RequestHook modifies a built http request before it is sent
+type RequestHook func(req *http.Request) error
+
+// Retry defines retry policy of request
+// Request is retried when connection error occurred or
+// status code of response is retryable
+type Retry struct {
+	// MaxAttempts defines max attempts of request
+	// including the first one
+	MaxAttempts int `json:"maxAttempts"`
+
+	// Interval defines initial interval between two attempts
+	// Default interval is 100 millisecond
+	Interval *Duration `json:"interval,omitempty"`
+
+	// Multiplier defines multiplier of interval for exponential backoff
+	// Default multiplier is 1 which means interval is constant
+	Multiplier float64 `json:"multiplier,omitempty"`
+
+	// StatusCodes defines retryable status codes of response
+	StatusCodes []int `json:"statusCodes,omitempty"`
+}
+
+// BodyType defines encoding of request body
+type BodyType string
+
+const (
+	// JSONBody means body is sent as it is rendered
+	JSONBody BodyType = "json"
+
+	// FormBody means body is rendered as a json object and
+	// then encoded as application/x-www-form-urlencoded
+	FormBody BodyType = "form"
+
+	// XMLBody means body is sent as it is rendered with xml
+	// content type, and response body is matched as xml
+	XMLBody BodyType = "xml"
+
+	// GraphQLBody means graphql of request is wrapped into
+	// a standard graphql request, response is matched as json
+	GraphQLBody BodyType = "graphql"
+
+	// BinaryBody means body is base64 encoded and sent as raw bytes
+	// with octet-stream content type, and body file is sent as it is
+	// In response, base64 encoded body is compared with raw bytes
+	BinaryBody BodyType = "binary"
+)
+
+// ContentEncoding defines compression of request body
+type ContentEncoding string
+
+const (
+	// GzipEncoding compresses body by gzip
+	GzipEncoding ContentEncoding = "gzip"
+
+	// DeflateEncoding compresses body by zlib
+	DeflateEncoding ContentEncoding = "deflate"
+)
+
+// GraphQL defines a graphql request
+type GraphQL struct {
+	// Query is text of graphql query or mutation
+	Query *Template `json:"query"`
+
+	// Variables defines a json object of graphql variables
+	Variables *Template `json:"variables,omitempty"`
+
+	// OperationName selects operation if query has multiple operations
+	OperationName string `json:"operationName,omitempty"`
+}
+
+// Multipart defines a multipart/form-data body
+type Multipart struct {
+	// Fields defines form fields
+	Fields map[string]Template `json:"fields,omitempty"`
+
+	// Files defines file parts
+	Files []FilePart `json:"files,omitempty"`
+}
+
+// FilePart defines a file part of multipart body
+type FilePart struct {
+	// Name defines field name of file
+	Name string `json:"name"`
+
+	// Filename defines name of file
+	// Default is base name of path
+	Filename *Template `json:"filename,omitempty"`
+
+	// ContentType defines content type of file
+	// Default is application/octet-stream
+	ContentType string `json:"contentType,omitempty"`
+
+	// Content defines inline content of file
+	Content *Template `json:"content,omitempty"`
+
+	// Path defines path of file, it is relative to
+	// directory of test data
+	// It can't be used with content
+	Path *Template `json:"path,omitempty"`
+}
+
+// MessageType defines type of websocket message
+type MessageType string
+
+const (
+	// TextMessage is the default message type
+	TextMessage MessageType = "text"
+
+	// BinaryMessage defines binary message
+	// Body of binary message is base64 encoded
+	BinaryMessage MessageType = "binary"
+)
+
+// Message defines a websocket message
+type Message struct {
+	// Type defines type of message
+	// Default type is text
+	Type MessageType `json:"type,omitempty"`
+
+	// Body defines a template of message body
+	Body *Template `json:"body,omitempty"`
+}
+
+// TransformType defines how a field of response body is transformed
+type TransformType string
+
+const (
+	// JSONTransform parses a string field as json
+	JSONTransform TransformType = "json"
+
+	// Base64Transform decodes a base64 string field into string
+	Base64Transform TransformType = "base64"
+)
+
+// Transform defines a transformation of fields in response body
+type Transform struct {
+	// Path is jsonpath of transformed fields, e.g. $.data
+	Path string `json:"path"`
+
+	// Type defines how fields are transformed
+	Type TransformType `json:"type"`
 }
 
+// MatchMode defines mode of matching response body
+type MatchMode string
+
+const (
+	// DefaultMatchMode ignores extra fields of objects but
+	// requires arrays to have exactly expected elements
+	DefaultMatchMode MatchMode = ""
+
+	// SubsetMatchMode also ignores extra elements of arrays
+	// Expected elements should be matched in order
+	SubsetMatchMode MatchMode = "subset"
+)
+
 // Response defines a http response checker
 type Response struct {
 	// StatusCode checks response code
-	StatusCode int `json:"statusCode"`
+	// It can be a code, a class like 2xx or a list of them
+	StatusCode StatusCode `json:"statusCode"`
+
+	// Trailers defines matchers of trailers of response like headers
+	// Trailers of grpc response contain Grpc-Status and Grpc-Message
+	Trailers map[string]Template `json:"trailers,omitempty"`
+
+	// GRPCCode defines expected status code of grpc response, e.g.
+	// NotFound, NOT_FOUND or 5. Default code is OK
+	GRPCCode string `json:"grpcCode,omitempty"`
 
 	// Body is also a template like request body
 	// It can be used to generate a matcher which
 	// can test response body
 	Body *Template `json:"body,omitempty"`
 
+	// BodyType defines how body is matched
+	// Default body type is json
+	BodyType BodyType `json:"bodyType,omitempty"`
+
+	// MatchMode defines how body is matched
+	// It only works for json body
+	MatchMode MatchMode `json:"matchMode,omitempty"`
+
+	// IgnoreFields defines jsonpaths of fields which are removed from
+	// both expected and actual json body before they are matched
+	// e.g. $.id, $.items[*].createdAt
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+
+	// AbsentFields defines jsonpaths of fields which should not exist in
+	// response body, e.g. $.items[*].password. A field with null value
+	// also exists
+	AbsentFields []string `json:"absentFields,omitempty"`
+
+	// Transforms modify decoded response body in order before it is
+	// matched and used to define variables, e.g. parse a json string
+	Transforms []Transform `json:"transforms,omitempty"`
+
+	// JSONSchema defines a json schema (draft 7) of response body
+	// It can be used instead of or in addition to body
+	JSONSchema *Template `json:"jsonSchema,omitempty"`
+
+	// IsJSON checks that raw response body is valid json without
+	// matching its content, e.g. a huge or variable body
+	IsJSON bool `json:"isJSON,omitempty"`
+
+	// BodyChecksum defines checksum of raw response body in hex
+	// e.g. sha256:9f86d08... md5, sha1, sha256 and sha512 are supported
+	BodyChecksum *Template `json:"bodyChecksum,omitempty"`
+
+	// BodySize defines expected size of raw response body in bytes
+	BodySize *int64 `json:"bodySize,omitempty"`
+
+	// Stream means body is hashed and counted while it is read instead
+	// of being buffered, e.g. a large download. Only status, headers,
+	// cookies, size and checksum of body can be matched
+	Stream bool `json:"stream,omitempty"`
+
+	// Golden defines path of a golden file of response body, it is
+	// relative to dir of test data. Json body is normalized, i.e.
+	// ignored fields are removed and keys are sorted, before it is
+	// diffed with the file, and it is written into the file instead
+	// in update golden mode
+	Golden *Template `json:"golden,omitempty"`
+
+	// Headers defines matchers of headers of response
+	// A value matches the header literally, and special matchers
+	// like {"$regexp": "..."} or {"$exists": false} can be used
+	Headers map[string]Template `json:"headers,omitempty"`
+
+	// Cookies defines matchers of cookies set by response
+	// A value or special matcher matches value of cookie, and other
+	// objects match its attributes: value, path, domain, expires,
+	// maxAge, secure, httpOnly and sameSite
+	Cookies map[string]Template `json:"cookies,omitempty"`
+
+	// Messages defines matchers of received websocket messages or
+	// server-sent events. They are matched in order
+	Messages []*Template `json:"messages,omitempty"`
+
+	// MaxDuration defines max duration of a http request
+	// Duration is measured until response headers are received
+	MaxDuration *Duration `json:"maxDuration,omitempty"`
+
 	// Eventually defines an async checker for response
 	// It means response will eventually be matched
 	Eventually *Eventually `json:"eventually,omitempty"`
+
+	// Consistently defines an async checker for response
+	// It means response keeps matched for the whole duration
+	// If eventually is also set, it is checked after response
+	// is eventually matched
+	Consistently *Consistently `json:"consistently,omitempty"`
+}
+
+// StatusCode defines expected status codes of response
+// Each of them is a code like 200 or a class like 2xx
+type StatusCode []string
+
+// NewStatusCode returns status code which matches any of codes
+func NewStatusCode(codes ...int) StatusCode {
+	s := StatusCode{}
+	for _, code := range codes {
+		s = append(s, strconv.Itoa(code))
+	}
+	return s
+}
+
+// Match returns true if code is one of expected codes
+func (s StatusCode) Match(code int) bool {
+	c := strconv.Itoa(code)
+	for _, expected := range s {
+		if expected == c {
+			return true
+		}
+		if isStatusClass(expected) && len(c) == 3 && expected[0] == c[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns allowed status codes
+func (s StatusCode) String() string {
+	if len(s) == 1 {
+		return s[0]
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+// Status code can be a number, a string or a list of them
+func (s *StatusCode) UnmarshalJSON(body []byte) error {
+	trimmed := bytes.TrimSpace(body)
+	raws := []json.RawMessage{}
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return err
+		}
+	} else {
+		raws = append(raws, trimmed)
+	}
+	codes := StatusCode{}
+	for _, raw := range raws {
+		code := string(raw)
+		if unquoted, err := strconv.Unquote(code); err == nil {
+			code = unquoted
+		}
+		if !isStatusCode(code) && !isStatusClass(code) {
+			return fmt.Errorf("invalid status code %v, expected a code like 200 or a class like 2xx", code)
+		}
+		codes = append(codes, strings.ToLower(code))
+	}
+	*s = codes
+	return nil
+}
+
+func isStatusCode(code string) bool {
+	if len(code) != 3 || code[0] < '1' || code[0] > '5' {
+		return false
+	}
+	for _, r := range code[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isStatusClass(code string) bool {
+	return len(code) == 3 && code[0] >= '1' && code[0] <= '5' && strings.EqualFold(code[1:], "xx")
 }
 
 // Definition defines new variable from response
@@ -58,9 +534,59 @@ type Definition struct {
 	Name string `json:"name"`
 
 	// Selector select variable value from response
-	Selector []string `json:"selector"`
+	Selector []string `json:"selector,omitempty"`
+
+	// JSONPath selects variable value from response by jsonpath
+	// e.g. $.items[0].id
+	// It is used instead of selector if it is set
+	JSONPath string `json:"jsonPath,omitempty"`
+
+	// XPath selects variable value from xml response by xpath
+	// e.g. /envelope/body/id
+	XPath string `json:"xpath,omitempty"`
+
+	// Header selects variable value from response header
+	// e.g. Location. It is used instead of body if it is set
+	Header string `json:"header,omitempty"`
+
+	// Cookie selects variable value from value of cookie set by response
+	Cookie string `json:"cookie,omitempty"`
+
+	// Optional means variable is not defined instead of failure
+	// if header or cookie is missing
+	Optional bool `json:"optional,omitempty"`
+
+	// Secret means value of variable is masked in logs,
+	// failure messages and reports
+	Secret bool `json:"secret,omitempty"`
+
+	// Type declares type of variable, selected value is converted
+	// to it or it is failed, e.g. "42" in header can be an int
+	// Typed variables which are not strings are rendered without
+	// quotes if they are whole json strings in json, e.g. "%{count}"
+	Type VariableType `json:"type,omitempty"`
 }
 
+// VariableType defines declared type of variable
+type VariableType string
+
+const (
+	// StringVariable converts numbers and booleans to string
+	StringVariable VariableType = "string"
+
+	// IntVariable accepts integers and strings of integers
+	IntVariable VariableType = "int"
+
+	// NumberVariable accepts numbers and strings of numbers
+	NumberVariable VariableType = "number"
+
+	// BoolVariable accepts booleans and strings of booleans
+	BoolVariable VariableType = "bool"
+
+	// JSONVariable accepts any json value and strings of json
+	JSONVariable VariableType = "json"
+)
+
 // Template is used to get template from json
 type Template struct {
 	template.Template
@@ -68,13 +594,76 @@ type Template struct {
 	raw []byte
 }
 
+// Templates defines a list of templates
+// It can be unmarshaled from a single template, and numbers and
+// booleans are unmarshaled as literal templates, e.g. page: 1
+type Templates []Template
+
+// UnmarshalJSON implements json.Unmarshaler
+func (ts *Templates) UnmarshalJSON(body []byte) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		list := []json.RawMessage{}
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return err
+		}
+		templates := Templates{}
+		for _, raw := range list {
+			t, err := literalTemplate(raw)
+			if err != nil {
+				return err
+			}
+			templates = append(templates, t)
+		}
+		*ts = templates
+		return nil
+	}
+	t, err := literalTemplate(trimmed)
+	if err != nil {
+		return err
+	}
+	*ts = Templates{t}
+	return nil
+}
+
+// literalTemplate unmarshals template which may be a number or boolean
+func literalTemplate(raw []byte) (Template, error) {
+	t := Template{}
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] != '"' && !isJSONCollection(trimmed) {
+		trimmed = []byte(strconv.Quote(string(trimmed)))
+	}
+	err := t.UnmarshalJSON(trimmed)
+	return t, err
+}
+
 // Eventually defines config for eventually
 type Eventually struct {
 	// Timeout defines deadline of checking
+	// Default timeout is 1 second
+	Timeout *Duration `json:"timeout,omitempty"`
+
+	// Interval defines interval of polling and checking
+	// Default interval is 100 milliseconds
+	Interval *Duration `json:"interval,omitempty"`
+
+	// Multiplier defines multiplier of interval for exponential backoff
+	// Default multiplier is 1 which means interval is constant
+	Multiplier float64 `json:"multiplier,omitempty"`
+
+	// MaxInterval defines max interval of exponential backoff
+	// Interval is not limited if it is nil
+	MaxInterval *Duration `json:"maxInterval,omitempty"`
+}
+
+// Consistently defines config for consistently
+type Consistently struct {
+	// Timeout defines duration of checking
+	// Default timeout is 1 second
 	Timeout *Duration `json:"timeout,omitempty"`
 
 	// Interval defines interval of polling and checking
-	// Default interval is 1 second
+	// Default interval is 100 milliseconds
 	Interval *Duration `json:"interval,omitempty"`
 }
 
@@ -102,16 +691,39 @@ func (d *Duration) UnmarshalJSON(body []byte) error {
 	return nil
 }
 
+// NewTemplate returns a template from raw string
+func NewTemplate(raw string) (*Template, error) {
+	templ, err := template.New(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{
+		Template: templ,
+		raw:      []byte(raw),
+	}, nil
+}
+
+// Raw returns raw string of template
+func (t *Template) Raw() string {
+	return string(t.raw)
+}
+
 // MarshalJSON implements json.Marshaler
 func (t *Template) MarshalJSON() ([]byte, error) {
 	return t.raw, nil
 }
 
 // UnmarshalJSON implements json.Marshaler
+// Template can be a json string or a json object (array)
+// which is used as raw template
 func (t *Template) UnmarshalJSON(body []byte) error {
-	s, err := strconv.Unquote(string(body))
-	if err != nil {
-		return err
+	s := string(body)
+	if !isJSONCollection(body) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return err
+		}
+		s = unquoted
 	}
 	templ, err := template.New(s)
 	if err != nil {
@@ -121,3 +733,8 @@ func (t *Template) UnmarshalJSON(body []byte) error {
 	t.raw = []byte(s)
 	return nil
 }
+
+func isJSONCollection(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusCode(t *testing.T) {
+	cases := []struct {
+		raw      string
+		code     int
+		matched  bool
+		hasError bool
+	}{
+		{`200`, 200, true, false},
+		{`200`, 201, false, false},
+		{`"201"`, 201, true, false},
+		{`[200, 201]`, 201, true, false},
+		{`[200, 201]`, 204, false, false},
+		{`"2xx"`, 204, true, false},
+		{`"2XX"`, 299, true, false},
+		{`"2xx"`, 301, false, false},
+		{`[404, "2xx"]`, 404, true, false},
+		{`"600"`, 0, false, true},
+		{`"2x"`, 0, false, true},
+		{`[200, "abc"]`, 0, false, true},
+	}
+	for _, c := range cases {
+		s := StatusCode{}
+		err := json.Unmarshal([]byte(c.raw), &s)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		assert.NoError(t, err, c.raw)
+		assert.Equal(t, c.matched, s.Match(c.code), "status code: %v, code: %v", c.raw, c.code)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	cases := []struct {
+		raw      string
+		expected []string
+	}{
+		{`"a"`, []string{"a"}},
+		{`["a", "%{b}"]`, []string{"a", "%{b}"}},
+		{`1`, []string{"1"}},
+		{`[1, true, "c"]`, []string{"1", "true", "c"}},
+	}
+	for _, c := range cases {
+		ts := Templates{}
+		if !assert.NoError(t, json.Unmarshal([]byte(c.raw), &ts), c.raw) {
+			continue
+		}
+		raws := []string{}
+		for _, t := range ts {
+			raws = append(raws, t.Raw())
+		}
+		assert.Equal(t, c.expected, raws, c.raw)
+	}
+}
@@ -0,0 +1,78 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+)
+
+// Cast converts variable to declared type and marks it as typed
+// It returns error if value of variable can't be converted
+func Cast(v *template.Variable, t types.VariableType) error {
+	if t == "" {
+		return nil
+	}
+	raw, jt, err := cast(v, t)
+	if err != nil {
+		return fmt.Errorf("can't convert variable %v to %v: %v", v.Name, t, err)
+	}
+	v.Raw, v.Type, v.Typed = raw, jt, true
+	return nil
+}
+
+func cast(v *template.Variable, t types.VariableType) ([]byte, template.JSONType, error) {
+	isString := v.Type == template.StringType
+	switch t {
+	case types.StringVariable:
+		switch v.Type {
+		case template.StringType, template.NumberType, template.BooleanType:
+			return v.Raw, template.StringType, nil
+		}
+	case types.IntVariable:
+		if isString || v.Type == template.NumberType {
+			if _, err := strconv.ParseInt(string(v.Raw), 10, 64); err == nil {
+				return v.Raw, template.NumberType, nil
+			}
+		}
+	case types.NumberVariable:
+		if isString || v.Type == template.NumberType {
+			if _, err := strconv.ParseFloat(string(v.Raw), 64); err == nil {
+				return v.Raw, template.NumberType, nil
+			}
+		}
+	case types.BoolVariable:
+		if isString || v.Type == template.BooleanType {
+			if b, err := strconv.ParseBool(string(v.Raw)); err == nil {
+				return []byte(strconv.FormatBool(b)), template.BooleanType, nil
+			}
+		}
+	case types.JSONVariable:
+		if !isString {
+			return v.Raw, v.Type, nil
+		}
+		raw := v.Raw
+		if !json.Valid(raw) {
+			// values of strings in json are escaped
+			s := ""
+			if err := json.Unmarshal([]byte(`"`+string(raw)+`"`), &s); err != nil {
+				return nil, "", fmt.Errorf("invalid json %v", valueOf(v))
+			}
+			raw = []byte(s)
+		}
+		return GetByJSONPath(raw, "$")
+	default:
+		return nil, "", fmt.Errorf("unknown type %v", t)
+	}
+	return nil, "", fmt.Errorf("invalid %v value %v", v.Type, valueOf(v))
+}
+
+// valueOf returns value of variable in error messages
+func valueOf(v *template.Variable) string {
+	if v.Secret {
+		return template.Masked
+	}
+	return string(v.Raw)
+}
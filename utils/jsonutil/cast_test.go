@@ -0,0 +1,51 @@
+package jsonutil
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCast(t *testing.T) {
+	cases := []struct {
+		raw      string
+		from     template.JSONType
+		to       types.VariableType
+		out      string
+		typ      template.JSONType
+		hasError bool
+	}{
+		{"42", template.StringType, types.IntVariable, "42", template.NumberType, false},
+		{"42", template.NumberType, types.StringVariable, "42", template.StringType, false},
+		{"4.2", template.NumberType, types.IntVariable, "", "", true},
+		{"4.2", template.StringType, types.NumberVariable, "4.2", template.NumberType, false},
+		{"abc", template.StringType, types.NumberVariable, "", "", true},
+		{"TRUE", template.StringType, types.BoolVariable, "true", template.BooleanType, false},
+		{"yes", template.StringType, types.BoolVariable, "", "", true},
+		{`{"a":1}`, template.ObjectType, types.StringVariable, "", "", true},
+		{`{\"a\":1}`, template.StringType, types.JSONVariable, `{"a":1}`, template.ObjectType, false},
+		{`[1, 2]`, template.StringType, types.JSONVariable, `[1,2]`, template.ArrayType, false},
+		{`{"a":1}`, template.ObjectType, types.JSONVariable, `{"a":1}`, template.ObjectType, false},
+		{"abc", template.StringType, types.JSONVariable, "", "", true},
+		{"abc", template.StringType, "date", "", "", true},
+	}
+	for _, c := range cases {
+		v := &template.Variable{
+			Raw:  []byte(c.raw),
+			Name: "v",
+			Type: c.from,
+		}
+		err := Cast(v, c.to)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		if assert.NoError(t, err, c.raw) {
+			assert.Equal(t, c.out, string(v.Raw), c.raw)
+			assert.Equal(t, c.typ, v.Type, c.raw)
+			assert.True(t, v.Typed, c.raw)
+		}
+	}
+}
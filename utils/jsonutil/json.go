@@ -9,7 +9,19 @@ import (
 )
 
 // GetVariable returns a variable from raw json
+// Value is selected by jsonpath if it is set, otherwise by selector
 func GetVariable(rawJSON []byte, def *types.Definition) (*template.Variable, error) {
+	if def.JSONPath != "" {
+		v, t, err := GetByJSONPath(rawJSON, def.JSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("can't get variable %v from json: %v", def.Name, err)
+		}
+		return &template.Variable{
+			Raw:  v,
+			Name: def.Name,
+			Type: t,
+		}, nil
+	}
 	v, dt, _, err := jsonparser.Get(rawJSON, def.Selector...)
 	if err != nil {
 		return nil, fmt.Errorf("can't get variable %v from json with selector %v: %v", def.Name, def.Selector, err)
@@ -0,0 +1,382 @@
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caicloud/aloe/template"
+)
+
+// segment is a step of jsonpath
+type segment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses a subset of jsonpath
+// Supported syntax:
+//
+//	$          root
+//	.name      child of object
+//	['name']   child of object
+//	[0], [-1]  element of array
+//	.*, [*]    all children
+func parseJSONPath(path string) ([]segment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("jsonpath %v should start with $", path)
+	}
+	segs := []segment{}
+	for i := 1; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			name := path[i:end]
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath %v has empty name at %v, recursive descent is not supported", path, i)
+			}
+			if name == "*" {
+				segs = append(segs, segment{wildcard: true})
+			} else {
+				segs = append(segs, segment{key: name})
+			}
+			i = end
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("jsonpath %v has unclosed [ at %v", path, i)
+			}
+			content := strings.TrimSpace(path[i+1 : i+end])
+			seg, err := parseBracket(content)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %v is invalid at %v: %v", path, i, err)
+			}
+			segs = append(segs, seg)
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("jsonpath %v has unexpected %q at %v", path, path[i], i)
+		}
+	}
+	return segs, nil
+}
+
+func parseBracket(content string) (segment, error) {
+	if content == "*" {
+		return segment{wildcard: true}, nil
+	}
+	if len(content) >= 2 {
+		q := content[0]
+		if (q == '\'' || q == '"') && content[len(content)-1] == q {
+			return segment{key: content[1 : len(content)-1]}, nil
+		}
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return segment{}, fmt.Errorf("%q is neither a quoted name nor an index", content)
+	}
+	return segment{index: index, isIndex: true}, nil
+}
+
+func (s *segment) apply(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			children := []interface{}{}
+			for _, k := range keys {
+				children = append(children, t[k])
+			}
+			return children
+		}
+		if s.isIndex {
+			return nil
+		}
+		child, ok := t[s.key]
+		if !ok {
+			return nil
+		}
+		return []interface{}{child}
+	case []interface{}:
+		if s.wildcard {
+			return t
+		}
+		if !s.isIndex {
+			return nil
+		}
+		index := s.index
+		if index < 0 {
+			index += len(t)
+		}
+		if index < 0 || index >= len(t) {
+			return nil
+		}
+		return []interface{}{t[index]}
+	}
+	return nil
+}
+
+// GetByJSONPath returns the only value selected by jsonpath
+// It returns error if no value or more than one value is selected
+func GetByJSONPath(rawJSON []byte, path string) ([]byte, template.JSONType, error) {
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		return nil, "", err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, "", fmt.Errorf("can't unmarshal body to json: %v", err)
+	}
+
+	values := selectSegments(root, segs)
+	switch len(values) {
+	case 0:
+		return nil, "", fmt.Errorf("no value matches jsonpath %v", path)
+	case 1:
+	default:
+		return nil, "", fmt.Errorf("%v values match jsonpath %v, expected only one", len(values), path)
+	}
+	return encodeValue(values[0])
+}
+
+// SelectByJSONPath returns all values selected by jsonpath in a decoded json value
+func SelectByJSONPath(v interface{}, path string) ([]interface{}, error) {
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return selectSegments(v, segs), nil
+}
+
+func selectSegments(root interface{}, segs []segment) []interface{} {
+	values := []interface{}{root}
+	for i := range segs {
+		next := []interface{}{}
+		for _, v := range values {
+			next = append(next, segs[i].apply(v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+// DeleteByJSONPath removes all values selected by jsonpath from a
+// decoded json value and returns the modified value
+// Nothing is removed if no value is selected
+func DeleteByJSONPath(v interface{}, path string) (interface{}, error) {
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("root can't be deleted by jsonpath %v", path)
+	}
+	return deleteSegments(v, segs), nil
+}
+
+func deleteSegments(v interface{}, segs []segment) interface{} {
+	s, last := &segs[0], len(segs) == 1
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if s.isIndex {
+			return t
+		}
+		for k := range t {
+			if !s.wildcard && k != s.key {
+				continue
+			}
+			if last {
+				delete(t, k)
+			} else {
+				t[k] = deleteSegments(t[k], segs[1:])
+			}
+		}
+		return t
+	case []interface{}:
+		if !s.wildcard && !s.isIndex {
+			return t
+		}
+		index := s.index
+		if index < 0 {
+			index += len(t)
+		}
+		if !s.wildcard && (index < 0 || index >= len(t)) {
+			return t
+		}
+		switch {
+		case last && s.wildcard:
+			return []interface{}{}
+		case last:
+			return append(append([]interface{}{}, t[:index]...), t[index+1:]...)
+		case s.wildcard:
+			for i := range t {
+				t[i] = deleteSegments(t[i], segs[1:])
+			}
+		default:
+			t[index] = deleteSegments(t[index], segs[1:])
+		}
+		return t
+	}
+	return v
+}
+
+// LocateByJSONPath returns concrete jsonpaths of all values selected by
+// jsonpath in a decoded json value, e.g. $.items[1].id for $.items[*].id
+func LocateByJSONPath(v interface{}, path string) ([]string, error) {
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return locateSegments(v, segs, "$"), nil
+}
+
+func locateSegments(v interface{}, segs []segment, prefix string) []string {
+	if len(segs) == 0 {
+		return []string{prefix}
+	}
+	s := &segs[0]
+	located := []string{}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if s.isIndex {
+			return nil
+		}
+		keys := []string{}
+		for k := range t {
+			if s.wildcard || k == s.key {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			located = append(located, locateSegments(t[k], segs[1:], prefix+childPath(k))...)
+		}
+	case []interface{}:
+		if !s.wildcard && !s.isIndex {
+			return nil
+		}
+		for i := range t {
+			index := s.index
+			if index < 0 {
+				index += len(t)
+			}
+			if s.wildcard || i == index {
+				located = append(located, locateSegments(t[i], segs[1:], fmt.Sprintf("%v[%v]", prefix, i))...)
+			}
+		}
+	}
+	return located
+}
+
+// childPath returns jsonpath of child of object with key
+func childPath(key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]'\"*") {
+		return "['" + key + "']"
+	}
+	return "." + key
+}
+
+// UpdateByJSONPath replaces all values selected by jsonpath in a decoded
+// json value with results of fn and returns the modified value
+// It returns error if no value is selected or fn returns error
+func UpdateByJSONPath(v interface{}, path string, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	updated := 0
+	v, err = updateSegments(v, segs, fn, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if updated == 0 {
+		return nil, fmt.Errorf("no value matches jsonpath %v", path)
+	}
+	return v, nil
+}
+
+func updateSegments(v interface{}, segs []segment, fn func(interface{}) (interface{}, error), updated *int) (interface{}, error) {
+	if len(segs) == 0 {
+		*updated++
+		return fn(v)
+	}
+	s := &segs[0]
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if s.isIndex {
+			return t, nil
+		}
+		for k := range t {
+			if !s.wildcard && k != s.key {
+				continue
+			}
+			child, err := updateSegments(t[k], segs[1:], fn, updated)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = child
+		}
+		return t, nil
+	case []interface{}:
+		if !s.wildcard && !s.isIndex {
+			return t, nil
+		}
+		for i := range t {
+			index := s.index
+			if index < 0 {
+				index += len(t)
+			}
+			if !s.wildcard && i != index {
+				continue
+			}
+			child, err := updateSegments(t[i], segs[1:], fn, updated)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = child
+		}
+		return t, nil
+	}
+	return v, nil
+}
+
+// encodeValue encodes value like jsonparser
+// value of string is not quoted
+func encodeValue(v interface{}) ([]byte, template.JSONType, error) {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, "", err
+	}
+	raw := bytes.TrimSpace(buf.Bytes())
+	switch v.(type) {
+	case string:
+		return raw[1 : len(raw)-1], template.StringType, nil
+	case json.Number:
+		return raw, template.NumberType, nil
+	case bool:
+		return raw, template.BooleanType, nil
+	case nil:
+		return raw, template.NullType, nil
+	case map[string]interface{}:
+		return raw, template.ObjectType, nil
+	case []interface{}:
+		return raw, template.ArrayType, nil
+	}
+	return nil, "", fmt.Errorf("unknown type %T", v)
+}
@@ -0,0 +1,169 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetByJSONPath(t *testing.T) {
+	body := []byte(`{"data": {"items": [{"id": "a<b", "n": 1}, {"id": "b", "n": 2.5}], "ok": true, "none": null}}`)
+	cases := []struct {
+		path     string
+		raw      string
+		typ      template.JSONType
+		hasError bool
+	}{
+		{"$.data.items[0].id", "a<b", template.StringType, false},
+		{"$.data.items[-1].n", "2.5", template.NumberType, false},
+		{"$['data']['ok']", "true", template.BooleanType, false},
+		{"$.data.none", "null", template.NullType, false},
+		{"$.data.items[1]", `{"id":"b","n":2.5}`, template.ObjectType, false},
+		{"$.data.items[*].id", "", "", true},
+		{"$.data.items[2]", "", "", true},
+		{"$.data.missing", "", "", true},
+		{"$..id", "", "", true},
+		{"data.items", "", "", true},
+		{"$.data.items[x]", "", "", true},
+	}
+	for _, c := range cases {
+		raw, typ, err := GetByJSONPath(body, c.path)
+		if c.hasError {
+			assert.Error(t, err, c.path)
+			continue
+		}
+		if assert.NoError(t, err, c.path) {
+			assert.Equal(t, c.raw, string(raw), c.path)
+			assert.Equal(t, c.typ, typ, c.path)
+		}
+	}
+}
+
+func TestDeleteByJSONPath(t *testing.T) {
+	body := `{"id": "1", "data": {"items": [{"id": "a", "n": 1}, {"id": "b", "n": 2}], "etag": "x"}}`
+	cases := []struct {
+		paths    []string
+		expected string
+		hasError bool
+	}{
+		{[]string{"$.id", "$.data.etag"}, `{"data": {"items": [{"id": "a", "n": 1}, {"id": "b", "n": 2}]}}`, false},
+		{[]string{"$.data.items[*].id"}, `{"id": "1", "data": {"items": [{"n": 1}, {"n": 2}], "etag": "x"}}`, false},
+		{[]string{"$.data.items[-1]"}, `{"id": "1", "data": {"items": [{"id": "a", "n": 1}], "etag": "x"}}`, false},
+		{[]string{"$['data'].*"}, `{"id": "1", "data": {}}`, false},
+		{[]string{"$.missing", "$.data.items[5]", "$.id.x"}, body, false},
+		{[]string{"$"}, "", true},
+		{[]string{"id"}, "", true},
+	}
+	for _, c := range cases {
+		var v interface{}
+		assert.NoError(t, json.Unmarshal([]byte(body), &v))
+		var err error
+		for _, p := range c.paths {
+			if v, err = DeleteByJSONPath(v, p); err != nil {
+				break
+			}
+		}
+		if c.hasError {
+			assert.Error(t, err, "%v", c.paths)
+			continue
+		}
+		if assert.NoError(t, err, "%v", c.paths) {
+			actual, _ := json.Marshal(v)
+			assert.JSONEq(t, c.expected, string(actual), "%v", c.paths)
+		}
+	}
+}
+
+func TestUpdateByJSONPath(t *testing.T) {
+	body := `{"id": "1", "data": {"items": [{"id": "a"}, {"id": "b"}]}}`
+	upper := func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", v)
+		}
+		return strings.ToUpper(s), nil
+	}
+	cases := []struct {
+		path     string
+		expected string
+		hasError bool
+	}{
+		{"$.data.items[0].id", `{"id": "1", "data": {"items": [{"id": "A"}, {"id": "b"}]}}`, false},
+		{"$.data.items[*].id", `{"id": "1", "data": {"items": [{"id": "A"}, {"id": "B"}]}}`, false},
+		{"$.data.items[-1].id", `{"id": "1", "data": {"items": [{"id": "a"}, {"id": "B"}]}}`, false},
+		{"$.data", "", true},
+		{"$.missing", "", true},
+		{"id", "", true},
+	}
+	for _, c := range cases {
+		var v interface{}
+		assert.NoError(t, json.Unmarshal([]byte(body), &v))
+		v, err := UpdateByJSONPath(v, c.path, upper)
+		if c.hasError {
+			assert.Error(t, err, c.path)
+			continue
+		}
+		if assert.NoError(t, err, c.path) {
+			actual, _ := json.Marshal(v)
+			assert.JSONEq(t, c.expected, string(actual), c.path)
+		}
+	}
+}
+
+func TestLocateByJSONPath(t *testing.T) {
+	body := `{"id": "1", "a.b": {"password": "x"}, "items": [{"password": "y"}, {"id": "b"}, {"password": null}]}`
+	cases := []struct {
+		path     string
+		located  []string
+		hasError bool
+	}{
+		{"$.items[*].password", []string{"$.items[0].password", "$.items[2].password"}, false},
+		{"$.*.password", []string{"$['a.b'].password"}, false},
+		{"$.items[-1]", []string{"$.items[2]"}, false},
+		{"$.password", []string{}, false},
+		{"$", []string{"$"}, false},
+		{"password", nil, true},
+	}
+	for _, c := range cases {
+		var v interface{}
+		assert.NoError(t, json.Unmarshal([]byte(body), &v))
+		located, err := LocateByJSONPath(v, c.path)
+		if c.hasError {
+			assert.Error(t, err, c.path)
+			continue
+		}
+		if assert.NoError(t, err, c.path) {
+			assert.Equal(t, c.located, located, c.path)
+		}
+	}
+}
+
+func TestSelectByJSONPath(t *testing.T) {
+	body := `{"id": "1", "items": [{"id": "a"}, {"name": "b"}, {"id": 3}]}`
+	cases := []struct {
+		path     string
+		selected []interface{}
+		hasError bool
+	}{
+		{"$.items[*].id", []interface{}{"a", float64(3)}, false},
+		{"$.items[1]", []interface{}{map[string]interface{}{"name": "b"}}, false},
+		{"$.name", []interface{}{}, false},
+		{"id", nil, true},
+	}
+	for _, c := range cases {
+		var v interface{}
+		assert.NoError(t, json.Unmarshal([]byte(body), &v))
+		selected, err := SelectByJSONPath(v, c.path)
+		if c.hasError {
+			assert.Error(t, err, c.path)
+			continue
+		}
+		if assert.NoError(t, err, c.path) {
+			assert.Equal(t, c.selected, selected, c.path)
+		}
+	}
+}
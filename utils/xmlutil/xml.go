@@ -0,0 +1,127 @@
+package xmlutil
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Node defines an element of xml
+// Whitespace around text is trimmed
+type Node struct {
+	Name     xml.Name
+	Attrs    map[string]string
+	Text     string
+	Children []*Node
+}
+
+// Parse parses xml to node tree and returns the root element
+func Parse(data []byte) (*Node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *Node
+	stack := []*Node{}
+	texts := []*strings.Builder{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't parse xml: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &Node{
+				Name:  t.Name,
+				Attrs: map[string]string{},
+			}
+			for _, attr := range t.Attr {
+				n.Attrs[attrName(attr.Name)] = attr.Value
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("can't parse xml: more than one root element")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			}
+			stack = append(stack, n)
+			texts = append(texts, &strings.Builder{})
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			n.Text = strings.TrimSpace(texts[len(texts)-1].String())
+			stack = stack[:len(stack)-1]
+			texts = texts[:len(texts)-1]
+		case xml.CharData:
+			if len(texts) > 0 {
+				texts[len(texts)-1].Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("can't parse xml: no root element")
+	}
+	return root, nil
+}
+
+func attrName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// Diff compares two node trees structurally
+// Order of attributes and whitespace around text are ignored
+// Each difference is reported with its path, e.g. /a/b[2]
+func Diff(expected, actual *Node) []error {
+	return diff("/"+expected.Name.Local, expected, actual)
+}
+
+func diff(path string, expected, actual *Node) []error {
+	if expected.Name != actual.Name {
+		return []error{fmt.Errorf("%v: element is not matched, expected: %v, actual: %v", path, expected.Name.Local, actual.Name.Local)}
+	}
+	errs := []error{}
+	names := []string{}
+	for k := range expected.Attrs {
+		names = append(names, k)
+	}
+	for k := range actual.Attrs {
+		if _, ok := expected.Attrs[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		e, eok := expected.Attrs[k]
+		a, aok := actual.Attrs[k]
+		switch {
+		case !aok:
+			errs = append(errs, fmt.Errorf("%v/@%v: attribute is missing", path, k))
+		case !eok:
+			errs = append(errs, fmt.Errorf("%v/@%v: unexpected attribute", path, k))
+		case e != a:
+			errs = append(errs, fmt.Errorf("%v/@%v: attribute is not matched, expected: %q, actual: %q", path, k, e, a))
+		}
+	}
+	if expected.Text != actual.Text {
+		errs = append(errs, fmt.Errorf("%v: text is not matched, expected: %q, actual: %q", path, expected.Text, actual.Text))
+	}
+	if len(expected.Children) != len(actual.Children) {
+		errs = append(errs, fmt.Errorf("%v: unexpected number of child elements, expected: %v, actual: %v", path, len(expected.Children), len(actual.Children)))
+		return errs
+	}
+	positions := map[string]int{}
+	for i, child := range expected.Children {
+		positions[child.Name.Local]++
+		childPath := fmt.Sprintf("%v/%v[%v]", path, child.Name.Local, positions[child.Name.Local])
+		errs = append(errs, diff(childPath, child, actual.Children[i])...)
+	}
+	return errs
+}
@@ -0,0 +1,60 @@
+package xmlutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		expected string
+		actual   string
+		errs     int
+	}{
+		{`<a x="1" y="2"><b>t</b></a>`, `<a y="2" x="1">
+			<b> t </b>
+		</a>`, 0},
+		{`<a><b>t</b></a>`, `<a><b>u</b></a>`, 1},
+		{`<a x="1"/>`, `<a x="2" z="3"/>`, 2},
+		{`<a><b/><c/></a>`, `<a><c/><b/></a>`, 2},
+		{`<a><b/></a>`, `<a><b/><b/></a>`, 1},
+	}
+	for _, c := range cases {
+		e, err := Parse([]byte(c.expected))
+		assert.NoError(t, err)
+		a, err := Parse([]byte(c.actual))
+		assert.NoError(t, err)
+		assert.Len(t, Diff(e, a), c.errs, "expected: %v, actual: %v", c.expected, c.actual)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	root, err := Parse([]byte(`<r><item id="1">a</item><item id="2">b</item><other>c</other></r>`))
+	assert.NoError(t, err)
+	cases := []struct {
+		xpath    string
+		value    string
+		hasError bool
+	}{
+		{"/r/other", "c", false},
+		{"/r/item[2]", "b", false},
+		{"/r/item[1]/@id", "1", false},
+		{"/r/other/text()", "c", false},
+		{"/r/item", "", true},
+		{"/r/*[3]", "c", false},
+		{"/r/item[3]", "", true},
+		{"/r/other/@id", "", true},
+		{"//item", "", true},
+		{"/r/item[0]", "", true},
+	}
+	for _, c := range cases {
+		v, err := Select(root, c.xpath)
+		if c.hasError {
+			assert.Error(t, err, c.xpath)
+			continue
+		}
+		assert.NoError(t, err, c.xpath)
+		assert.Equal(t, c.value, v, c.xpath)
+	}
+}
@@ -0,0 +1,127 @@
+package xmlutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/types"
+)
+
+// step is a step of xpath
+type step struct {
+	name     string
+	position int
+}
+
+// Select returns the only value selected by xpath
+// Supported syntax is a subset of xpath:
+//
+//	/a/b     child elements with name, * means any name
+//	/a/b[2]  child element by position which starts from 1
+//	/a/@id   attribute of element, it should be the last step
+//	/a/text() text of element, same as /a
+//
+// Text of element is returned if an element is selected
+func Select(root *Node, xpath string) (string, error) {
+	if !strings.HasPrefix(xpath, "/") || strings.HasPrefix(xpath, "//") {
+		return "", fmt.Errorf("xpath %v should be an absolute path without //", xpath)
+	}
+	parts := strings.Split(xpath[1:], "/")
+	attr := ""
+	switch last := parts[len(parts)-1]; {
+	case strings.HasPrefix(last, "@"):
+		attr = last[1:]
+		parts = parts[:len(parts)-1]
+	case last == "text()":
+		parts = parts[:len(parts)-1]
+	}
+	steps := []step{}
+	for _, p := range parts {
+		s, err := parseStep(p)
+		if err != nil {
+			return "", fmt.Errorf("xpath %v is invalid: %v", xpath, err)
+		}
+		steps = append(steps, s)
+	}
+	if len(steps) == 0 {
+		return "", fmt.Errorf("xpath %v should select an element", xpath)
+	}
+
+	nodes := []*Node{{Children: []*Node{root}}}
+	for _, s := range steps {
+		next := []*Node{}
+		for _, n := range nodes {
+			next = append(next, s.apply(n)...)
+		}
+		nodes = next
+	}
+	switch len(nodes) {
+	case 0:
+		return "", fmt.Errorf("no element matches xpath %v", xpath)
+	case 1:
+	default:
+		return "", fmt.Errorf("%v elements match xpath %v, expected only one", len(nodes), xpath)
+	}
+	if attr == "" {
+		return nodes[0].Text, nil
+	}
+	v, ok := nodes[0].Attrs[attr]
+	if !ok {
+		return "", fmt.Errorf("attribute %v is not found by xpath %v", attr, xpath)
+	}
+	return v, nil
+}
+
+func parseStep(s string) (step, error) {
+	if s == "" {
+		return step{}, fmt.Errorf("empty step")
+	}
+	i := strings.IndexByte(s, '[')
+	if i == -1 {
+		return step{name: s}, nil
+	}
+	if !strings.HasSuffix(s, "]") {
+		return step{}, fmt.Errorf("unclosed [ in %v", s)
+	}
+	position, err := strconv.Atoi(s[i+1 : len(s)-1])
+	if err != nil || position < 1 {
+		return step{}, fmt.Errorf("position of %v should be a positive integer", s)
+	}
+	return step{name: s[:i], position: position}, nil
+}
+
+func (s *step) apply(n *Node) []*Node {
+	matched := []*Node{}
+	for _, child := range n.Children {
+		if s.name == "*" || child.Name.Local == s.name {
+			matched = append(matched, child)
+		}
+	}
+	if s.position == 0 {
+		return matched
+	}
+	if s.position > len(matched) {
+		return nil
+	}
+	return matched[s.position-1 : s.position]
+}
+
+// GetVariable returns a variable from raw xml by xpath of definition
+// Value of variable is always a string
+func GetVariable(rawXML []byte, def *types.Definition) (*template.Variable, error) {
+	root, err := Parse(rawXML)
+	if err != nil {
+		return nil, fmt.Errorf("can't get variable %v from xml: %v", def.Name, err)
+	}
+	v, err := Select(root, def.XPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't get variable %v from xml: %v", def.Name, err)
+	}
+	return &template.Variable{
+		Raw:  []byte(v),
+		Name: def.Name,
+		Type: template.StringType,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/template"
+	"github.com/caicloud/aloe/utils/jsonutil"
+)
+
+const (
+	// EnvironmentEnv defines env of environment name which selects
+	// variables of environments in variables files
+	EnvironmentEnv = "ALOE_ENV"
+)
+
+// WithEnvironment selects variables of environment in variables files
+// It overrides EnvironmentEnv
+func WithEnvironment(env string) Option {
+	return func(gf *genericFramework) {
+		gf.env = env
+	}
+}
+
+// dirVariables returns variables defined by variables file of dir
+// Variables of current environment override default ones
+func (gf *genericFramework) dirVariables(dir *data.Dir) (map[string]template.Variable, error) {
+	vs := map[string]template.Variable{}
+	for _, m := range []map[string]json.RawMessage{dir.Variables.Variables, dir.Variables.Environments[gf.env]} {
+		for name, raw := range m {
+			value, t, err := jsonutil.GetByJSONPath(raw, "$")
+			if err != nil {
+				return nil, fmt.Errorf("can't read variable %v in %v: %v", name, dir.Path, err)
+			}
+			vs[name] = template.Variable{
+				Raw:  value,
+				Name: name,
+				Type: t,
+			}
+		}
+	}
+	return vs, nil
+}
+
+// validateVariables checks variables files of dir and all sub dirs
+func (gf *genericFramework) validateVariables(dir *data.Dir) error {
+	if _, err := gf.dirVariables(dir); err != nil {
+		return err
+	}
+	for _, d := range dir.Dirs {
+		if err := gf.validateVariables(&d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withVariables returns a copy of vs with variables in dirVs
+func withVariables(vs, dirVs map[string]template.Variable) map[string]template.Variable {
+	newVs := copyVariables(vs)
+	for k, v := range dirVs {
+		newVs[k] = v
+	}
+	return newVs
+}
@@ -0,0 +1,52 @@
+package framework
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEnvironment(t *testing.T) {
+	fsys := fstest.MapFS{
+		"products/_context.yaml": {Data: []byte(`summary: "Products"`)},
+		"products/variables.yaml": {Data: []byte(`variables:
+  host: "localhost"
+  tenant: "t1"
+environments:
+  staging:
+    host: "staging.example.com"
+  production:
+    host: "example.com"
+`)},
+	}
+	defer os.Setenv(EnvironmentEnv, os.Getenv(EnvironmentEnv))
+	cases := []struct {
+		description string
+		env         string
+		opts        []Option
+		host        string
+	}{
+		{"default variables", "", nil, `localhost`},
+		{"environment selected by env", "staging", nil, `staging.example.com`},
+		{"option overrides env", "staging", []Option{WithEnvironment("production")}, `example.com`},
+		{"unknown environment", "", []Option{WithEnvironment("dev")}, `localhost`},
+	}
+	for _, c := range cases {
+		os.Setenv(EnvironmentEnv, c.env)
+		opts := append([]Option{WithDataFS(fsys, "products")}, c.opts...)
+		gf := NewFrameworkWithOptions("localhost", func() {}, nil, opts...).(*genericFramework)
+		dirs, err := gf.load()
+		if !assert.NoError(t, err, c.description) || !assert.Len(t, dirs, 1, c.description) {
+			continue
+		}
+		vs, err := gf.dirVariables(dirs[0])
+		if !assert.NoError(t, err, c.description) {
+			continue
+		}
+		assert.Equal(t, c.host, string(vs["host"].Raw), c.description)
+		// variables which aren't overridden are kept
+		assert.Equal(t, "t1", string(vs["tenant"].Raw), c.description)
+	}
+}